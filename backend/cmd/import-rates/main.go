@@ -0,0 +1,54 @@
+// Command import-rates mengimpor feed harga referensi (emas, perak, beras, fitrah) dari file
+// CSV/JSON lokal ke tabel riwayat harga.
+//
+//	go run ./cmd/import-rates                       # feed dari REFERENCE_RATE_FEED
+//	go run ./cmd/import-rates -file rates-2026.csv  # feed tertentu
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"zakat/pkg/postgres"
+	"zakat/repositories"
+	"zakat/services"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	file := flag.String("file", "", "CSV or JSON feed to import (default REFERENCE_RATE_FEED)")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	config := services.RateImportConfigFromEnv()
+	path := *file
+	if path == "" {
+		path = config.FeedPath
+	}
+	if path == "" {
+		log.Fatal("No feed given; use -file or set REFERENCE_RATE_FEED")
+	}
+
+	postgres.DatabaseInit()
+	importer := services.NewRateImporter(config, repositories.NewReferenceRateRepository(postgres.DB))
+
+	result, err := importer.ImportFile(context.Background(), path)
+	if err != nil {
+		log.Fatal("Import failed: ", err)
+	}
+
+	for _, msg := range result.Errors {
+		fmt.Println("⚠️ ", msg)
+	}
+	fmt.Printf("✅ Imported %d rate(s), skipped %d\n", result.Imported, result.Skipped)
+	if result.Skipped > 0 {
+		os.Exit(1)
+	}
+}
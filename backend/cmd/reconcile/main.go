@@ -0,0 +1,62 @@
+// Command reconcile menghitung ulang total_collected dan donor_count setiap campaign dari
+// donasi sukses dan melaporkan selisihnya.
+//
+//	go run ./cmd/reconcile        # hanya laporan, exit code 1 jika ada selisih
+//	go run ./cmd/reconcile -fix   # timpa total campaign dengan hasil hitung ulang
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"zakat/pkg/audit"
+	"zakat/pkg/postgres"
+	"zakat/repositories"
+	"zakat/services"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	fix := flag.Bool("fix", false, "overwrite drifted campaign totals with recomputed values")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+	postgres.DatabaseInit()
+
+	allocator := services.NewAllocator(services.AllocationConfigFromEnv())
+	service := services.NewDonationService(repositories.NewUnitOfWork(postgres.DB), allocator)
+	ctx := audit.WithSystemActor(context.Background(), "reconcile")
+
+	drifted, err := service.ReconcileTotals(ctx, *fix)
+	if err != nil {
+		log.Fatal("Reconciliation failed: ", err)
+	}
+
+	if len(drifted) == 0 {
+		fmt.Println("✅ All campaign totals match successful donations")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "campaign\ttotal_collected\texpected_total\tdrift\tnet_collected\texpected_net\tdonor_count\texpected_donors\t")
+	for _, t := range drifted {
+		fmt.Fprintf(w, "%d\t%.2f\t%.2f\t%+.2f\t%.2f\t%.2f\t%d\t%d\t\n",
+			t.CampaignID, t.TotalCollected, t.ExpectedTotal, t.TotalCollected-t.ExpectedTotal,
+			t.NetCollected, t.ExpectedNet, t.DonorCount, t.ExpectedDonors)
+	}
+	w.Flush()
+
+	if *fix {
+		fmt.Printf("✅ Fixed %d campaign(s)\n", len(drifted))
+		return
+	}
+	fmt.Printf("❌ %d campaign(s) drifted; run with -fix to correct\n", len(drifted))
+	os.Exit(1)
+}
@@ -2,20 +2,317 @@ package database
 
 import (
 	"fmt"
+	"os"
+	"strings"
+	"time"
 	"zakat/models"
 	"zakat/pkg/postgres"
+	"zakat/pkg/secretbox"
+
+	"gorm.io/gorm"
 )
 
+// backfill pengisian data sekali jalan untuk kolom/tabel baru. needed dihitung dari skema
+// sebelum AutoMigrate: false berarti data sudah dibuat oleh kode yang mengenal kolom tersebut,
+// sehingga backfill hanya ditandai tanpa dijalankan.
+type backfill struct {
+	name   string
+	needed bool
+	run    func(tx *gorm.DB) error
+}
+
 func RunMigration() {
-	err := postgres.DB.AutoMigrate(
+	if err := secretbox.CheckKey(); err != nil {
+		fmt.Println("❌ Migration failed:", err)
+		panic("Migration Failed")
+	}
+
+	// Seluruh migrasi satu transaksi (DDL Postgres ikut transaksi): jika backfill gagal, kolom
+	// barunya ikut batal sehingga start berikutnya masih mendeteksi skema lama dan mengulang backfill
+	if err := postgres.DB.Transaction(migrate); err != nil {
+		fmt.Println("❌ Migration failed:", err)
+		panic("Migration Failed")
+	}
+
+	fmt.Println("✅ Migration Success")
+}
+
+func migrate(tx *gorm.DB) error {
+	backfills := legacyBackfills(tx)
+
+	err := tx.AutoMigrate(
+		&models.SchemaMigration{},
 		&models.User{},
 		&models.Campaign{},
 		&models.Donation{},
 		&models.PasswordReset{},
+		&models.Session{},
+		&models.ThrottleRecord{},
+		&models.EmailVerification{},
+		&models.PhoneOTP{},
+		&models.RecoveryCode{},
+		&models.AdminInvite{},
+		&models.AuditEvent{},
+		&models.PaymentNotification{},
+		&models.ReconciliationRun{},
+		&models.ReconciliationItem{},
+		&models.TransferProof{},
+		&models.CollectionSession{},
+		&models.OfflineDonation{},
+		&models.Refund{},
+		&models.DonationPlan{},
+		&models.DonationPlanCharge{},
+		&models.ReferenceRate{},
+		&models.DonationAllocation{},
+		&models.Mustahik{},
+		&models.MustahikMember{},
+		&models.MustahikAssessment{},
+		&models.MustahikDocument{},
 	)
 	if err != nil {
-		fmt.Println("❌ Migration failed:", err)
-		panic("Migration Failed")
+		return err
 	}
-	fmt.Println("✅ Migration Success")
+
+	// Dokumen mustahik kini aset privat; URL publik lama tidak lagi disimpan
+	if tx.Migrator().HasColumn(&models.MustahikDocument{}, "url") {
+		if err := tx.Migrator().DropColumn(&models.MustahikDocument{}, "url"); err != nil {
+			return fmt.Errorf("mustahik document migration: %w", err)
+		}
+	}
+
+	if err := runBackfills(tx, backfills); err != nil {
+		return err
+	}
+
+	// Nominal transfer manual pending unik per rekening tujuan; dijaga database agar dua
+	// permintaan bersamaan tidak mendapat kode unik yang sama
+	err = tx.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_donations_pending_manual_amount
+		ON donations (transfer_account, amount)
+		WHERE payment_provider = 'manual' AND status = 'pending' AND transfer_account <> '' AND deleted_at IS NULL`).Error
+	if err != nil {
+		return fmt.Errorf("manual transfer index: %w", err)
+	}
+
+	bootstrapSuperAdmin(tx)
+
+	// Data yang dienkripsi dengan kunci lama (turunan SECRET_KEY) dipindah ke DATA_ENCRYPTION_KEY
+	if err := resealSecrets(tx); err != nil {
+		return fmt.Errorf("secret re-encryption: %w", err)
+	}
+	return nil
+}
+
+// legacyBackfills daftar backfill berurutan; harus dipanggil sebelum AutoMigrate
+func legacyBackfills(tx *gorm.DB) []backfill {
+	migrator := tx.Migrator()
+	hasUsers := migrator.HasTable(&models.User{})
+	hasDonations := migrator.HasTable(&models.Donation{})
+
+	return []backfill{
+		{
+			// Admin lama (sebelum ada kolom role) dijadikan super admin
+			name:   "role_from_is_admin",
+			needed: hasUsers,
+			run: func(tx *gorm.DB) error {
+				return tx.Model(&models.User{}).
+					Where("is_admin = ? AND (role IS NULL OR role = '' OR role = ?)", true, models.RoleDonor).
+					Update("role", models.RoleSuperAdmin).Error
+			},
+		},
+		{
+			// Kolom email_verified_at baru ditambahkan: akun yang sudah ada dianggap terverifikasi
+			name:   "email_verified_at",
+			needed: hasUsers && !migrator.HasColumn(&models.User{}, "EmailVerifiedAt"),
+			run: func(tx *gorm.DB) error {
+				return tx.Model(&models.User{}).
+					Where("email_verified_at IS NULL").
+					Update("email_verified_at", gorm.Expr("created_at")).Error
+			},
+		},
+		{
+			// Kolom credited_at baru ditambahkan: donasi sukses lama sudah dikredit oleh kode sebelumnya,
+			// dan donor_count sebelumnya hanya dihitung saat dibaca sehingga perlu diisi sekali
+			name:   "donation_credited_at",
+			needed: hasDonations && !migrator.HasColumn(&models.Donation{}, "CreditedAt"),
+			run: func(tx *gorm.DB) error {
+				err := tx.Model(&models.Donation{}).
+					Where("status = ? AND credited_at IS NULL", models.DonationStatusSuccess).
+					Update("credited_at", gorm.Expr("updated_at")).Error
+				if err != nil {
+					return err
+				}
+				return tx.Exec(`UPDATE campaigns SET donor_count = (
+					SELECT COUNT(DISTINCT d.user_id) FROM donations d
+					WHERE d.campaign_id = campaigns.id AND d.status = ? AND d.deleted_at IS NULL)`,
+					models.DonationStatusSuccess).Error
+			},
+		},
+		{
+			// Kolom channel baru ditambahkan: default online, transfer manual lama ditandai sesuai provider
+			name:   "donation_channel",
+			needed: hasDonations && !migrator.HasColumn(&models.Donation{}, "Channel"),
+			run: func(tx *gorm.DB) error {
+				return tx.Model(&models.Donation{}).
+					Where("payment_provider = ?", models.PaymentProviderManual).
+					Update("channel", models.DonationChannelManualTransfer).Error
+			},
+		},
+		{
+			// Kolom fund_type baru ditambahkan: jenis dana campaign lama ditebak dari kategorinya,
+			// donasi mengikuti campaign
+			name:   "fund_type",
+			needed: migrator.HasTable(&models.Campaign{}) && !migrator.HasColumn(&models.Campaign{}, "FundType"),
+			run: func(tx *gorm.DB) error {
+				for _, fundType := range models.FundTypes {
+					err := tx.Model(&models.Campaign{}).
+						Where("category ILIKE ?", fundType+"%").
+						Update("fund_type", fundType).Error
+					if err != nil {
+						return err
+					}
+				}
+				return tx.Exec(`UPDATE donations SET fund_type = campaigns.fund_type
+					FROM campaigns WHERE campaigns.id = donations.campaign_id`).Error
+			},
+		},
+		{
+			// Tabel alokasi baru ditambahkan: penerimaan lama dicatat seluruhnya sebagai porsi program,
+			// sama dengan cara total campaign dihitung sebelumnya
+			name:   "donation_allocations",
+			needed: hasDonations && !migrator.HasTable(&models.DonationAllocation{}),
+			run: func(tx *gorm.DB) error {
+				err := tx.Exec(`INSERT INTO donation_allocations
+					(donation_id, campaign_id, fund_type, portion, entry, rate, amount, created_at)
+					SELECT id, campaign_id, fund_type, ?, ?, 0, amount - refunded_amount, credited_at
+					FROM donations WHERE status = ? AND credited_at IS NOT NULL AND deleted_at IS NULL`,
+					models.AllocationProgram, models.AllocationEntryCredit, models.DonationStatusSuccess).Error
+				if err != nil {
+					return err
+				}
+				return tx.Exec("UPDATE campaigns SET net_collected = total_collected").Error
+			},
+		},
+		{
+			// Kolom transfer_account baru ditambahkan: transfer manual pending lama diberi rekening
+			// campaign-nya. Jika nominal lama sudah bentrok, hanya donasi pertama yang diberi rekening
+			// agar unique index bisa dibuat.
+			name:   "donation_transfer_account",
+			needed: hasDonations && !migrator.HasColumn(&models.Donation{}, "TransferAccount"),
+			run: func(tx *gorm.DB) error {
+				return tx.Exec(`UPDATE donations SET transfer_account = campaigns.c_pocket
+					FROM campaigns WHERE campaigns.id = donations.campaign_id AND donations.id IN (
+						SELECT DISTINCT ON (c.c_pocket, d.amount) d.id FROM donations d
+						JOIN campaigns c ON c.id = d.campaign_id
+						WHERE d.payment_provider = ? AND d.status = ? AND d.deleted_at IS NULL
+						ORDER BY c.c_pocket, d.amount, d.id)`,
+					models.PaymentProviderManual, models.DonationStatusPending).Error
+			},
+		},
+	}
+}
+
+// runBackfills menjalankan backfill yang belum tercatat di schema_migrations. Setiap backfill
+// berjalan di transaksinya sendiri (savepoint) dan dicatat di transaksi yang sama.
+func runBackfills(tx *gorm.DB, backfills []backfill) error {
+	var applied []string
+	if err := tx.Model(&models.SchemaMigration{}).Pluck("name", &applied).Error; err != nil {
+		return err
+	}
+	done := make(map[string]bool, len(applied))
+	for _, name := range applied {
+		done[name] = true
+	}
+
+	for _, b := range backfills {
+		if done[b.name] {
+			continue
+		}
+		err := tx.Transaction(func(tx *gorm.DB) error {
+			if b.needed {
+				if err := b.run(tx); err != nil {
+					return err
+				}
+			}
+			return tx.Create(&models.SchemaMigration{Name: b.name, Skipped: !b.needed, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("backfill %s: %w", b.name, err)
+		}
+		if b.needed {
+			fmt.Println("✅ Backfill applied:", b.name)
+		}
+	}
+	return nil
+}
+
+// bootstrapSuperAdmin: pendaftaran publik tidak bisa memberi role staf, jadi akun dengan email
+// BOOTSTRAP_SUPER_ADMIN_EMAIL dipromosikan selama belum ada super admin
+func bootstrapSuperAdmin(tx *gorm.DB) {
+	email := strings.TrimSpace(os.Getenv("BOOTSTRAP_SUPER_ADMIN_EMAIL"))
+	if email == "" {
+		return
+	}
+
+	var superAdmins int64
+	tx.Model(&models.User{}).Where("role = ?", models.RoleSuperAdmin).Count(&superAdmins)
+	if superAdmins > 0 {
+		return
+	}
+	result := tx.Model(&models.User{}).
+		Where("LOWER(email) = LOWER(?)", email).
+		Updates(map[string]interface{}{"role": models.RoleSuperAdmin, "is_admin": true})
+	if result.Error != nil {
+		fmt.Println("❌ Super admin bootstrap failed:", result.Error)
+	} else if result.RowsAffected > 0 {
+		fmt.Println("✅ Super admin bootstrapped:", email)
+	}
+}
+
+// resealSecrets mengenkripsi ulang secret TOTP dan NIK mustahik yang masih memakai kunci lama;
+// digest NIK ikut dihitung ulang karena kuncinya juga berganti
+func resealSecrets(tx *gorm.DB) error {
+	var users []models.User
+	err := tx.Select("id", "totp_secret").Where("totp_secret <> ''").
+		FindInBatches(&users, 200, func(batchTx *gorm.DB, batch int) error {
+			for _, user := range users {
+				sealed, _, changed, err := secretbox.Reseal(user.TOTPSecret)
+				if err != nil {
+					fmt.Printf("⚠️  Cannot open TOTP secret of user %d: %v\n", user.ID, err)
+					continue
+				}
+				if !changed {
+					continue
+				}
+				if err := tx.Model(&models.User{}).Where("id = ?", user.ID).
+					UpdateColumn("totp_secret", sealed).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	var mustahik []models.Mustahik
+	return tx.Select("id", "nik_encrypted").
+		FindInBatches(&mustahik, 200, func(batchTx *gorm.DB, batch int) error {
+			for _, m := range mustahik {
+				sealed, nik, changed, err := secretbox.Reseal(m.NIKEncrypted)
+				if err != nil {
+					fmt.Printf("⚠️  Cannot open NIK of mustahik %d: %v\n", m.ID, err)
+					continue
+				}
+				if !changed {
+					continue
+				}
+				if err := tx.Model(&models.Mustahik{}).Where("id = ?", m.ID).UpdateColumns(map[string]interface{}{
+					"nik_encrypted": sealed,
+					"nik_digest":    secretbox.Digest(nik),
+				}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
 }
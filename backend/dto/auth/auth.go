@@ -1,27 +1,24 @@
 package dto
 
-import "time"
-
 // --- Request DTOs ---
 
-// SignUpRequest digunakan saat donatur atau admin mendaftar
+// SignUpRequest digunakan saat donatur mendaftar. Role staf hanya bisa didapat lewat undangan admin.
 
 type SignUpRequest struct {
 	FirstName string `json:"first_name" validate:"required,min=2,max=50"`
 	LastName  string `json:"last_name" validate:"required,min=2,max=50"`
 	Username  string `json:"username" validate:"required,min=3,max=20,alphanum"`
-	Phone     string `json:"phone" validate:"required,e164"` // Format E.164: +6281234567890
-	Address   string `json:"address" validate:"max=255"`     // Opsional
+	Phone     string `json:"phone" validate:"required,phone"` // 08xx atau E.164: +6281234567890
+	Address   string `json:"address" validate:"max=255"`      // Opsional
 	Email     string `json:"email" validate:"required,email,max=100"`
 	Password  string `json:"password" validate:"required,min=8,max=72,containsany=!@#$%^&*,containsuppercase,containsnumber"`
-	IsAdmin   bool   `json:"is_admin"` // Default false jika donatur
 }
 
 // SignInRequest digunakan saat user login
 // @Example {"email":"test@example.com", "password":"SecurePass123!"}
 type SignInRequest struct {
 	Value    string `json:"value" validate:"required,min=3,max=100"`
-	Password string `json:"password" validate:"required,min=8,max=72"`
+	Password string `json:"password" validate:"required,max=72"` // tanpa min agar akun lama tetap bisa login
 }
 
 // ChangePasswordRequest digunakan saat user mengganti password
@@ -33,72 +30,119 @@ type ChangePasswordRequest struct {
 
 // --- Response DTOs ---
 
-// BaseResponse struktur dasar untuk semua response
-type BaseResponse struct {
-	Success   bool        `json:"success"`
-	Message   string      `json:"message,omitempty"`
-	Data      interface{} `json:"data,omitempty"`
-	Timestamp time.Time   `json:"timestamp"`
-}
-
 // AuthData struktur data auth untuk response
 type AuthData struct {
-	ID        uint   `json:"id"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Username  string `json:"username"`
-	Gender    string `json:"gender,omitempty"`
-	Phone     string `json:"phone"`
-	Address   string `json:"address,omitempty"`
-	Email     string `json:"email"`
-	Photo     string `json:"photo,omitempty"`
-	IsAdmin   bool   `json:"is_admin"`
-	Token     string `json:"token"`
+	ID            uint   `json:"id"`
+	FirstName     string `json:"first_name"`
+	LastName      string `json:"last_name"`
+	Username      string `json:"username"`
+	Gender        string `json:"gender,omitempty"`
+	Phone         string `json:"phone"`
+	Address       string `json:"address,omitempty"`
+	Email         string `json:"email"`
+	Photo         string `json:"photo,omitempty"`
+	IsAdmin       bool   `json:"is_admin"`
+	Role          string `json:"role"`
+	EmailVerified bool   `json:"email_verified"`
+	Token         string `json:"token"`
+	RefreshToken  string `json:"refresh_token,omitempty"` // dipakai di /refresh
+	ExpiresIn     int64  `json:"expires_in,omitempty"`
 }
 
+// UpdateUserRequest semua field opsional; field kosong tidak mengubah data
 type UpdateUserRequest struct {
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Username  string `json:"username"`
-	Gender    string `json:"gender"`
-	Phone     string `json:"phone"`
-	Address   string `json:"address"`
-	Email     string `json:"email"`
-	Photo     string `json:"photo"`
-	Name      string `json:"name"`
-}
-
-// AuthResponse digunakan untuk response setelah login atau signup
-func NewAuthResponse(message string, authData AuthData) BaseResponse {
-	return BaseResponse{
-		Success:   true,
-		Message:   message,
-		Data:      authData,
-		Timestamp: time.Now(),
-	}
-}
-
-// ErrorResponse digunakan untuk response error
-func ErrorResponse(code int, message string) BaseResponse {
-	return BaseResponse{
-		Success:   false,
-		Message:   message,
-		Timestamp: time.Now(),
-	}
-}
-
-// MessageResponse digunakan untuk respons umum
-func MessageResponse(message string) BaseResponse {
-	return BaseResponse{
-		Success:   true,
-		Message:   message,
-		Timestamp: time.Now(),
-	}
+	FirstName string `json:"first_name" validate:"omitempty,min=2,max=50"`
+	LastName  string `json:"last_name" validate:"omitempty,min=2,max=50"`
+	Username  string `json:"username" validate:"omitempty,min=3,max=20,alphanum"`
+	Gender    string `json:"gender" validate:"omitempty,max=20"`
+	Phone     string `json:"phone" validate:"omitempty,phone"`
+	Address   string `json:"address" validate:"max=255"`
+	Email     string `json:"email" validate:"omitempty,email,max=100"`
+	Photo     string `json:"photo" validate:"omitempty,url"`
+	Name      string `json:"name" validate:"max=100"`
+}
+
+// RefreshTokenRequest digunakan untuk menukar refresh token dengan token baru
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// TokenPair access token dan refresh token hasil login/refresh
+type TokenPair struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // detik
+	SessionID    string `json:"session_id"`
+}
+
+// VerifyEmailRequest digunakan saat user membuka link verifikasi email
+type VerifyEmailRequest struct {
+	Token string `json:"token" query:"token" validate:"required"`
+}
+
+// OTPRequest digunakan untuk meminta kode OTP lewat WhatsApp/SMS
+type OTPRequest struct {
+	Phone   string `json:"phone" validate:"required,phone"`
+	Channel string `json:"channel" validate:"omitempty,oneof=whatsapp sms"` // default whatsapp
+}
+
+// PhoneVerificationRequest meminta OTP verifikasi nomor HP; jika phone kosong dipakai nomor di profil
+type PhoneVerificationRequest struct {
+	Phone   string `json:"phone" validate:"omitempty,phone"`
+	Channel string `json:"channel" validate:"omitempty,oneof=whatsapp sms"`
+}
+
+// OTPVerifyRequest digunakan untuk menukar kode OTP dengan sesi login / verifikasi nomor
+type OTPVerifyRequest struct {
+	Phone string `json:"phone" validate:"required,phone"`
+	Code  string `json:"code" validate:"required,numeric,len=6"`
+}
+
+// TwoFactorSetupRequest memulai pendaftaran authenticator, butuh password agar access token
+// curian tidak bisa memasang authenticator milik orang lain
+type TwoFactorSetupRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// TwoFactorCodeRequest digunakan saat mengaktifkan 2FA / membuat ulang recovery code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required,numeric,len=6"`
+}
+
+// TwoFactorVerifyRequest langkah kedua login: challenge token + kode TOTP atau recovery code
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required_without=RecoveryCode,omitempty,numeric,len=6"`
+	RecoveryCode   string `json:"recovery_code" validate:"required_without=Code"`
+}
+
+// TwoFactorDisableRequest mematikan 2FA, butuh password dan kode TOTP
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required,numeric,len=6"`
+}
+
+// UpdateRoleRequest digunakan super admin untuk mengubah role user
+type UpdateRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=super_admin amil campaign_manager donor"`
+}
+
+// CreateInviteRequest digunakan super admin untuk mengundang staf baru lewat email atau WhatsApp
+type CreateInviteRequest struct {
+	Email   string `json:"email" validate:"required_without=Phone,omitempty,email,max=100"`
+	Phone   string `json:"phone" validate:"required_without=Email,omitempty,phone"`
+	Role    string `json:"role" validate:"required,oneof=super_admin amil campaign_manager"`
+	Channel string `json:"channel" validate:"omitempty,oneof=email whatsapp"` // default email jika ada, selain itu whatsapp
+}
+
+// AcceptInviteRequest digunakan user yang sudah login untuk menerima undangan
+type AcceptInviteRequest struct {
+	Token string `json:"token" validate:"required"`
 }
 
 type ForgotPasswordRequest struct {
 	Email    string `json:"email,omitempty" validate:"omitempty,email"`
-	Whatsapp string `json:"whatsapp,omitempty" validate:"omitempty,e164"`
+	Whatsapp string `json:"whatsapp,omitempty" validate:"omitempty,phone"`
 	Method   string `json:"method" validate:"required,oneof=email whatsapp"`
 }
 
@@ -3,32 +3,35 @@ package dto
 import "time"
 
 type CampaignCreateRequest struct {
-	Title       string    `json:"title" form:"title"`
-	Description string    `json:"description" form:"description"`
+	Title       string    `json:"title" form:"title" validate:"required,min=3,max=150"`
+	Description string    `json:"description" form:"description" validate:"required"`
 	Details     string    `json:"details" form:"details"`
-	Start       time.Time `json:"start" form:"start"`
-	End         time.Time `json:"end" form:"end"`
-	CPocket     string    `json:"cpocket" form:"cpocket"`
-	Status      string    `json:"status" form:"status"`
-	Photo       string    `json:"photo" form:"photo"`
-	TargetTotal float64   `json:"target_total" form:"target_total"`
-	Category    string    `json:"category" form:"category"`
-	Location    string    `json:"location" form:"location"`
+	Start       time.Time `json:"start" form:"start" validate:"required"`
+	End         time.Time `json:"end" form:"end" validate:"required,gtefield=Start"`
+	CPocket     string    `json:"cpocket" form:"cpocket" validate:"max=100"`
+	Status      string    `json:"status" form:"status" validate:"max=30"`
+	Photo       string    `json:"photo" form:"photo" validate:"omitempty,url"`
+	TargetTotal float64   `json:"target_total" form:"target_total" validate:"required,gt=0"`
+	Category    string    `json:"category" form:"category" validate:"max=50"`
+	FundType    string    `json:"fund_type" form:"fund_type" validate:"required,oneof=zakat infaq sedekah wakaf qurban fidyah"`
+	Location    string    `json:"location" form:"location" validate:"max=100"`
 	UserID      int       `json:"user_id" form:"user_id"`
 }
 
 type CampaignUpdateRequest struct {
-	Title       string    `json:"title" form:"title"`
-	Description string    `json:"description" form:"description"`
+	Title       string    `json:"title" form:"title" validate:"required,min=3,max=150"`
+	Description string    `json:"description" form:"description" validate:"required"`
 	Details     string    `json:"details" form:"details"`
-	Start       time.Time `json:"start" form:"start"`
-	End         time.Time `json:"end" form:"end"`
-	CPocket     string    `json:"cpocket" form:"cpocket"`
-	Status      string    `json:"status" form:"status"`
-	Photo       string    `json:"photo" form:"photo"`
-	TargetTotal float64   `json:"target_total" form:"target_total"`
-	Category    string    `json:"category" form:"category"`
-	Location    string    `json:"location" form:"location"`
+	Start       time.Time `json:"start" form:"start" validate:"required"`
+	End         time.Time `json:"end" form:"end" validate:"required,gtefield=Start"`
+	CPocket     string    `json:"cpocket" form:"cpocket" validate:"max=100"`
+	Status      string    `json:"status" form:"status" validate:"max=30"`
+	Photo       string    `json:"photo" form:"photo" validate:"omitempty,url"`
+	TargetTotal float64   `json:"target_total" form:"target_total" validate:"required,gt=0"`
+	Category    string    `json:"category" form:"category" validate:"max=50"`
+	// FundType kosong berarti tidak diubah; hanya boleh diganti selama belum ada donasi
+	FundType string `json:"fund_type" form:"fund_type" validate:"omitempty,oneof=zakat infaq sedekah wakaf qurban fidyah"`
+	Location string `json:"location" form:"location" validate:"max=100"`
 }
 
 type CampaignResponse struct {
@@ -43,7 +46,9 @@ type CampaignResponse struct {
 	Photo          string    `json:"photo"`
 	TargetTotal    float64   `json:"target_total"`
 	TotalCollected float64   `json:"total_collected"`
+	NetCollected   float64   `json:"net_collected"`
 	Category       string    `json:"category"`
+	FundType       string    `json:"fund_type"`
 	Location       string    `json:"location"`
 	UserID         int       `json:"user_id"`
 	UserName       string    `json:"user_name"`
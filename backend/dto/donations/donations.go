@@ -3,12 +3,30 @@ package dto
 import "time"
 
 type DonationCreateRequest struct {
-	Amount     float64 `json:"amount" form:"amount"`
+	Amount     float64 `json:"amount" form:"amount" validate:"required,gt=0,rupiah"`
 	Status     string  `json:"status" form:"status"`
 	UserID     int     `json:"user_id" form:"user_id"`
-	CampaignID int     `json:"campaign_id" form:"campaign_id"`
+	CampaignID int     `json:"campaign_id" form:"campaign_id" validate:"required,gt=0"`
+	// PaymentMode "manual_transfer" melewati payment gateway; donatur transfer ke rekening campaign
+	PaymentMode string `json:"payment_mode" form:"payment_mode" validate:"omitempty,oneof=gateway manual_transfer"`
+	IsAnonymous bool   `json:"is_anonymous" form:"is_anonymous"`
+	// FundType opsional; jika diisi harus sama dengan jenis dana campaign (mis. donatur berniat zakat)
+	FundType string `json:"fund_type" form:"fund_type" validate:"omitempty,oneof=zakat infaq sedekah wakaf qurban fidyah"`
 }
 
+// GuestDonationCreateRequest checkout tanpa akun; selalu lewat payment gateway
+type GuestDonationCreateRequest struct {
+	Amount      float64 `json:"amount" form:"amount" validate:"required,gt=0,rupiah"`
+	CampaignID  int     `json:"campaign_id" form:"campaign_id" validate:"required,gt=0"`
+	Name        string  `json:"name" form:"name" validate:"required,max=150"`
+	Email       string  `json:"email" form:"email" validate:"required,email,max=100"`
+	Phone       string  `json:"phone" form:"phone" validate:"omitempty,max=20"`
+	IsAnonymous bool    `json:"is_anonymous" form:"is_anonymous"`
+	FundType    string  `json:"fund_type" form:"fund_type" validate:"omitempty,oneof=zakat infaq sedekah wakaf qurban fidyah"`
+}
+
+const PaymentModeManualTransfer = "manual_transfer"
+
 type DonationResponse struct {
 	ID         int       `json:"id"`
 	Amount     float64   `json:"amount"`
@@ -22,3 +40,17 @@ type DonationResponse struct {
 	Campaign   string    `json:"campaign"`
 	CreatedAt  time.Time `json:"created_at"`
 }
+
+// DonationUpdateRequest field donasi yang boleh diubah admin; kosong berarti tidak diubah.
+// Status berpindah lewat state machine; nominal, campaign, donatur dan jenis dana terkunci
+// setelah donasi dikredit ke campaign.
+type DonationUpdateRequest struct {
+	Amount      *float64   `json:"amount" form:"amount" validate:"omitempty,gt=0,rupiah"`
+	Date        *time.Time `json:"date" form:"date"`
+	Status      string     `json:"status" form:"status"`
+	UserID      *int       `json:"user_id" form:"user_id" validate:"omitempty,gt=0"`
+	CampaignID  *int       `json:"campaign_id" form:"campaign_id" validate:"omitempty,gt=0"`
+	FundType    string     `json:"fund_type" form:"fund_type" validate:"omitempty,oneof=zakat infaq sedekah wakaf qurban fidyah"`
+	DonorName   *string    `json:"donor_name" form:"donor_name" validate:"omitempty,max=150"`
+	IsAnonymous *bool      `json:"is_anonymous" form:"is_anonymous"`
+}
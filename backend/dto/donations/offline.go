@@ -0,0 +1,37 @@
+package dto
+
+import "time"
+
+// OfflineDonationEntry satu donasi tunai/barang yang diterima langsung oleh amil
+type OfflineDonationEntry struct {
+	DonorUserID      *int       `json:"donor_user_id" validate:"omitempty,gt=0"` // isi jika donatur punya akun, agar masuk riwayatnya
+	DonorName        string     `json:"donor_name" validate:"max=150"`
+	Kind             string     `json:"kind" validate:"required,oneof=cash goods"`
+	Amount           float64    `json:"amount" validate:"required,gt=0"` // nominal tunai atau taksiran nilai barang
+	GoodsDescription string     `json:"goods_description" validate:"required_if=Kind goods,max=255"`
+	Quantity         float64    `json:"quantity" validate:"omitempty,gt=0"`
+	Unit             string     `json:"unit" validate:"max=20"`
+	ReceiptNumber    string     `json:"receipt_number" validate:"required,max=50"`
+	Date             *time.Time `json:"date"`
+}
+
+type OfflineDonationCreateRequest struct {
+	CampaignID    int    `json:"campaign_id" validate:"required,gt=0"`
+	CollectorName string `json:"collector_name" validate:"required,max=150"`
+	Location      string `json:"location" validate:"max=255"`
+	OfflineDonationEntry
+}
+
+type CollectionSessionCreateRequest struct {
+	CampaignID    int                    `json:"campaign_id" validate:"required,gt=0"`
+	Name          string                 `json:"name" validate:"required,max=150"`
+	Location      string                 `json:"location" validate:"max=255"`
+	CollectorName string                 `json:"collector_name" validate:"required,max=150"`
+	CollectedAt   *time.Time             `json:"collected_at"`
+	Note          string                 `json:"note"`
+	Entries       []OfflineDonationEntry `json:"entries" validate:"max=500,dive"`
+}
+
+type CollectionSessionEntriesRequest struct {
+	Entries []OfflineDonationEntry `json:"entries" validate:"required,min=1,max=500,dive"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+import "time"
+
+type DonationPlanCreateRequest struct {
+	CampaignID *int       `json:"campaign_id" validate:"omitempty,gt=0"` // kosong berarti dana umum
+	Amount     float64    `json:"amount" validate:"required,gt=0,rupiah"`
+	Interval   string     `json:"interval" validate:"required,oneof=weekly monthly"`
+	StartAt    *time.Time `json:"start_at"` // tagihan pertama; default sekarang
+}
+
+type DonationPlanUpdateRequest struct {
+	Amount   float64 `json:"amount" validate:"omitempty,gt=0,rupiah"`
+	Interval string  `json:"interval" validate:"omitempty,oneof=weekly monthly"`
+}
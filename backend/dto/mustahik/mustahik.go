@@ -0,0 +1,67 @@
+package dto
+
+// MustahikMemberRequest anggota rumah tangga mustahik
+type MustahikMemberRequest struct {
+	Name          string  `json:"name" validate:"required,max=150"`
+	Relationship  string  `json:"relationship" validate:"max=30"`
+	BirthDate     string  `json:"birth_date" validate:"omitempty,datetime=2006-01-02"`
+	Occupation    string  `json:"occupation" validate:"max=100"`
+	MonthlyIncome float64 `json:"monthly_income" validate:"gte=0"`
+}
+
+// MustahikCreateRequest pendaftaran mustahik; NIK 16 digit sesuai KTP
+type MustahikCreateRequest struct {
+	NIK           string                  `json:"nik" validate:"required,len=16,numeric"`
+	FullName      string                  `json:"full_name" validate:"required,max=150"`
+	Gender        string                  `json:"gender" validate:"omitempty,oneof=male female"`
+	BirthDate     string                  `json:"birth_date" validate:"omitempty,datetime=2006-01-02"`
+	Phone         string                  `json:"phone" validate:"omitempty,max=20"`
+	Address       string                  `json:"address" validate:"required"`
+	Village       string                  `json:"village" validate:"max=100"`
+	District      string                  `json:"district" validate:"max=100"`
+	City          string                  `json:"city" validate:"required,max=100"`
+	Province      string                  `json:"province" validate:"max=100"`
+	Occupation    string                  `json:"occupation" validate:"max=100"`
+	MonthlyIncome float64                 `json:"monthly_income" validate:"gte=0"`
+	HouseholdSize int                     `json:"household_size" validate:"required,gte=1,lte=50"`
+	Asnaf         string                  `json:"asnaf" validate:"omitempty,oneof=fakir miskin amil mualaf riqab gharimin fisabilillah ibnu_sabil"`
+	Notes         string                  `json:"notes"`
+	Members       []MustahikMemberRequest `json:"members" validate:"max=50,dive"`
+}
+
+// MustahikUpdateRequest sama dengan pendaftaran; NIK kosong berarti tidak diubah.
+// Status hanya boleh diubah ke inactive atau dikembalikan ke pending (selebihnya lewat asesmen).
+type MustahikUpdateRequest struct {
+	NIK           string                  `json:"nik" validate:"omitempty,len=16,numeric"`
+	FullName      string                  `json:"full_name" validate:"required,max=150"`
+	Gender        string                  `json:"gender" validate:"omitempty,oneof=male female"`
+	BirthDate     string                  `json:"birth_date" validate:"omitempty,datetime=2006-01-02"`
+	Phone         string                  `json:"phone" validate:"omitempty,max=20"`
+	Address       string                  `json:"address" validate:"required"`
+	Village       string                  `json:"village" validate:"max=100"`
+	District      string                  `json:"district" validate:"max=100"`
+	City          string                  `json:"city" validate:"required,max=100"`
+	Province      string                  `json:"province" validate:"max=100"`
+	Occupation    string                  `json:"occupation" validate:"max=100"`
+	MonthlyIncome float64                 `json:"monthly_income" validate:"gte=0"`
+	HouseholdSize int                     `json:"household_size" validate:"required,gte=1,lte=50"`
+	Status        string                  `json:"status" validate:"omitempty,oneof=pending inactive"`
+	Notes         string                  `json:"notes"`
+	Members       []MustahikMemberRequest `json:"members" validate:"max=50,dive"`
+}
+
+// MustahikNIKCheckRequest cek NIK sebelum mendaftar; lewat body agar NIK tidak tercatat di URL/log
+type MustahikNIKCheckRequest struct {
+	NIK string `json:"nik" validate:"required,len=16,numeric"`
+}
+
+// MustahikAssessmentRequest hasil asesmen kelayakan; penghasilan dan jumlah anggota rumah tangga
+// kosong berarti memakai data profil
+type MustahikAssessmentRequest struct {
+	Asnaf         string   `json:"asnaf" validate:"required,oneof=fakir miskin amil mualaf riqab gharimin fisabilillah ibnu_sabil"`
+	Eligible      *bool    `json:"eligible" validate:"required"`
+	MonthlyIncome *float64 `json:"monthly_income" validate:"omitempty,gte=0"`
+	HouseholdSize int      `json:"household_size" validate:"omitempty,gte=1,lte=50"`
+	Notes         string   `json:"notes"`
+	ValidUntil    string   `json:"valid_until" validate:"omitempty,datetime=2006-01-02"`
+}
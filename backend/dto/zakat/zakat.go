@@ -0,0 +1,57 @@
+package dto
+
+import "time"
+
+type CalculateRequest struct {
+	Type       string     `json:"type" validate:"required,oneof=maal penghasilan perdagangan fitrah"`
+	Madhhab    string     `json:"madhhab" validate:"omitempty,oneof=standard shafii hanafi maliki hanbali"`
+	NisabBasis string     `json:"nisab_basis" validate:"omitempty,oneof=gold silver"`
+	Region     string     `json:"region" validate:"max=50"` // harga referensi wilayah; kosong berarti nasional
+	HaulStart  *time.Time `json:"haul_start"`               // tanggal harta mencapai nisab (maal/perdagangan)
+	Date       *time.Time `json:"date"`                     // tanggal perhitungan; default sekarang
+
+	Maal   *MaalInput   `json:"maal" validate:"required_if=Type maal"`
+	Income *IncomeInput `json:"income" validate:"required_if=Type penghasilan"`
+	Trade  *TradeInput  `json:"trade" validate:"required_if=Type perdagangan"`
+	Fitrah *FitrahInput `json:"fitrah" validate:"required_if=Type fitrah"`
+}
+
+type MaalInput struct {
+	Savings          float64 `json:"savings" validate:"gte=0"`
+	GoldGrams        float64 `json:"gold_grams" validate:"gte=0"`
+	GoldJewelryGrams float64 `json:"gold_jewelry_grams" validate:"gte=0"`
+	SilverGrams      float64 `json:"silver_grams" validate:"gte=0"`
+	Stocks           float64 `json:"stocks" validate:"gte=0"`
+	Receivables      float64 `json:"receivables" validate:"gte=0"`
+	Debts            float64 `json:"debts" validate:"gte=0"`
+}
+
+type IncomeInput struct {
+	Period      string  `json:"period" validate:"omitempty,oneof=monthly annual"`
+	Income      float64 `json:"income" validate:"gte=0"`
+	OtherIncome float64 `json:"other_income" validate:"gte=0"`
+	Net         bool    `json:"net"`
+	BasicNeeds  float64 `json:"basic_needs" validate:"gte=0"`
+	Debts       float64 `json:"debts" validate:"gte=0"`
+}
+
+type TradeInput struct {
+	Inventory   float64 `json:"inventory" validate:"gte=0"`
+	Cash        float64 `json:"cash" validate:"gte=0"`
+	Receivables float64 `json:"receivables" validate:"gte=0"`
+	Debts       float64 `json:"debts" validate:"gte=0"`
+}
+
+type FitrahInput struct {
+	People int `json:"people" validate:"required,gte=1,lte=100"`
+}
+
+// ReferenceRateCreateRequest tarif resmi dari admin (mis. BAZNAS pusat atau daerah)
+type ReferenceRateCreateRequest struct {
+	Kind          string  `json:"kind" validate:"required,oneof=gold silver rice fitrah"`
+	Region        string  `json:"region" validate:"max=50"` // kosong berarti nasional
+	Price         float64 `json:"price" validate:"required,gt=0"`
+	EffectiveFrom string  `json:"effective_from" validate:"required,datetime=2006-01-02"`
+	Authority     string  `json:"authority" validate:"max=100"`
+	Note          string  `json:"note"`
+}
@@ -2,10 +2,18 @@ module zakat
 
 go 1.23.3
 
+require (
+	github.com/cloudinary/cloudinary-go/v2 v2.13.0
+	github.com/go-playground/locales v0.14.1
+	github.com/go-playground/universal-translator v0.18.1
+	github.com/go-playground/validator/v10 v10.23.0
+	gorm.io/driver/postgres v1.6.0
+)
+
 require (
 	filippo.io/edwards25519 v1.1.0 // indirect
-	github.com/cloudinary/cloudinary-go/v2 v2.13.0 // indirect
 	github.com/creasty/defaults v1.7.0 // indirect
+	github.com/gabriel-vasile/mimetype v1.4.3 // indirect
 	github.com/go-sql-driver/mysql v1.8.1 // indirect
 	github.com/gorilla/schema v1.4.1 // indirect
 	github.com/jackc/pgpassfile v1.0.0 // indirect
@@ -14,8 +22,8 @@ require (
 	github.com/jackc/puddle/v2 v2.2.2 // indirect
 	github.com/jinzhu/inflection v1.0.0 // indirect
 	github.com/jinzhu/now v1.1.5 // indirect
+	github.com/leodido/go-urn v1.4.0 // indirect
 	golang.org/x/sync v0.10.0 // indirect
-	gorm.io/driver/postgres v1.6.0 // indirect
 )
 
 require (
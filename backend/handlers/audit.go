@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"zakat/models"
+	"zakat/pkg/response"
+	"zakat/repositories"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ==================== Audit Log Handlers ====================
+
+const (
+	auditDefaultLimit = 50
+	auditMaxLimit     = 200
+	auditExportLimit  = 10000
+)
+
+// parseAuditDate menerima YYYY-MM-DD atau RFC3339. Untuk batas "to" berformat tanggal,
+// seluruh hari tersebut ikut dihitung.
+func parseAuditDate(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// auditFilterFromQuery membaca filter audit log dari query string
+func auditFilterFromQuery(c echo.Context) (repositories.AuditFilter, error) {
+	filter := repositories.AuditFilter{
+		EntityType: c.QueryParam("entity_type"),
+		EntityID:   c.QueryParam("entity_id"),
+		Action:     c.QueryParam("action"),
+	}
+
+	if actorID := c.QueryParam("actor_id"); actorID != "" {
+		id, err := strconv.Atoi(actorID)
+		if err != nil {
+			return filter, response.Fail(http.StatusBadRequest, "Invalid actor_id")
+		}
+		filter.ActorID = &id
+	}
+
+	from, err := parseAuditDate(c.QueryParam("from"), false)
+	if err != nil {
+		return filter, response.Fail(http.StatusBadRequest, "Invalid from date, use YYYY-MM-DD or RFC3339")
+	}
+	to, err := parseAuditDate(c.QueryParam("to"), true)
+	if err != nil {
+		return filter, response.Fail(http.StatusBadRequest, "Invalid to date, use YYYY-MM-DD or RFC3339")
+	}
+	filter.From, filter.To = from, to
+
+	return filter, nil
+}
+
+// GetAuditEvents mencari audit log dengan filter dan paginasi
+func (h *Handler) GetAuditEvents(c echo.Context) error {
+	filter, err := auditFilterFromQuery(c)
+	if err != nil {
+		return err
+	}
+
+	page, limit := pagination(c, auditDefaultLimit, auditMaxLimit)
+	filter.Limit = limit
+	filter.Offset = (page - 1) * limit
+
+	events, total, err := h.auditRepository.Search(filter)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to fetch audit events").Wrap(err)
+	}
+
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"events": events,
+		"page":   page,
+		"limit":  limit,
+		"total":  total,
+	})
+}
+
+// ExportAuditEvents mengunduh audit log (format=csv atau json) untuk keperluan pemeriksaan
+func (h *Handler) ExportAuditEvents(c echo.Context) error {
+	filter, err := auditFilterFromQuery(c)
+	if err != nil {
+		return err
+	}
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		return response.Fail(http.StatusBadRequest, "Invalid format, use csv or json")
+	}
+
+	filter.Limit = auditExportLimit
+	events, _, err := h.auditRepository.Search(filter)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to export audit events").Wrap(err)
+	}
+
+	filename := fmt.Sprintf("audit-events-%s.%s", time.Now().Format("20060102-150405"), format)
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+
+	if format == "json" {
+		return c.JSON(http.StatusOK, events)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	c.Response().WriteHeader(http.StatusOK)
+
+	w := csv.NewWriter(c.Response())
+	w.Write([]string{"id", "created_at", "actor_id", "actor_role", "actor_name", "action", "entity_type", "entity_id", "changes", "ip_address", "user_agent", "request_id"})
+	for _, event := range events {
+		w.Write(auditCSVRow(event))
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func auditCSVRow(event models.AuditEvent) []string {
+	actorID := ""
+	if event.ActorID != nil {
+		actorID = strconv.Itoa(*event.ActorID)
+	}
+	return []string{
+		strconv.FormatUint(uint64(event.ID), 10),
+		event.CreatedAt.Format(time.RFC3339),
+		actorID,
+		event.ActorRole,
+		event.ActorName,
+		event.Action,
+		event.EntityType,
+		event.EntityID,
+		string(event.Changes),
+		event.IPAddress,
+		event.UserAgent,
+		event.RequestID,
+	}
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+	"zakat/pkg/response"
+	"zakat/pkg/zakat"
+	"zakat/repositories"
+	"zakat/services"
+
+	"gorm.io/gorm"
+)
+
+// Sentinel error dari repository yang boleh dikembalikan langsung oleh handler;
+// HTTPErrorHandler memetakannya ke status dan kode berikut
+func init() {
+	response.RegisterDomainError(gorm.ErrRecordNotFound, http.StatusNotFound, response.CodeNotFound, "Data not found")
+	response.RegisterDomainError(repositories.ErrInviteUnavailable, http.StatusGone, response.CodeInviteUnavailable, "Invite is no longer available")
+	response.RegisterDomainError(repositories.ErrInviteDowngrade, http.StatusConflict, response.CodeConflict, "Invite role is lower than your current role")
+	response.RegisterDomainError(services.ErrManualCreditNotAllowed, http.StatusConflict, response.CodeInvalidStatusTransition, "Only offline and manual transfer donations can be marked successful by hand")
+	response.RegisterDomainError(services.ErrInvalidStatusTransition, http.StatusConflict, response.CodeInvalidStatusTransition, "Donation status transition is not allowed")
+	response.RegisterDomainError(repositories.ErrProofAlreadyReviewed, http.StatusConflict, response.CodeConflict, "Transfer proof has already been reviewed")
+	response.RegisterDomainError(repositories.ErrDuplicateReceipt, http.StatusConflict, response.CodeConflict, "Receipt number has already been recorded")
+	response.RegisterDomainError(services.ErrRefundNotAllowed, http.StatusConflict, response.CodeRefundNotAllowed, "Only successful donations can be refunded")
+	response.RegisterDomainError(services.ErrRefundExceedsAmount, http.StatusUnprocessableEntity, response.CodeRefundNotAllowed, "Refund amount exceeds the refundable amount")
+	response.RegisterDomainError(repositories.ErrDonationCredited, http.StatusConflict, response.CodeConflict, "Donation has been credited; refund it via POST /donations/:id/refunds instead")
+	response.RegisterDomainError(repositories.ErrFundTypeMismatch, http.StatusUnprocessableEntity, response.CodeFundTypeMismatch, "Fund type does not match the campaign")
+	response.RegisterDomainError(repositories.ErrDuplicateMustahik, http.StatusConflict, response.CodeConflict, "NIK is already registered")
+	response.RegisterDomainError(zakat.ErrMissingRate, http.StatusServiceUnavailable, response.CodeRateUnavailable, "Reference rates for this calculation are not available")
+	response.RegisterDomainError(services.ErrReconcileRunning, http.StatusConflict, response.CodeConflict, "Reconciliation is already running")
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"zakat/models"
+	"zakat/pkg/middleware"
+	"zakat/pkg/response"
+	"zakat/repositories"
+	"zakat/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ==================== Fund Type Handlers ====================
+
+// fundSummary rekap satu jenis dana; nominal jenis dana yang berbeda tidak pernah dijumlahkan.
+// Amount bruto; Net, Amil dan GatewayFee dari baris alokasi (refund sudah dibalik).
+type fundSummary struct {
+	FundType   string                      `json:"fund_type"`
+	Label      string                      `json:"label"`
+	Count      int64                       `json:"count"`
+	Amount     float64                     `json:"amount"`
+	Net        float64                     `json:"net"`
+	Amil       float64                     `json:"amil"`
+	GatewayFee float64                     `json:"gateway_fee"`
+	ByChannel  []repositories.ChannelTotal `json:"by_channel"`
+}
+
+// fundSummaries rekap bruto dan alokasi per jenis dana; from/to nil berarti tidak dibatasi
+func (h *Handler) fundSummaries(from, to *time.Time) ([]fundSummary, error) {
+	totals, err := h.donationRepository.SumPaidByFund(from, to)
+	if err != nil {
+		return nil, err
+	}
+	allocations, err := h.allocationRepository.SumByFund(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return groupFundTotals(totals, allocations), nil
+}
+
+// groupFundTotals mengelompokkan rekap per jenis dana sesuai urutan models.FundTypes;
+// jenis dana tanpa transaksi tetap ditampilkan dengan nilai 0
+func groupFundTotals(totals []repositories.FundTotal, allocations []repositories.AllocationTotal) []fundSummary {
+	index := make(map[string]int, len(models.FundTypes))
+	funds := make([]fundSummary, 0, len(models.FundTypes))
+	for _, fundType := range models.FundTypes {
+		rule, _ := models.FundRuleFor(fundType)
+		index[fundType] = len(funds)
+		funds = append(funds, fundSummary{FundType: fundType, Label: rule.Label, ByChannel: []repositories.ChannelTotal{}})
+	}
+
+	for _, total := range totals {
+		n, ok := index[total.FundType]
+		if !ok {
+			continue
+		}
+		funds[n].Count += total.Count
+		funds[n].Amount += total.Amount
+		funds[n].ByChannel = append(funds[n].ByChannel, repositories.ChannelTotal{
+			Channel: total.Channel,
+			Count:   total.Count,
+			Amount:  total.Amount,
+		})
+	}
+
+	for _, allocation := range allocations {
+		n, ok := index[allocation.FundType]
+		if !ok {
+			continue
+		}
+		switch allocation.Portion {
+		case models.AllocationProgram:
+			funds[n].Net += allocation.Amount
+		case models.AllocationAmil:
+			funds[n].Amil += allocation.Amount
+		case models.AllocationGatewayFee:
+			funds[n].GatewayFee += allocation.Amount
+		}
+	}
+	return funds
+}
+
+// GetFunds daftar jenis dana beserta aturannya (tujuan penyaluran, bagian amil, redaksi bukti setor)
+func (h *Handler) GetFunds(c echo.Context) error {
+	funds := make([]models.FundRule, 0, len(models.FundTypes))
+	for _, fundType := range models.FundTypes {
+		rule, _ := models.FundRuleFor(fundType)
+		funds = append(funds, rule)
+	}
+	return response.Success(c, http.StatusOK, funds)
+}
+
+// GetFundReport laporan penerimaan per jenis dana (?from=&to=YYYY-MM-DD, keduanya inklusif).
+// Bruto menurut tanggal donasi dikredit; alokasi menurut tanggal baris dicatat (refund di periode berjalan).
+func (h *Handler) GetFundReport(c echo.Context) error {
+	var from, to *time.Time
+	if c.QueryParam("from") != "" {
+		t, err := queryDate(c, "from")
+		if err != nil {
+			return response.Fail(http.StatusBadRequest, "Invalid date, use YYYY-MM-DD")
+		}
+		from = &t
+	}
+	if c.QueryParam("to") != "" {
+		t, err := queryDate(c, "to")
+		if err != nil {
+			return response.Fail(http.StatusBadRequest, "Invalid date, use YYYY-MM-DD")
+		}
+		t = t.AddDate(0, 0, 1)
+		to = &t
+	}
+
+	funds, err := h.fundSummaries(from, to)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to sum donation amount per fund type").Wrap(err)
+	}
+
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"from":  c.QueryParam("from"),
+		"to":    c.QueryParam("to"),
+		"funds": funds,
+	})
+}
+
+// GetDonationReceipt bukti setor donasi sukses dengan redaksi sesuai jenis dana;
+// pemilik donasi atau user dengan donations:read_all
+func (h *Handler) GetDonationReceipt(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid donation ID format")
+	}
+
+	donation, err := h.donationRepository.GetByID(uint(id))
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to get donation")
+	}
+	if donation == nil {
+		return response.NewError(http.StatusNotFound, response.CodeDonationNotFound, "Donation not found")
+	}
+
+	ownerID := 0
+	if donation.UserID != nil {
+		ownerID = *donation.UserID
+	}
+	if !middleware.IsSelfOrHasPermission(c, ownerID, middleware.PermDonationReadAll) {
+		return response.Fail(http.StatusForbidden, "Access denied")
+	}
+
+	if donation.Status != models.DonationStatusSuccess || donation.CreditedAt == nil {
+		return response.NewError(http.StatusConflict, response.CodeConflict, "Receipt is only available for successful donations")
+	}
+
+	lines, err := h.allocationRepository.ListByDonation(donation.ID)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to get donation allocation").Wrap(err)
+	}
+	allocation := make(map[string]float64)
+	for _, line := range lines {
+		allocation[line.Portion] += line.Amount
+	}
+
+	rule, ok := models.FundRuleFor(donation.FundType)
+	if !ok {
+		rule, _ = models.FundRuleFor(models.DefaultFundType)
+	}
+	name, _, _ := donation.DonorContact()
+
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"receipt_number": fmt.Sprintf("%s/%s/%06d", strings.ToUpper(rule.Type), donation.CreditedAt.Format("2006"), donation.ID),
+		"title":          rule.ReceiptTitle,
+		"wording":        rule.ReceiptWording,
+		"fund_type":      rule.Type,
+		"fund_label":     rule.Label,
+		"donor_name":     name,
+		"amount":         donation.Amount - donation.RefundedAmount,
+		"refunded":       donation.RefundedAmount,
+		"allocation":     allocation,
+		"campaign_id":    donation.CampaignID,
+		"campaign":       donation.Campaign.Title,
+		"channel":        donation.Channel,
+		"payment_method": donation.PaymentMethod,
+		"order_id":       donation.OrderID,
+		"received_at":    donation.CreditedAt,
+	})
+}
+
+// GetDonationAllocations baris pembagian donasi (program, amil, biaya gateway) beserta pembalik refund;
+// pemilik donasi atau user dengan donations:read_all
+func (h *Handler) GetDonationAllocations(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid donation ID format")
+	}
+
+	donation, err := h.donationRepository.GetByID(uint(id))
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to get donation")
+	}
+	if donation == nil {
+		return response.NewError(http.StatusNotFound, response.CodeDonationNotFound, "Donation not found")
+	}
+
+	ownerID := 0
+	if donation.UserID != nil {
+		ownerID = *donation.UserID
+	}
+	if !middleware.IsSelfOrHasPermission(c, ownerID, middleware.PermDonationReadAll) {
+		return response.Fail(http.StatusForbidden, "Access denied")
+	}
+
+	lines, err := h.allocationRepository.ListByDonation(donation.ID)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to get donation allocation").Wrap(err)
+	}
+
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"donation_id":     donation.ID,
+		"fund_type":       donation.FundType,
+		"amount":          donation.Amount,
+		"refunded_amount": donation.RefundedAmount,
+		"net_to_program":  services.ProgramAmount(lines),
+		"allocations":     lines,
+	})
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	dtoDonation "zakat/dto/donations"
+	"zakat/models"
+	"zakat/pkg/middleware"
+	"zakat/pkg/phone"
+	"zakat/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ==================== Guest Donation Handlers ====================
+
+// CreateGuestDonation checkout tanpa akun; nama, email dan nomor donatur disimpan di donasi
+// agar bisa ditautkan ke akun setelah email yang sama diverifikasi
+func (h *Handler) CreateGuestDonation(c echo.Context) error {
+	var req dtoDonation.GuestDonationCreateRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	ipTarget := throttleTarget{ThrottleGuestDonationIP, c.RealIP()}
+	if wait := h.throttleCheck(ipTarget); wait > 0 {
+		return tooManyRequests(c, wait)
+	}
+	h.throttleFail(ipTarget)
+
+	donorPhone := ""
+	if strings.TrimSpace(req.Phone) != "" {
+		normalized, err := phone.Normalize(req.Phone)
+		if err != nil {
+			return response.Fail(http.StatusBadRequest, "Invalid phone number")
+		}
+		donorPhone = normalized
+	}
+
+	campaign, err := h.donatableCampaign(req.CampaignID, req.FundType)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	donation := models.Donation{
+		Amount:      req.Amount,
+		Date:        now,
+		Status:      models.DonationStatusPending,
+		DonorName:   strings.TrimSpace(req.Name),
+		DonorEmail:  strings.ToLower(strings.TrimSpace(req.Email)),
+		DonorPhone:  donorPhone,
+		IsAnonymous: req.IsAnonymous,
+		CampaignID:  req.CampaignID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		OrderID:     fmt.Sprintf("GUEST-%d", now.UnixNano()),
+		Channel:     models.DonationChannelOnline,
+		FundType:    campaign.FundType,
+
+		PaymentProvider: h.paymentService.DefaultProvider(),
+	}
+
+	return h.checkoutGateway(c, donation, nil, campaign)
+}
+
+// LinkGuestDonations menautkan donasi tamu dengan email akun yang sudah terverifikasi
+func (h *Handler) LinkGuestDonations(c echo.Context) error {
+	userID, ok := c.Get("userLogin").(int)
+	if !ok {
+		return response.Fail(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	user, err := h.userRepository.GetByID(uint(userID))
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to get user information")
+	}
+	if user == nil {
+		return response.NewError(http.StatusNotFound, response.CodeUserNotFound, "User not found")
+	}
+	// Tanpa verifikasi siapa pun bisa mendaftar dengan email orang lain dan mengklaim donasinya
+	if user.EmailVerifiedAt == nil || user.Email == "" {
+		return response.NewError(http.StatusForbidden, response.CodeEmailNotVerified, "Silakan verifikasi email Anda terlebih dahulu")
+	}
+
+	linked, err := h.donationService.LinkGuestDonations(c.Request().Context(), user.ID, user.Email)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to link guest donations").Wrap(err)
+	}
+
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"linked": linked,
+	})
+}
+
+// linkVerifiedGuestDonations dipanggil setelah email terverifikasi; kegagalan hanya dicatat
+func (h *Handler) linkVerifiedGuestDonations(c echo.Context, user *models.User) {
+	linked, err := h.donationService.LinkGuestDonations(c.Request().Context(), user.ID, user.Email)
+	if err != nil {
+		log.Printf("Failed to link guest donations for user %d: %v", user.ID, err)
+		return
+	}
+	if linked > 0 {
+		log.Printf("Linked %d guest donation(s) to user %d", linked, user.ID)
+	}
+}
+
+// publicDonations menyamarkan donatur untuk listing publik; pengelola dengan donations:read_all
+// dan pemilik donasi tetap melihat data lengkap
+func publicDonations(c echo.Context, donations []models.Donation) {
+	if middleware.HasPermission(c, middleware.PermDonationReadAll) {
+		return
+	}
+	userID, _ := c.Get("userLogin").(int)
+	for i := range donations {
+		if userID != 0 && donations[i].UserID != nil && *donations[i].UserID == userID {
+			continue
+		}
+		donations[i].MaskDonor()
+	}
+}
+
+// publicCampaignDonations menerapkan publicDonations ke donasi yang ikut dimuat bersama campaign
+func publicCampaignDonations(c echo.Context, campaigns []models.Campaign) {
+	for i := range campaigns {
+		publicDonations(c, campaigns[i].Donations)
+	}
+}
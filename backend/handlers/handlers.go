@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"math/rand"
 	"net/http"
@@ -12,16 +14,18 @@ import (
 	dtoAuth "zakat/dto/auth"
 	dtoCampaign "zakat/dto/campaign"
 	dtoDonation "zakat/dto/donations"
-	dto "zakat/dto/result"
 	"zakat/models"
+	"zakat/pkg/audit"
 	"zakat/pkg/bcrypt"
+	"zakat/pkg/docstore"
+	"zakat/pkg/middleware"
+	"zakat/pkg/payment"
+	"zakat/pkg/phone"
+	"zakat/pkg/response"
+	"zakat/pkg/throttle"
 	"zakat/repositories"
 	"zakat/services"
 
-	jwtToken "zakat/pkg/jwt"
-
-	"github.com/golang-jwt/jwt/v4"
-
 	"github.com/labstack/echo/v4"
 )
 
@@ -33,6 +37,31 @@ type Handler struct {
 	passwordRepository repositories.PasswordResetRepository
 	emailService       *services.EmailService
 	whatsappService    *services.WhatsAppService
+	sessionRepository  repositories.SessionRepository
+	limiter            *throttle.Limiter
+
+	emailVerificationRepository repositories.EmailVerificationRepository
+	otpRepository               repositories.PhoneOTPRepository
+	smsService                  *services.SMSService
+	recoveryCodeRepository      repositories.RecoveryCodeRepository
+	adminInviteRepository       repositories.AdminInviteRepository
+	auditRepository             repositories.AuditRepository
+	donationService             services.DonationService
+	reconciliationRepository    repositories.ReconciliationRepository
+	reconciler                  *services.Reconciler
+	transferProofRepository     repositories.TransferProofRepository
+	offlineDonationRepository   repositories.OfflineDonationRepository
+	refundRepository            repositories.RefundRepository
+	refundService               services.RefundService
+	donationPlanRepository      repositories.DonationPlanRepository
+	planScheduler               *services.PlanScheduler
+	zakatService                *services.ZakatService
+	referenceRateRepository     repositories.ReferenceRateRepository
+	referenceRates              *services.ReferenceRates
+	rateImporter                *services.RateImporter
+	allocationRepository        repositories.AllocationRepository
+	mustahikRepository          repositories.MustahikRepository
+	documentStore               *docstore.Store
 }
 
 func NewHandler(
@@ -43,6 +72,30 @@ func NewHandler(
 	passwordRepo repositories.PasswordResetRepository,
 	emailService *services.EmailService,
 	whatsappService *services.WhatsAppService,
+	sessionRepo repositories.SessionRepository,
+	limiter *throttle.Limiter,
+	emailVerificationRepo repositories.EmailVerificationRepository,
+	otpRepo repositories.PhoneOTPRepository,
+	smsService *services.SMSService,
+	recoveryCodeRepo repositories.RecoveryCodeRepository,
+	adminInviteRepo repositories.AdminInviteRepository,
+	auditRepo repositories.AuditRepository,
+	donationService services.DonationService,
+	reconciliationRepo repositories.ReconciliationRepository,
+	reconciler *services.Reconciler,
+	transferProofRepo repositories.TransferProofRepository,
+	offlineDonationRepo repositories.OfflineDonationRepository,
+	refundRepo repositories.RefundRepository,
+	refundService services.RefundService,
+	donationPlanRepo repositories.DonationPlanRepository,
+	planScheduler *services.PlanScheduler,
+	zakatService *services.ZakatService,
+	referenceRateRepo repositories.ReferenceRateRepository,
+	referenceRates *services.ReferenceRates,
+	rateImporter *services.RateImporter,
+	allocationRepo repositories.AllocationRepository,
+	mustahikRepo repositories.MustahikRepository,
+	documentStore *docstore.Store,
 ) *Handler {
 	return &Handler{
 		userRepository:     userRepo,
@@ -52,6 +105,31 @@ func NewHandler(
 		passwordRepository: passwordRepo,
 		emailService:       emailService,
 		whatsappService:    whatsappService,
+		sessionRepository:  sessionRepo,
+		limiter:            limiter,
+
+		emailVerificationRepository: emailVerificationRepo,
+		otpRepository:               otpRepo,
+		smsService:                  smsService,
+		recoveryCodeRepository:      recoveryCodeRepo,
+		adminInviteRepository:       adminInviteRepo,
+		auditRepository:             auditRepo,
+		donationService:             donationService,
+		reconciliationRepository:    reconciliationRepo,
+		reconciler:                  reconciler,
+		transferProofRepository:     transferProofRepo,
+		offlineDonationRepository:   offlineDonationRepo,
+		refundRepository:            refundRepo,
+		refundService:               refundService,
+		donationPlanRepository:      donationPlanRepo,
+		planScheduler:               planScheduler,
+		zakatService:                zakatService,
+		referenceRateRepository:     referenceRateRepo,
+		referenceRates:              referenceRates,
+		rateImporter:                rateImporter,
+		allocationRepository:        allocationRepo,
+		mustahikRepository:          mustahikRepo,
+		documentStore:               documentStore,
 	}
 }
 
@@ -69,22 +147,20 @@ func generateResetToken() string {
 func (h *Handler) ForgotPassword(c echo.Context) error {
 	var req dtoAuth.ForgotPasswordRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResponse(
-			http.StatusBadRequest, "Format request tidak valid",
-		))
+		return response.Fail(http.StatusBadRequest, "Format request tidak valid")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
 	}
 
 	// Validasi berdasarkan method yang dipilih
 	if req.Method == "email" && req.Email == "" {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResponse(
-			http.StatusBadRequest, "Email harus diisi untuk metode email",
-		))
+		return response.Fail(http.StatusBadRequest, "Email harus diisi untuk metode email")
 	}
 
 	if req.Method == "whatsapp" && req.Whatsapp == "" {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResponse(
-			http.StatusBadRequest, "Nomor WhatsApp harus diisi untuk metode WhatsApp",
-		))
+		return response.Fail(http.StatusBadRequest, "Nomor WhatsApp harus diisi untuk metode WhatsApp")
 	}
 
 	// Tentukan contact yang akan digunakan
@@ -94,19 +170,31 @@ func (h *Handler) ForgotPassword(c echo.Context) error {
 
 	if req.Method == "email" {
 		contact = req.Email
-		user, err = h.userRepository.GetByEmail(req.Email)
 	} else {
 		contact = req.Whatsapp
-		user, err = h.userRepository.GetByPhone(req.Whatsapp)
+	}
+
+	// Setiap permintaan dihitung, agar endpoint ini tidak dipakai untuk spam email/WhatsApp
+	targets := []throttleTarget{
+		{ThrottlePasswordRecovery, throttleKey(contact)},
+		{ThrottleRecoveryIP, c.RealIP()},
+	}
+	if wait := h.throttleCheck(targets...); wait > 0 {
+		return tooManyRequests(c, wait)
+	}
+	h.throttleFail(targets...)
+
+	if req.Method == "email" {
+		user, err = h.userRepository.GetByEmail(req.Email)
+	} else if phoneNumber, phoneErr := phone.Normalize(req.Whatsapp); phoneErr == nil {
+		// Reset lewat WhatsApp hanya untuk nomor yang sudah diverifikasi pemiliknya
+		user, err = h.userRepository.GetByVerifiedPhone(phoneNumber)
+		req.Whatsapp = phoneNumber
 	}
 
 	// Untuk keamanan, selalu return success meskipun user tidak ditemukan
 	if err != nil || user == nil {
-		return c.JSON(http.StatusOK, dto.BaseResponse{
-			Success:   true,
-			Message:   "Jika email/nomor terdaftar, tautan reset telah dikirim",
-			Timestamp: time.Now(),
-		})
+		return response.SuccessMessage(c, http.StatusOK, "Jika email/nomor terdaftar, tautan reset telah dikirim", nil)
 	}
 
 	// Generate reset token
@@ -122,9 +210,7 @@ func (h *Handler) ForgotPassword(c echo.Context) error {
 	}
 
 	if err := h.passwordRepository.Create(resetRecord); err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResponse(
-			http.StatusInternalServerError, "Gagal membuat token reset",
-		))
+		return response.Fail(http.StatusInternalServerError, "Gagal membuat token reset")
 	}
 
 	// Kirim token berdasarkan method
@@ -156,15 +242,10 @@ func (h *Handler) ForgotPassword(c echo.Context) error {
 		fmt.Printf("Gagal mengirim %s: %v\n", channel, sendError)
 	}
 
-	return c.JSON(http.StatusOK, dto.BaseResponse{
-		Success: true,
-		Message: "Instruksi reset telah dikirim",
-		Data: map[string]interface{}{
-			"channel": channel,
-			"contact": contact,
-			"message": "Tautan reset telah dikirim",
-		},
-		Timestamp: time.Now(),
+	return response.SuccessMessage(c, http.StatusOK, "Instruksi reset telah dikirim", map[string]interface{}{
+		"channel": channel,
+		"contact": contact,
+		"message": "Tautan reset telah dikirim",
 	})
 }
 
@@ -186,63 +267,56 @@ func (h *Handler) sendResetWhatsAppFallback(whatsapp, token string) {
 }
 
 func (h *Handler) ResetPassword(c echo.Context) error {
-	var req struct {
-		Token       string `json:"token" validate:"required"`
-		NewPassword string `json:"new_password" validate:"required,min=8"`
-	}
+	var req dtoAuth.ResetPasswordRequest
 
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResponse(
-			http.StatusBadRequest, "Format request tidak valid",
-		))
+		return response.Fail(http.StatusBadRequest, "Format request tidak valid")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	ipTarget := throttleTarget{ThrottleResetToken, c.RealIP()}
+	if wait := h.throttleCheck(ipTarget); wait > 0 {
+		return tooManyRequests(c, wait)
 	}
 
 	// Validasi token
 	resetRecord, err := h.passwordRepository.GetByToken(req.Token)
 	if err != nil || resetRecord == nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResponse(
-			http.StatusBadRequest, "Token reset tidak valid atau sudah kadaluarsa",
-		))
+		h.throttleFail(ipTarget)
+		return response.NewError(http.StatusBadRequest, response.CodeInvalidToken, "Token reset tidak valid atau sudah kadaluarsa")
 	}
 
 	// Cek apakah token sudah digunakan
 	if resetRecord.Used {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResponse(
-			http.StatusBadRequest, "Token reset sudah digunakan",
-		))
+		return response.Fail(http.StatusBadRequest, "Token reset sudah digunakan")
 	}
 
 	// Cek apakah token sudah expired
 	if time.Now().After(resetRecord.ExpiresAt) {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResponse(
-			http.StatusBadRequest, "Token reset sudah kadaluarsa",
-		))
+		return response.Fail(http.StatusBadRequest, "Token reset sudah kadaluarsa")
 	}
 
 	// Cari user berdasarkan email
 	user, err := h.userRepository.GetByEmail(resetRecord.Email)
 	if err != nil || user == nil {
-		return c.JSON(http.StatusNotFound, dto.ErrorResponse(
-			http.StatusNotFound, "User tidak ditemukan",
-		))
+		return response.NewError(http.StatusNotFound, response.CodeUserNotFound, "User tidak ditemukan")
 	}
 
 	// Hash password baru
 	hashedPassword, err := bcrypt.HashingPassword(req.NewPassword)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResponse(
-			http.StatusInternalServerError, "Error saat hashing password",
-		))
+		return response.Fail(http.StatusInternalServerError, "Error saat hashing password")
 	}
 
 	// Update password user
 	user.Password = hashedPassword
 	user.UpdatedAt = time.Now()
 
-	if err := h.userRepository.Update(user); err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResponse(
-			http.StatusInternalServerError, "Gagal update password",
-		))
+	if err := h.userRepository.WithContext(c.Request().Context()).Update(user); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Gagal update password")
 	}
 
 	// Tandai token sebagai sudah digunakan
@@ -250,37 +324,34 @@ func (h *Handler) ResetPassword(c echo.Context) error {
 		fmt.Printf("Gagal menandai token sebagai used: %v\n", err)
 	}
 
-	return c.JSON(http.StatusOK, dto.BaseResponse{
-		Success:   true,
-		Message:   "Password berhasil direset",
-		Timestamp: time.Now(),
-	})
+	// Password sudah diganti, semua sesi lama tidak berlaku lagi
+	h.revokeUserSessions(c, user.ID, "password_reset", false)
+
+	return response.SuccessMessage(c, http.StatusOK, "Password berhasil direset", nil)
 }
 
 // VerifyResetToken handler
 func (h *Handler) VerifyResetToken(c echo.Context) error {
 	token := c.QueryParam("token")
 	if token == "" {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResponse(
-			http.StatusBadRequest, "Token harus diisi",
-		))
+		return response.Fail(http.StatusBadRequest, "Token harus diisi")
+	}
+
+	ipTarget := throttleTarget{ThrottleResetToken, c.RealIP()}
+	if wait := h.throttleCheck(ipTarget); wait > 0 {
+		return tooManyRequests(c, wait)
 	}
 
 	resetRecord, err := h.passwordRepository.GetByToken(token)
 	if err != nil || resetRecord == nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResponse(
-			http.StatusBadRequest, "Token reset tidak valid atau sudah kadaluarsa",
-		))
+		h.throttleFail(ipTarget)
+		return response.NewError(http.StatusBadRequest, response.CodeInvalidToken, "Token reset tidak valid atau sudah kadaluarsa")
 	}
 
-	return c.JSON(http.StatusOK, dto.BaseResponse{
-		Success: true,
-		Data: map[string]interface{}{
-			"valid":      true,
-			"email":      resetRecord.Email,
-			"expires_at": resetRecord.ExpiresAt,
-		},
-		Timestamp: time.Now(),
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"valid":      true,
+		"email":      resetRecord.Email,
+		"expires_at": resetRecord.ExpiresAt,
 	})
 }
 
@@ -293,105 +364,70 @@ func (h *Handler) CheckAuth(c echo.Context) error {
 	// Fetch user dari DB
 	user, err := h.userRepository.GetByID(uint(userId))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to fetch user",
-		})
+		return response.Fail(http.StatusInternalServerError, "Failed to fetch user")
 	}
 
 	// Return user info tanpa password
 	userResponse := models.UserResponseJWT{
-		ID:       user.ID,
-		Name:     user.FirstName + " " + user.LastName,
-		Email:    user.Email,
-		Username: user.Username,
-		Address:  user.Address,
-		Phone:    user.Phone,
-		Photo:    user.Photo,
-		Token:    "",
-		IsAdmin:  user.IsAdmin,
-	}
-
-	return c.JSON(http.StatusOK, dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: userResponse,
-	})
+		ID:            user.ID,
+		Name:          user.FirstName + " " + user.LastName,
+		Email:         user.Email,
+		Username:      user.Username,
+		Address:       user.Address,
+		Phone:         user.Phone,
+		Photo:         user.Photo,
+		Token:         "",
+		IsAdmin:       user.IsAdmin,
+		Role:          user.EffectiveRole(),
+		EmailVerified: user.EmailVerifiedAt != nil,
+		PhoneVerified: user.PhoneVerifiedAt != nil,
+		TwoFactor:     user.TOTPEnabledAt != nil,
+	}
+
+	return response.Success(c, http.StatusOK, userResponse)
 }
 
 func (h *Handler) ChangePassword(c echo.Context) error {
 	userLogin := c.Get("userLogin")
 	if userLogin == nil {
-		return c.JSON(http.StatusUnauthorized, dto.ErrorResult{
-			Code:    http.StatusUnauthorized,
-			Message: "Unauthorized",
-		})
+		return response.Fail(http.StatusUnauthorized, "Unauthorized")
 	}
 	userID := userLogin.(int)
 
-	var body struct {
-		OldPassword string `json:"old_password"`
-		NewPassword string `json:"new_password"`
-	}
+	var body dtoAuth.ChangePasswordRequest
 	if err := c.Bind(&body); err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Invalid request",
-		})
+		return response.Fail(http.StatusBadRequest, "Invalid request")
+	}
+
+	if err := c.Validate(&body); err != nil {
+		return validationError(c, err)
 	}
 
 	user, err := h.userRepository.GetByID(uint(userID))
 	if err != nil || user == nil {
-		return c.JSON(http.StatusNotFound, dto.ErrorResult{
-			Code:    http.StatusNotFound,
-			Message: "User not found",
-		})
+		return response.NewError(http.StatusNotFound, response.CodeUserNotFound, "User not found")
 	}
 
 	if !bcrypt.CheckPasswordHash(body.OldPassword, user.Password) {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Old password incorrect",
-		})
+		return response.NewError(http.StatusBadRequest, response.CodeInvalidCredentials, "Old password incorrect")
 	}
 
 	hashedPassword, err := bcrypt.HashingPassword(body.NewPassword)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Error hashing password",
-		})
+		return response.Fail(http.StatusInternalServerError, "Error hashing password")
 	}
 
 	user.Password = hashedPassword
 	user.UpdatedAt = time.Now()
 
-	if err := h.userRepository.Update(user); err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to update password",
-		})
+	if err := h.userRepository.WithContext(c.Request().Context()).Update(user); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to update password")
 	}
 
-	return c.JSON(http.StatusOK, dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: "Password changed successfully",
-	})
-}
-
-func (h *Handler) GetAdminCount(c echo.Context) error {
-	count, err := h.userRepository.CountAdmins()
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to get admin count",
-		})
-	}
+	// Keluarkan perangkat lain, sesi saat ini tetap aktif
+	h.revokeUserSessions(c, user.ID, "password_changed", true)
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"admin_count":      count,
-		"max_admins":       3,
-		"can_create_admin": count < 3,
-	})
+	return response.Success(c, http.StatusOK, "Password changed successfully")
 }
 
 // ==================== User Handlers ====================
@@ -399,57 +435,35 @@ func (h *Handler) GetAdminCount(c echo.Context) error {
 func (h *Handler) CreateUser(c echo.Context) error {
 	var req dtoAuth.SignUpRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Invalid request body",
-		})
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
 	}
 
 	log.Printf("Request Body: %+v", req)
 
 	// Cek apakah username sudah digunakan
 	if existingUser, _ := h.userRepository.GetByUsername(req.Username); existingUser != nil {
-		return c.JSON(http.StatusConflict, dto.ErrorResult{
-			Code:    http.StatusConflict,
-			Message: "Username sudah digunakan",
-		})
+		return response.NewError(http.StatusConflict, response.CodeUsernameTaken, "Username sudah digunakan")
 	}
 
 	// Cek apakah email sudah digunakan
 	if existingUser, _ := h.userRepository.GetByEmail(req.Email); existingUser != nil {
-		return c.JSON(http.StatusConflict, dto.ErrorResult{
-			Code:    http.StatusConflict,
-			Message: "Email sudah digunakan",
-		})
+		return response.NewError(http.StatusConflict, response.CodeEmailTaken, "Email sudah digunakan")
 	}
 
-	// Cek batas admin (max 3)
-	if req.IsAdmin {
-		adminCount, err := h.userRepository.CountAdmins()
-		if err != nil {
-			log.Printf("❌ Error CountAdmins: %v", err)
-			return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-				Code:    http.StatusInternalServerError,
-				Message: "Failed to check admin count",
-			})
-		}
-
-		if adminCount >= 3 {
-			return c.JSON(http.StatusForbidden, dto.ErrorResult{
-				Code:    http.StatusForbidden,
-				Message: "Admin limit reached (max 3 admins allowed)",
-			})
-		}
+	// Simpan nomor HP dalam format E.164 agar bisa dicocokkan saat login OTP
+	if phoneNumber, err := phone.Normalize(req.Phone); err == nil {
+		req.Phone = phoneNumber
 	}
 
 	// Hash password
 	hashedPassword, err := bcrypt.HashingPassword(req.Password)
 	if err != nil {
 		log.Printf("❌ Error hashing password: %v", err)
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to hash password",
-		})
+		return response.Fail(http.StatusInternalServerError, "Failed to hash password")
 	}
 
 	user := models.User{
@@ -460,64 +474,59 @@ func (h *Handler) CreateUser(c echo.Context) error {
 		LastName:  req.LastName,
 		Phone:     req.Phone,
 		Address:   req.Address,
-		IsAdmin:   req.IsAdmin,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
+	// Pendaftaran publik selalu sebagai donatur; role staf hanya lewat undangan
+	user.SetRole(models.RoleDonor)
 
 	log.Printf("Final User Model: %+v", user)
 
-	if err := h.userRepository.Create(&user); err != nil {
+	if err := h.userRepository.WithContext(c.Request().Context()).Create(&user); err != nil {
 		log.Printf("❌ Error CreateUser DB: %v", err)
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to create user",
-		})
+		return response.Fail(http.StatusInternalServerError, "Failed to create user")
 	}
 
-	// Generate JWT token
-	claims := jwt.MapClaims{
-		"id":       user.ID,
-		"email":    user.Email,
-		"is_admin": user.IsAdmin,
-		"exp":      time.Now().Add(time.Hour * 24).Unix(),
+	// Kirim link verifikasi email; kegagalan kirim tidak menggagalkan registrasi
+	if err := h.sendEmailVerification(&user); err != nil {
+		log.Printf("❌ Error send verification email: %v", err)
 	}
-	tokenObj := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
-	secretKey := []byte(jwtToken.GetSecretKey())
-	token, err := tokenObj.SignedString(secretKey)
+	// Buat sesi login
+	tokens, err := h.issueSession(c, &user, false)
 	if err != nil {
-		log.Printf("❌ Error generate token: %v", err)
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to generate authentication token",
-		})
+		log.Printf("❌ Error issue session: %v", err)
+		return response.Fail(http.StatusInternalServerError, "Failed to generate authentication token")
 	}
 
 	// Buat response AuthData
 	authData := dtoAuth.AuthData{
-		ID:        uint(user.ID),
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Username:  user.Username,
-		Phone:     user.Phone,
-		Address:   user.Address,
-		Email:     user.Email,
-		IsAdmin:   user.IsAdmin,
-		Token:     token,
-	}
-
-	return c.JSON(http.StatusCreated, dtoAuth.NewAuthResponse("Registration successful!", authData))
+		ID:           uint(user.ID),
+		FirstName:    user.FirstName,
+		LastName:     user.LastName,
+		Username:     user.Username,
+		Phone:        user.Phone,
+		Address:      user.Address,
+		Email:        user.Email,
+		IsAdmin:      user.IsAdmin,
+		Role:         user.EffectiveRole(),
+		Token:        tokens.Token,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+	}
+
+	return response.SuccessMessage(c, http.StatusCreated, "Registration successful!", authData)
 }
 
 func (h *Handler) SignIn(c echo.Context) error {
 	var req dtoAuth.SignInRequest
 
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Invalid request body",
-		})
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
 	}
 	req.Password = strings.TrimSpace(req.Password)
 	var user *models.User
@@ -527,6 +536,12 @@ func (h *Handler) SignIn(c echo.Context) error {
 	req.Value = strings.TrimSpace(req.Value)
 	req.Password = strings.TrimSpace(req.Password)
 
+	// Batasi percobaan login per IP dan per akun
+	ipTarget := throttleTarget{ThrottleLoginIP, c.RealIP()}
+	if wait := h.throttleCheck(ipTarget); wait > 0 {
+		return tooManyRequests(c, wait)
+	}
+
 	if strings.Contains(req.Value, "@") {
 		user, err = h.userRepository.GetByEmail(req.Value)
 		log.Printf("Looking up by email: %s", req.Value)
@@ -536,17 +551,22 @@ func (h *Handler) SignIn(c echo.Context) error {
 	}
 
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Server error",
-		})
+		return response.Fail(http.StatusInternalServerError, "Server error")
+	}
+
+	// Hitungan per akun memakai ID user agar email dan username akun yang sama berbagi satu batas;
+	// identifier mentah hanya dipakai untuk akun yang tidak dikenal
+	accountTarget := throttleTarget{ThrottleLoginAccount, throttleKey(req.Value)}
+	if user != nil {
+		accountTarget.key = "user:" + strconv.Itoa(user.ID)
+	}
+	if wait := h.throttleCheck(accountTarget); wait > 0 {
+		return tooManyRequests(c, wait)
 	}
 
 	if user == nil {
-		return c.JSON(http.StatusUnauthorized, dto.ErrorResult{
-			Code:    http.StatusUnauthorized,
-			Message: "Invalid username/email or password",
-		})
+		h.throttleFail(accountTarget, ipTarget)
+		return response.NewError(http.StatusUnauthorized, response.CodeInvalidCredentials, "Invalid username/email or password")
 	}
 
 	// Debug user data (without sensitive info)
@@ -559,143 +579,88 @@ func (h *Handler) SignIn(c echo.Context) error {
 	log.Printf("Password valid: %v", isValid)
 
 	if !isValid {
-		return c.JSON(http.StatusUnauthorized, dto.ErrorResult{
-			Code:    http.StatusUnauthorized,
-			Message: "Invalid username/email or password",
-		})
-	}
-
-	// Generate JWT token
-	claims := jwt.MapClaims{
-		"id":       user.ID,
-		"email":    user.Email,
-		"username": user.Username,
-		"isAdmin":  user.IsAdmin,
-		"exp":      time.Now().Add(time.Hour * 24).Unix(), // Token expires in 24 hours
-	}
-
-	tokenObj := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	secretKey := []byte(jwtToken.GetSecretKey())
-
-	token, err := tokenObj.SignedString(secretKey)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to generate authentication token",
-		})
+		h.throttleFail(accountTarget, ipTarget)
+		return response.NewError(http.StatusUnauthorized, response.CodeInvalidCredentials, "Invalid username/email or password")
 	}
 
-	// Prepare response
-	response := dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: map[string]interface{}{
-			"token": token,
-			"user": models.UserResponseJWT{
-				ID:       user.ID,
-				Name:     user.FirstName + " " + user.LastName,
-				Email:    user.Email,
-				Username: user.Username,
-				Gender:   user.Gender,
-				Phone:    user.Phone,
-				Address:  user.Address,
-				Photo:    user.Photo,
-				Token:    token,
-				IsAdmin:  user.IsAdmin,
-			},
-		},
-	}
+	h.throttleReset(accountTarget.scope, accountTarget.key)
 
-	return c.JSON(http.StatusOK, response)
+	return h.completeLogin(c, user)
 }
 
 func (h *Handler) GetUser(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Invalid user ID format",
-		})
+		return response.Fail(http.StatusBadRequest, "Invalid user ID format")
+	}
+
+	if !middleware.IsSelfOrHasPermission(c, id, middleware.PermUserRead) {
+		return response.Fail(http.StatusForbidden, "Access denied")
 	}
 
 	user, err := h.userRepository.GetByID(uint(id))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to get user",
-		})
+		return response.Fail(http.StatusInternalServerError, "Failed to get user")
 	}
 
 	if user == nil {
-		return c.JSON(http.StatusNotFound, dto.ErrorResult{
-			Code:    http.StatusNotFound,
-			Message: "User not found",
-		})
-	}
-
-	response := models.UserResponseJWT{
-		ID:       user.ID,
-		Name:     user.FirstName + " " + user.LastName,
-		Email:    user.Email,
-		Username: user.Username,
-		Gender:   user.Gender,
-		Phone:    user.Phone,
-		Address:  user.Address,
-		Photo:    user.Photo,
-		IsAdmin:  user.IsAdmin,
-		Token:    "",
-	}
-
-	return c.JSON(http.StatusOK, dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: response,
-	})
+		return response.NewError(http.StatusNotFound, response.CodeUserNotFound, "User not found")
+	}
+
+	userData := models.UserResponseJWT{
+		ID:            user.ID,
+		Name:          user.FirstName + " " + user.LastName,
+		Email:         user.Email,
+		Username:      user.Username,
+		Gender:        user.Gender,
+		Phone:         user.Phone,
+		Address:       user.Address,
+		Photo:         user.Photo,
+		IsAdmin:       user.IsAdmin,
+		Role:          user.EffectiveRole(),
+		EmailVerified: user.EmailVerifiedAt != nil,
+		PhoneVerified: user.PhoneVerifiedAt != nil,
+		TwoFactor:     user.TOTPEnabledAt != nil,
+		Token:         "",
+	}
+
+	return response.Success(c, http.StatusOK, userData)
 }
 
 func (h *Handler) GetAllUsers(c echo.Context) error {
 	users, err := h.userRepository.GetAll()
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to get users",
-		})
+		return response.Fail(http.StatusInternalServerError, "Failed to get users")
 	}
 
-	return c.JSON(http.StatusOK, dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: users,
-	})
+	return response.Success(c, http.StatusOK, users)
 }
 
 func (h *Handler) UpdateUser(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Invalid user ID format",
-		})
+		return response.Fail(http.StatusBadRequest, "Invalid user ID format")
+	}
+
+	if !middleware.IsSelfOrHasPermission(c, id, middleware.PermUserManage) {
+		return response.Fail(http.StatusForbidden, "Access denied")
 	}
 
 	user, err := h.userRepository.GetByID(uint(id))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to get user",
-		})
+		return response.Fail(http.StatusInternalServerError, "Failed to get user")
 	}
 	if user == nil {
-		return c.JSON(http.StatusNotFound, dto.ErrorResult{
-			Code:    http.StatusNotFound,
-			Message: "User not found",
-		})
+		return response.NewError(http.StatusNotFound, response.CodeUserNotFound, "User not found")
 	}
 
 	var req dtoAuth.UpdateUserRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Invalid request body",
-		})
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
 	}
 	fmt.Println("Received request:", req)
 
@@ -725,13 +690,26 @@ func (h *Handler) UpdateUser(c echo.Context) error {
 		user.Gender = req.Gender
 	}
 	if req.Phone != "" {
+		// Format E.164 seperti saat registrasi, dipakai login OTP dan pencocokan undangan
+		phoneNumber, err := phone.Normalize(req.Phone)
+		if err != nil {
+			return response.Fail(http.StatusBadRequest, "Invalid phone number")
+		}
+		req.Phone = phoneNumber
+	}
+	if req.Phone != "" && req.Phone != user.Phone {
 		user.Phone = req.Phone
+		// Nomor baru harus diverifikasi ulang lewat OTP
+		user.PhoneVerifiedAt = nil
 	}
 	if req.Address != "" {
 		user.Address = req.Address
 	}
-	if req.Email != "" {
+	emailChanged := false
+	if req.Email != "" && !strings.EqualFold(req.Email, user.Email) {
 		user.Email = req.Email
+		user.EmailVerifiedAt = nil
+		emailChanged = true
 	}
 	if req.Photo != "" {
 		user.Photo = req.Photo
@@ -739,59 +717,45 @@ func (h *Handler) UpdateUser(c echo.Context) error {
 
 	user.UpdatedAt = time.Now()
 
-	if err := h.userRepository.Update(user); err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to update user: " + err.Error(),
-		})
+	if err := h.userRepository.WithContext(c.Request().Context()).Update(user); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to update user").Wrap(err)
+	}
+
+	// Email baru harus diverifikasi ulang
+	if emailChanged {
+		if err := h.sendEmailVerification(user); err != nil {
+			fmt.Printf("Gagal mengirim email verifikasi: %v\n", err)
+		}
 	}
 
 	fmt.Println("Updated user:", user)
-	return c.JSON(http.StatusOK, dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: user,
-	})
+	return response.Success(c, http.StatusOK, user)
 }
 
 func (h *Handler) ChangeProfileImage(c echo.Context) error {
 	userLogin := c.Get("userLogin")
 	if userLogin == nil {
-		return c.JSON(http.StatusUnauthorized, dto.ErrorResult{
-			Code:    http.StatusUnauthorized,
-			Message: "Unauthorized",
-		})
+		return response.Fail(http.StatusUnauthorized, "Unauthorized")
 	}
 
 	userID, ok := userLogin.(int)
 	if !ok {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Invalid user ID type",
-		})
+		return response.Fail(http.StatusInternalServerError, "Invalid user ID type")
 	}
 
 	// Ambil URL Cloudinary dari context
 	cloudinaryURL, ok := c.Get("dataFile").(string)
 	if !ok || cloudinaryURL == "" {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "No image uploaded",
-		})
+		return response.Fail(http.StatusBadRequest, "No image uploaded")
 	}
 
 	user, err := h.userRepository.GetByID(uint(userID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to get user",
-		})
+		return response.Fail(http.StatusInternalServerError, "Failed to get user")
 	}
 
 	if user == nil {
-		return c.JSON(http.StatusNotFound, dto.ErrorResult{
-			Code:    http.StatusNotFound,
-			Message: "User  not found",
-		})
+		return response.NewError(http.StatusNotFound, response.CodeUserNotFound, "User  not found")
 	}
 
 	// Tidak perlu hapus file lokal karena sudah upload ke Cloudinary
@@ -800,29 +764,27 @@ func (h *Handler) ChangeProfileImage(c echo.Context) error {
 	user.Photo = cloudinaryURL
 	user.UpdatedAt = time.Now()
 
-	if err := h.userRepository.Update(user); err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to update image",
-		})
-	}
-
-	return c.JSON(http.StatusOK, dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: map[string]interface{}{
-			"message": "Profile image updated successfully",
-			"photo":   cloudinaryURL,
-			"user": models.UserResponseJWT{
-				ID:       user.ID,
-				Name:     user.FirstName + " " + user.LastName,
-				Email:    user.Email,
-				Username: user.Username,
-				Gender:   user.Gender,
-				Phone:    user.Phone,
-				Address:  user.Address,
-				Photo:    cloudinaryURL,
-				IsAdmin:  user.IsAdmin,
-			},
+	if err := h.userRepository.WithContext(c.Request().Context()).Update(user); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to update image")
+	}
+
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"message": "Profile image updated successfully",
+		"photo":   cloudinaryURL,
+		"user": models.UserResponseJWT{
+			ID:            user.ID,
+			Name:          user.FirstName + " " + user.LastName,
+			Email:         user.Email,
+			Username:      user.Username,
+			Gender:        user.Gender,
+			Phone:         user.Phone,
+			Address:       user.Address,
+			Photo:         cloudinaryURL,
+			IsAdmin:       user.IsAdmin,
+			Role:          user.EffectiveRole(),
+			EmailVerified: user.EmailVerifiedAt != nil,
+			PhoneVerified: user.PhoneVerifiedAt != nil,
+			TwoFactor:     user.TOTPEnabledAt != nil,
 		},
 	})
 }
@@ -830,22 +792,74 @@ func (h *Handler) ChangeProfileImage(c echo.Context) error {
 func (h *Handler) DeleteUser(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Invalid user ID format",
-		})
+		return response.Fail(http.StatusBadRequest, "Invalid user ID format")
 	}
 
-	if err := h.userRepository.Delete(uint(id)); err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to delete user",
-		})
+	if err := h.userRepository.WithContext(c.Request().Context()).Delete(uint(id)); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to delete user")
+	}
+	h.revokeUserSessions(c, id, "user_deleted", false)
+
+	return response.Success(c, http.StatusOK, "User deleted successfully")
+}
+
+func (h *Handler) UpdateUserRole(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid user ID format")
+	}
+
+	var req dtoAuth.UpdateRoleRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
 	}
 
-	return c.JSON(http.StatusOK, dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: "User deleted successfully",
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	if !models.IsValidRole(req.Role) {
+		return response.Fail(http.StatusBadRequest, "Invalid role")
+	}
+
+	if currentUserID, _ := c.Get("userLogin").(int); currentUserID == id && req.Role != models.RoleSuperAdmin {
+		return response.Fail(http.StatusBadRequest, "You cannot remove your own super admin role")
+	}
+
+	user, err := h.userRepository.GetByID(uint(id))
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to get user")
+	}
+	if user == nil {
+		return response.NewError(http.StatusNotFound, response.CodeUserNotFound, "User not found")
+	}
+
+	roleChanged := user.EffectiveRole() != req.Role
+	user.SetRole(req.Role)
+	user.UpdatedAt = time.Now()
+
+	if err := h.userRepository.WithContext(c.Request().Context()).Update(user); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to update user role")
+	}
+	// Token lama masih membawa role lama; user harus login ulang
+	if roleChanged {
+		h.revokeUserSessions(c, user.ID, "role_changed", false)
+	}
+
+	return response.Success(c, http.StatusOK, models.UserResponseJWT{
+		ID:            user.ID,
+		Name:          user.FirstName + " " + user.LastName,
+		Email:         user.Email,
+		Username:      user.Username,
+		Gender:        user.Gender,
+		Phone:         user.Phone,
+		Address:       user.Address,
+		Photo:         user.Photo,
+		IsAdmin:       user.IsAdmin,
+		Role:          user.EffectiveRole(),
+		EmailVerified: user.EmailVerifiedAt != nil,
+		PhoneVerified: user.PhoneVerifiedAt != nil,
+		TwoFactor:     user.TOTPEnabledAt != nil,
 	})
 }
 
@@ -856,13 +870,14 @@ func (h *Handler) CreateCampaign(c echo.Context) error {
 
 	// Manual parsing multipart form (untuk field non-file)
 	if err := c.Request().ParseMultipartForm(10 << 20); err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{Code: http.StatusBadRequest, Message: "Failed to parse form"})
+		return response.Fail(http.StatusBadRequest, "Failed to parse form")
 	}
 
 	req.Title = c.FormValue("title")
 	req.Description = c.FormValue("description")
 	req.Details = c.FormValue("details")
 	req.Category = c.FormValue("category")
+	req.FundType = c.FormValue("fund_type")
 	req.CPocket = c.FormValue("cpocket")
 	req.Status = c.FormValue("status")
 	req.Location = c.FormValue("location")
@@ -870,7 +885,7 @@ func (h *Handler) CreateCampaign(c echo.Context) error {
 	targetTotalStr := c.FormValue("target_total")
 	targetTotal, err := strconv.ParseFloat(targetTotalStr, 64)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{Code: http.StatusBadRequest, Message: "Invalid target_total"})
+		return response.Fail(http.StatusBadRequest, "Invalid target_total")
 	}
 	req.TargetTotal = targetTotal
 
@@ -878,30 +893,40 @@ func (h *Handler) CreateCampaign(c echo.Context) error {
 	endStr := c.FormValue("end")
 	req.Start, err = time.Parse("2006-01-02", startStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{Code: http.StatusBadRequest, Message: "Invalid start date"})
+		return response.Fail(http.StatusBadRequest, "Invalid start date")
 	}
 	req.End, err = time.Parse("2006-01-02", endStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{Code: http.StatusBadRequest, Message: "Invalid end date"})
+		return response.Fail(http.StatusBadRequest, "Invalid end date")
 	}
 
 	// Ambil URL foto hasil upload Cloudinary dari middleware
 	photoURL, ok := c.Get("dataFile").(string)
 	if !ok || photoURL == "" {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{Code: http.StatusBadRequest, Message: "Photo is required"})
+		return response.Fail(http.StatusBadRequest, "Photo is required")
 	}
 	req.Photo = photoURL
 
 	userIDVal := c.Get("userLogin")
 	userID, ok := userIDVal.(int)
 	if !ok {
-		return c.JSON(http.StatusUnauthorized, dto.ErrorResult{
-			Code:    http.StatusUnauthorized,
-			Message: "Unauthorized",
-		})
+		return response.Fail(http.StatusUnauthorized, "Unauthorized")
 	}
 	req.UserID = userID
 
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	user, err := h.userRepository.GetByID(uint(userID))
+	if err != nil || user == nil {
+		return response.Fail(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	if emailVerificationBlocked(user, VerificationActionCampaign) {
+		return response.NewError(http.StatusForbidden, response.CodeEmailNotVerified, "Silakan verifikasi email Anda sebelum membuat campaign")
+	}
+
 	newCampaign := models.Campaign{
 		Title:          req.Title,
 		Description:    req.Description,
@@ -913,6 +938,7 @@ func (h *Handler) CreateCampaign(c echo.Context) error {
 		Photo:          req.Photo,
 		TargetTotal:    req.TargetTotal,
 		Category:       req.Category,
+		FundType:       req.FundType,
 		Location:       req.Location,
 		UserID:         req.UserID,
 		TotalCollected: 0,
@@ -920,45 +946,30 @@ func (h *Handler) CreateCampaign(c echo.Context) error {
 		UpdatedAt:      time.Now(),
 	}
 
-	if err := h.campaignRepository.Create(&newCampaign); err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to create campaign",
-		})
+	if err := h.campaignRepository.WithContext(c.Request().Context()).Create(&newCampaign); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to create campaign")
 	}
 
-	return c.JSON(http.StatusOK, dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: map[string]interface{}{
-			"id":      newCampaign.ID,
-			"title":   newCampaign.Title,
-			"message": "Campaign berhasil dibuat",
-		},
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"id":      newCampaign.ID,
+		"title":   newCampaign.Title,
+		"message": "Campaign berhasil dibuat",
 	})
 }
 
 func (h *Handler) GetCampaignByID(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Invalid campaign ID format",
-		})
+		return response.Fail(http.StatusBadRequest, "Invalid campaign ID format")
 	}
 
 	campaign, err := h.campaignRepository.GetByID(uint(id))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to get campaign",
-		})
+		return response.Fail(http.StatusInternalServerError, "Failed to get campaign")
 	}
 
 	if campaign == nil {
-		return c.JSON(http.StatusNotFound, dto.ErrorResult{
-			Code:    http.StatusNotFound,
-			Message: "Campaign not found",
-		})
+		return response.NewError(http.StatusNotFound, response.CodeCampaignNotFound, "Campaign not found")
 	}
 
 	// Tambahkan baseURL ke photo
@@ -967,19 +978,15 @@ func (h *Handler) GetCampaignByID(c echo.Context) error {
 		campaign.Photo = baseURL + "/" + campaign.Photo
 	}
 
-	return c.JSON(http.StatusOK, dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: campaign,
-	})
+	publicDonations(c, campaign.Donations)
+
+	return response.Success(c, http.StatusOK, campaign)
 }
 
 func (h *Handler) GetAllCampaigns(c echo.Context) error {
 	campaigns, err := h.campaignRepository.GetAll()
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to get campaigns",
-		})
+		return response.Fail(http.StatusInternalServerError, "Failed to get campaigns")
 	}
 
 	baseURL := c.Scheme() + "://" + c.Request().Host // e.g., http://localhost:5000
@@ -1002,6 +1009,8 @@ func (h *Handler) GetAllCampaigns(c echo.Context) error {
 			campaigns[i].Photo = "https://via.placeholder.com/600x300?text=No+Image"
 		}
 	}
+	publicCampaignDonations(c, campaigns)
+
 	var totalCollected float64
 	for _, c := range campaigns {
 		totalCollected += c.TotalCollected
@@ -1009,73 +1018,74 @@ func (h *Handler) GetAllCampaigns(c echo.Context) error {
 
 	totalTransactions, err := h.donationRepository.CountPaid()
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to count paid donations",
-		})
+		return response.Fail(http.StatusInternalServerError, "Failed to count paid donations")
 	}
 
-	return c.JSON(http.StatusOK, dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: map[string]interface{}{
-			"campaigns":          campaigns,
-			"total_campaigns":    len(campaigns),
-			"total_collected":    totalCollected,
-			"total_transactions": totalTransactions,
-		},
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"campaigns":          campaigns,
+		"total_campaigns":    len(campaigns),
+		"total_collected":    totalCollected,
+		"total_transactions": totalTransactions,
 	})
 }
 
 func (h *Handler) GetCampaignsByFilters(c echo.Context) error {
 	category := c.QueryParam("category")
 	location := c.QueryParam("location")
+	fundType := c.QueryParam("fund_type")
+	if fundType != "" && !models.IsValidFundType(fundType) {
+		return response.Fail(http.StatusBadRequest, "Invalid fund type")
+	}
 
-	campaigns, err := h.campaignRepository.GetByFilters(category, location)
+	campaigns, err := h.campaignRepository.GetByFilters(category, location, fundType)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to get campaigns",
-		})
+		return response.Fail(http.StatusInternalServerError, "Failed to get campaigns")
 	}
 
-	return c.JSON(http.StatusOK, dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: campaigns,
-	})
+	publicCampaignDonations(c, campaigns)
+
+	return response.Success(c, http.StatusOK, campaigns)
 }
 
 func (h *Handler) UpdateCampaign(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Invalid campaign ID format",
-		})
+		return response.Fail(http.StatusBadRequest, "Invalid campaign ID format")
 	}
 
 	// Get existing campaign
 	campaign, err := h.campaignRepository.GetByID(uint(id))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to get campaign",
-		})
+		return response.Fail(http.StatusInternalServerError, "Failed to get campaign")
 	}
 
 	if campaign == nil {
-		return c.JSON(http.StatusNotFound, dto.ErrorResult{
-			Code:    http.StatusNotFound,
-			Message: "Campaign not found",
-		})
+		return response.NewError(http.StatusNotFound, response.CodeCampaignNotFound, "Campaign not found")
+	}
+	if !middleware.IsSelfOrHasPermission(c, campaign.UserID, middleware.PermCampaignManageAll) {
+		return response.Fail(http.StatusForbidden, "Access denied")
 	}
 
 	// Bind to DTO instead of directly to model
-	var updateRequest dtoCampaign.CampaignCreateRequest
+	var updateRequest dtoCampaign.CampaignUpdateRequest
 	if err := c.Bind(&updateRequest); err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Invalid request body",
-		})
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&updateRequest); err != nil {
+		return validationError(c, err)
+	}
+
+	// Jenis dana tidak boleh berubah setelah ada donasi agar dana yang sudah masuk tidak berpindah jenis
+	if updateRequest.FundType != "" && updateRequest.FundType != campaign.FundType {
+		count, err := h.donationRepository.CountByCampaign(uint(campaign.ID))
+		if err != nil {
+			return response.Fail(http.StatusInternalServerError, "Failed to count donations for campaign").Wrap(err)
+		}
+		if count > 0 {
+			return response.NewError(http.StatusConflict, response.CodeConflict, "Fund type cannot be changed after the campaign has received donations")
+		}
+		campaign.FundType = updateRequest.FundType
 	}
 
 	// Update campaign fields from DTO
@@ -1096,108 +1106,86 @@ func (h *Handler) UpdateCampaign(c echo.Context) error {
 		campaign.Photo = updateRequest.Photo
 	}
 
-	if err := h.campaignRepository.Update(campaign); err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to update campaign",
-		})
+	if err := h.campaignRepository.WithContext(c.Request().Context()).Update(campaign); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to update campaign")
 	}
 
-	return c.JSON(http.StatusOK, dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: campaign,
-	})
+	return response.Success(c, http.StatusOK, campaign)
 }
 
 func (h *Handler) UploadCampaignPhoto(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Invalid campaign ID",
-		})
+		return response.Fail(http.StatusBadRequest, "Invalid campaign ID")
 	}
 
 	campaign, err := h.campaignRepository.GetByID(uint(id))
 	if err != nil || campaign == nil {
-		return c.JSON(http.StatusNotFound, dto.ErrorResult{
-			Code:    http.StatusNotFound,
-			Message: "Campaign not found",
-		})
+		return response.NewError(http.StatusNotFound, response.CodeCampaignNotFound, "Campaign not found")
+	}
+	if !middleware.IsSelfOrHasPermission(c, campaign.UserID, middleware.PermCampaignManageAll) {
+		return response.Fail(http.StatusForbidden, "Access denied")
 	}
 
 	// Ambil URL foto hasil upload Cloudinary dari middleware
 	photoURL, ok := c.Get("dataFile").(string)
 	if !ok || photoURL == "" {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "No photo file provided",
-		})
+		return response.Fail(http.StatusBadRequest, "No photo file provided")
 	}
 
 	// Update campaign dengan URL Cloudinary
 	campaign.Photo = photoURL
 	campaign.UpdatedAt = time.Now()
 
-	if err := h.campaignRepository.Update(campaign); err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to update campaign photo",
-		})
+	if err := h.campaignRepository.WithContext(c.Request().Context()).Update(campaign); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to update campaign photo")
 	}
 
-	return c.JSON(http.StatusOK, dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: map[string]interface{}{
-			"message":  "Photo uploaded successfully",
-			"filename": photoURL,
-		},
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"message":  "Photo uploaded successfully",
+		"filename": photoURL,
 	})
 }
 
 func (h *Handler) DeleteCampaign(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Invalid campaign ID format",
-		})
+		return response.Fail(http.StatusBadRequest, "Invalid campaign ID format")
 	}
 
-	if err := h.campaignRepository.Delete(uint(id)); err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to delete campaign",
-		})
+	// campaign_manager hanya boleh menghapus campaign miliknya sendiri
+	campaign, err := h.campaignRepository.GetByID(uint(id))
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to get campaign")
+	}
+	if campaign == nil {
+		return response.NewError(http.StatusNotFound, response.CodeCampaignNotFound, "Campaign not found")
+	}
+	if !middleware.IsSelfOrHasPermission(c, campaign.UserID, middleware.PermCampaignManageAll) {
+		return response.Fail(http.StatusForbidden, "Access denied")
 	}
 
-	return c.JSON(http.StatusOK, dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: "Campaign deleted successfully",
-	})
+	if err := h.campaignRepository.WithContext(c.Request().Context()).Delete(uint(id)); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to delete campaign")
+	}
+
+	return response.Success(c, http.StatusOK, "Campaign deleted successfully")
 }
 
 func (h *Handler) GetDonationsByCampaign(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Invalid campaign ID format",
-		})
+		return response.Fail(http.StatusBadRequest, "Invalid campaign ID format")
 	}
 
 	donations, err := h.campaignRepository.GetDonations(uint(id))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to get donations for campaign",
-		})
+		return response.Fail(http.StatusInternalServerError, "Failed to get donations for campaign")
 	}
 
-	return c.JSON(http.StatusOK, dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: donations,
-	})
+	publicDonations(c, donations)
+
+	return response.Success(c, http.StatusOK, donations)
 }
 
 // ==================== Donation Handlers ====================
@@ -1205,47 +1193,37 @@ func (h *Handler) GetDonationsByCampaign(c echo.Context) error {
 func (h *Handler) CreateDonation(c echo.Context) error {
 	var req dtoDonation.DonationCreateRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Invalid request body",
-		})
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
 	}
 
-	if req.Amount <= 0 {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Donation amount must be greater than zero",
-		})
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	// Donatur selalu user yang sedang login, bukan dari request body
+	if userID, ok := c.Get("userLogin").(int); ok {
+		req.UserID = userID
 	}
 
-	campaign, err := h.campaignRepository.GetByID(uint(req.CampaignID))
+	campaign, err := h.donatableCampaign(req.CampaignID, req.FundType)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to get campaign",
-		})
+		return err
 	}
 
-	if campaign == nil {
-		return c.JSON(http.StatusNotFound, dto.ErrorResult{
-			Code:    http.StatusNotFound,
-			Message: "Campaign not found",
-		})
+	user, err := h.userRepository.GetByID(uint(req.UserID))
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to get user information")
+	}
+	if user == nil {
+		return response.NewError(http.StatusNotFound, response.CodeUserNotFound, "User not found")
 	}
 
-	if campaign.TotalCollected >= campaign.TargetTotal {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Campaign has already reached its target",
-		})
+	if emailVerificationBlocked(user, VerificationActionDonation) {
+		return response.NewError(http.StatusForbidden, response.CodeEmailNotVerified, "Silakan verifikasi email Anda sebelum berdonasi")
 	}
 
-	user, err := h.userRepository.GetByID(uint(req.UserID))
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to get user information",
-		})
+	if req.PaymentMode == dtoDonation.PaymentModeManualTransfer {
+		return h.createManualTransferDonation(c, req, user, campaign)
 	}
 
 	now := time.Now()
@@ -1253,105 +1231,110 @@ func (h *Handler) CreateDonation(c echo.Context) error {
 	orderID := fmt.Sprintf("DONATION-%d-%d", req.UserID, now.Unix())
 
 	donation := models.Donation{
-		Amount:     req.Amount,
-		Date:       now,
-		Status:     "pending",
-		UserID:     req.UserID,
-		CampaignID: req.CampaignID,
-		CreatedAt:  now,
-		UpdatedAt:  now,
-		OrderID:    orderID,
-	}
-
-	if err := h.donationRepository.Create(&donation); err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to create donation",
-		})
+		Amount:      req.Amount,
+		Date:        now,
+		Status:      models.DonationStatusPending,
+		UserID:      &req.UserID,
+		CampaignID:  req.CampaignID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		OrderID:     orderID,
+		Channel:     models.DonationChannelOnline,
+		FundType:    campaign.FundType,
+		IsAnonymous: req.IsAnonymous,
+
+		PaymentProvider: h.paymentService.DefaultProvider(),
+	}
+
+	return h.checkoutGateway(c, donation, user, campaign)
+}
+
+// donatableCampaign campaign tujuan donasi baru; ditolak jika tidak ada, target sudah tercapai,
+// atau jenis dana yang diminta donatur (opsional) berbeda dengan jenis dana campaign
+func (h *Handler) donatableCampaign(campaignID int, fundType string) (*models.Campaign, error) {
+	campaign, err := h.campaignRepository.GetByID(uint(campaignID))
+	if err != nil {
+		return nil, response.Fail(http.StatusInternalServerError, "Failed to get campaign")
 	}
 
-	donation.User = *user
+	if campaign == nil {
+		return nil, response.NewError(http.StatusNotFound, response.CodeCampaignNotFound, "Campaign not found")
+	}
+
+	if campaign.TotalCollected >= campaign.TargetTotal {
+		return nil, response.NewError(http.StatusConflict, response.CodeTargetReached, "Campaign has already reached its target")
+	}
+
+	if fundType != "" && fundType != campaign.FundType {
+		return nil, response.NewError(http.StatusUnprocessableEntity, response.CodeFundTypeMismatch,
+			fmt.Sprintf("Campaign collects %s, not %s", campaign.FundType, fundType))
+	}
+	return campaign, nil
+}
+
+// checkoutGateway menyimpan donasi pending lalu membuat transaksi di payment gateway.
+// user nil untuk donatur tamu.
+func (h *Handler) checkoutGateway(c echo.Context, donation models.Donation, user *models.User, campaign *models.Campaign) error {
+	if err := h.donationRepository.WithContext(c.Request().Context()).Create(&donation); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to create donation")
+	}
+
+	donation.User = user
 	donation.Campaign = *campaign
 
-	paymentResp, err := h.paymentService.CreateTransaction(donation)
+	paymentResp, err := h.paymentService.CreateTransaction(c.Request().Context(), donation)
 	if err != nil {
-		donation.Status = "failed"
-		_ = h.donationRepository.Update(&donation)
+		if _, err := h.donationService.TransitionStatus(c.Request().Context(), uint(donation.ID), models.DonationStatusFailed); err != nil {
+			log.Printf("Failed to mark donation %d as failed: %v", donation.ID, err)
+		}
 
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to create payment: " + err.Error(),
-		})
+		return response.NewError(http.StatusBadGateway, response.CodePaymentFailed, "Failed to create payment").Wrap(err)
 	}
 
+	// Charge sudah dibuat di gateway dan webhook dicocokkan lewat order_id, jadi kegagalan menyimpan
+	// URL hanya dicatat; mengembalikan error membuat donatur mengulang dan membuat charge ganda
 	donation.PaymentURL = paymentResp.RedirectURL
-	if err := h.donationRepository.Update(&donation); err != nil {
+	if err := h.donationRepository.WithContext(c.Request().Context()).Update(&donation); err != nil {
+		log.Printf("[Payment] Failed to save payment URL for order_id=%s: %v", donation.OrderID, err)
 	}
 
-	return c.JSON(http.StatusCreated, dto.SuccessResult{
-		Code: http.StatusCreated,
-		Data: map[string]interface{}{
-			"donation":    donation,
-			"payment_url": paymentResp.RedirectURL,
-			"token":       paymentResp.Token,
-		},
+	return response.Success(c, http.StatusCreated, map[string]interface{}{
+		"donation":    donation,
+		"payment_url": paymentResp.RedirectURL,
+		"token":       paymentResp.Token,
 	})
 }
 
 func (h *Handler) GetDonationByID(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Invalid donation ID format",
-		})
+		return response.Fail(http.StatusBadRequest, "Invalid donation ID format")
 	}
 
 	donation, err := h.donationRepository.GetByID(uint(id))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to get donation",
-		})
+		return response.Fail(http.StatusInternalServerError, "Failed to get donation")
 	}
 
 	if donation == nil {
-		return c.JSON(http.StatusNotFound, dto.ErrorResult{
-			Code:    http.StatusNotFound,
-			Message: "Donation not found",
-		})
+		return response.NewError(http.StatusNotFound, response.CodeDonationNotFound, "Donation not found")
 	}
 
-	return c.JSON(http.StatusOK, dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: donation,
-	})
+	// Donatur anonim tetap terlihat oleh pemiliknya dan pengelola
+	result := []models.Donation{*donation}
+	publicDonations(c, result)
+
+	return response.Success(c, http.StatusOK, result[0])
 }
 
 // GetAllDonationsAdmin - Get all donations for admin
 func (h *Handler) GetAllDonationsAdmin(c echo.Context) error {
-	// Check if user is admin
-	userID := c.Get("userLogin").(int)
-	user, err := h.userRepository.GetByID(uint(userID))
-	if err != nil || user == nil || !user.IsAdmin {
-		return c.JSON(http.StatusForbidden, dto.ErrorResult{
-			Code:    http.StatusForbidden,
-			Message: "Access denied. Admin only.",
-		})
-	}
-
 	donations, err := h.donationRepository.GetAllWithDetails()
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to fetch donations",
-		})
+		return response.Fail(http.StatusInternalServerError, "Failed to fetch donations")
 	}
 
-	return c.JSON(http.StatusOK, dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: donations,
-	})
+	return response.Success(c, http.StatusOK, donations)
 }
 
 // GetDonationsByUser - Get donations by user ID
@@ -1359,42 +1342,20 @@ func (h *Handler) GetDonationsByUser(c echo.Context) error {
 	userIDStr := c.Param("userId")
 	userID, err := strconv.Atoi(userIDStr)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Invalid user ID",
-		})
-	}
-
-	// Check if requesting own data or admin
-	currentUserID := c.Get("userLogin").(int)
-	currentUser, err := h.userRepository.GetByID(uint(currentUserID))
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to verify user",
-		})
+		return response.Fail(http.StatusBadRequest, "Invalid user ID")
 	}
 
-	// Allow if admin or requesting own data
-	if !currentUser.IsAdmin && currentUserID != userID {
-		return c.JSON(http.StatusForbidden, dto.ErrorResult{
-			Code:    http.StatusForbidden,
-			Message: "Access denied",
-		})
+	// Allow if requesting own data or has permission to read all donations
+	if !middleware.IsSelfOrHasPermission(c, userID, middleware.PermDonationReadAll) {
+		return response.Fail(http.StatusForbidden, "Access denied")
 	}
 
 	donations, err := h.donationRepository.GetByUser(uint(userID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to fetch user donations",
-		})
+		return response.Fail(http.StatusInternalServerError, "Failed to fetch user donations")
 	}
 
-	return c.JSON(http.StatusOK, dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: donations,
-	})
+	return response.Success(c, http.StatusOK, donations)
 }
 
 // GetAllDonations - Get all donations (bisa dengan filter)
@@ -1404,279 +1365,324 @@ func (h *Handler) GetAllDonations(c echo.Context) error {
 	if campaignID != "" {
 		campaignIDUint, err := strconv.ParseUint(campaignID, 10, 32)
 		if err != nil {
-			return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-				Code:    http.StatusBadRequest,
-				Message: "Invalid campaign ID",
-			})
+			return response.Fail(http.StatusBadRequest, "Invalid campaign ID")
 		}
 
 		donations, err := h.donationRepository.GetByCampaign(uint(campaignIDUint))
 		if err != nil {
-			return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-				Code:    http.StatusInternalServerError,
-				Message: "Failed to fetch campaign donations",
-			})
+			return response.Fail(http.StatusInternalServerError, "Failed to fetch campaign donations")
 		}
 
-		return c.JSON(http.StatusOK, dto.SuccessResult{
-			Code: http.StatusOK,
-			Data: donations,
-		})
+		publicDonations(c, donations)
+
+		return response.Success(c, http.StatusOK, donations)
 	}
 
 	// Jika tidak ada filter, kembalikan semua donations
 	donations, err := h.donationRepository.GetAll()
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to fetch donations",
-		})
+		return response.Fail(http.StatusInternalServerError, "Failed to fetch donations")
 	}
 
-	return c.JSON(http.StatusOK, dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: donations,
-	})
+	publicDonations(c, donations)
+
+	return response.Success(c, http.StatusOK, donations)
 }
 
 func (h *Handler) UpdateDonation(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Invalid donation ID format",
-		})
+		return response.Fail(http.StatusBadRequest, "Invalid donation ID format")
 	}
 
 	donation, err := h.donationRepository.GetByID(uint(id))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to get donation",
-		})
+		return response.Fail(http.StatusInternalServerError, "Failed to get donation")
 	}
 
 	if donation == nil {
-		return c.JSON(http.StatusNotFound, dto.ErrorResult{
-			Code:    http.StatusNotFound,
-			Message: "Donation not found",
-		})
+		return response.NewError(http.StatusNotFound, response.CodeDonationNotFound, "Donation not found")
 	}
 
-	if err := c.Bind(donation); err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Invalid request body",
-		})
+	var req dtoDonation.DonationUpdateRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+	if req.Status != "" && req.Status != donation.Status {
+		if !models.IsValidDonationStatus(req.Status) {
+			return response.Fail(http.StatusBadRequest, "Invalid donation status")
+		}
+		// Refund harus lewat gateway agar dana benar-benar kembali ke donatur
+		if req.Status == models.DonationStatusRefunded {
+			return response.NewError(http.StatusConflict, response.CodeRefundNotAllowed, "Use POST /donations/:id/refunds to refund a donation")
+		}
+	}
+
+	// Nominal, campaign, donatur dan jenis dana sudah masuk total campaign dan alokasi dana
+	if donation.CreditedAt != nil &&
+		((req.Amount != nil && *req.Amount != donation.Amount) ||
+			(req.CampaignID != nil && *req.CampaignID != donation.CampaignID) ||
+			(req.UserID != nil && (donation.UserID == nil || *req.UserID != *donation.UserID)) ||
+			(req.FundType != "" && req.FundType != donation.FundType)) {
+		return repositories.ErrDonationCredited
 	}
 
+	if req.Amount != nil {
+		donation.Amount = *req.Amount
+	}
+	if req.Date != nil {
+		donation.Date = *req.Date
+	}
+	if req.UserID != nil {
+		donation.UserID = req.UserID
+	}
+	if req.CampaignID != nil && *req.CampaignID != donation.CampaignID {
+		// Jenis dana mengikuti campaign baru kecuali diminta eksplisit (dicek di repository)
+		donation.CampaignID = *req.CampaignID
+		donation.FundType = ""
+	}
+	if req.FundType != "" {
+		donation.FundType = req.FundType
+	}
+	if req.DonorName != nil {
+		donation.DonorName = strings.TrimSpace(*req.DonorName)
+	}
+	if req.IsAnonymous != nil {
+		donation.IsAnonymous = *req.IsAnonymous
+	}
 	donation.UpdatedAt = time.Now()
 
-	if err := h.donationRepository.Update(donation); err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to update donation",
-		})
+	// Field dan status disimpan dalam satu transaksi; status hanya berpindah lewat state machine
+	// agar total campaign tetap konsisten
+	if err := h.donationService.UpdateDonation(c.Request().Context(), donation, req.Status); err != nil {
+		if errors.Is(err, repositories.ErrDonationCredited) || errors.Is(err, repositories.ErrFundTypeMismatch) ||
+			errors.Is(err, services.ErrInvalidStatusTransition) || errors.Is(err, services.ErrManualCreditNotAllowed) {
+			return err
+		}
+		return response.Fail(http.StatusInternalServerError, "Failed to update donation").Wrap(err)
 	}
 
-	return c.JSON(http.StatusOK, dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: donation,
-	})
+	updated, err := h.donationRepository.GetByID(uint(id))
+	if err != nil || updated == nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to get donation").Wrap(err)
+	}
+	return response.Success(c, http.StatusOK, updated)
 }
 
 func (h *Handler) DeleteDonation(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Invalid donation ID format",
-		})
+		return response.Fail(http.StatusBadRequest, "Invalid donation ID format")
 	}
 
-	if err := h.donationRepository.Delete(uint(id)); err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to delete donation",
-		})
+	// Donasi yang masih terhitung di total campaign harus di-refund dulu agar total dan alokasi ikut dibalik
+	if err := h.donationRepository.WithContext(c.Request().Context()).Delete(uint(id)); err != nil {
+		if errors.Is(err, repositories.ErrDonationCredited) {
+			return err
+		}
+		return response.Fail(http.StatusInternalServerError, "Failed to delete donation")
 	}
 
-	return c.JSON(http.StatusOK, dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: "Donation deleted successfully",
-	})
+	return response.Success(c, http.StatusOK, "Donation deleted successfully")
 }
 
 func (h *Handler) GetByCampaign(c echo.Context) error {
 	campaignID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Invalid campaign ID format",
-		})
+		return response.Fail(http.StatusBadRequest, "Invalid campaign ID format")
 	}
 
 	donations, err := h.donationRepository.GetByCampaign(uint(campaignID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to get donations by campaign",
-		})
+		return response.Fail(http.StatusInternalServerError, "Failed to get donations by campaign")
 	}
 
-	return c.JSON(http.StatusOK, dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: donations,
-	})
+	publicDonations(c, donations)
+
+	return response.Success(c, http.StatusOK, donations)
 }
 
 // ==================== Payment Notification ====================
 
+// HandlePaymentNotification memproses webhook payment provider (:provider, default Midtrans).
+// Webhook hanya dipercaya jika signature/token valid, dan status yang dipakai diambil ulang
+// langsung dari API provider.
 func (h *Handler) HandlePaymentNotification(c echo.Context) error {
-	var notification map[string]interface{}
-	if err := c.Bind(&notification); err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Invalid notification payload",
-		})
+	provider := c.Param("provider")
+	if provider == "" {
+		provider = models.PaymentProviderMidtrans
 	}
 
-	// Ambil order_id
-	orderID, ok := notification["order_id"].(string)
-	if !ok || orderID == "" {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Missing order ID in notification",
-		})
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, 1<<20))
+	if err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid notification payload")
+	}
+
+	notification, err := h.paymentService.ParseNotification(provider, body, c.Request().Header)
+	switch {
+	case errors.Is(err, payment.ErrUnknownProvider):
+		return response.Fail(http.StatusNotFound, "Unknown payment provider")
+	case errors.Is(err, payment.ErrInvalidSignature):
+		log.Printf("[Payment] Rejected %s notification with invalid signature: ip=%s", provider, c.RealIP())
+		return response.NewError(http.StatusUnauthorized, response.CodeInvalidSignature, "Invalid notification signature")
+	case err != nil:
+		return response.Fail(http.StatusBadRequest, "Invalid notification payload").Wrap(err)
 	}
 
-	// Ambil transaction status
-	transactionStatus, _ := notification["transaction_status"].(string)
-	fraudStatus, _ := notification["fraud_status"].(string)
-	paymentType, _ := notification["payment_type"].(string)
+	orderID := notification.OrderID
 
 	// Cari donation berdasarkan order_id
 	donation, err := h.donationRepository.GetByOrderID(orderID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to get donation",
-		})
+		return response.Fail(http.StatusInternalServerError, "Failed to get donation")
 	}
 	if donation == nil {
-		return c.JSON(http.StatusNotFound, dto.ErrorResult{
-			Code:    http.StatusNotFound,
-			Message: "Donation not found",
-		})
+		return response.NewError(http.StatusNotFound, response.CodeDonationNotFound, "Donation not found")
+	}
+	if donation.PaymentProvider != "" && donation.PaymentProvider != provider {
+		log.Printf("[Payment] Rejected %s notification for %s donation: order_id=%s", provider, donation.PaymentProvider, orderID)
+		return response.NewError(http.StatusConflict, response.CodePaymentMismatch, "Notification provider does not match donation")
 	}
 
-	// Mapping status Midtrans ke status internal
-	switch transactionStatus {
-	case "capture":
-		switch fraudStatus {
-		case "accept":
-			donation.Status = "success"
-		case "challenge":
-			donation.Status = "pending"
-		default:
-			donation.Status = "failed"
-		}
-
-	case "settlement":
-		donation.Status = "success"
+	// Webhook khusus refund (mis. Xendit refund.succeeded) tidak membawa nominal transaksi;
+	// refund-nya tetap diambil ulang dari status API di bawah
+	refundEvent := notification.Status == "" && len(notification.Refunds) > 0
+	if !refundEvent && !payment.AmountMatches(notification.GrossAmount, donation.Amount) {
+		log.Printf("[Payment] Rejected notification with mismatched amount: order_id=%s gross_amount=%.2f amount=%.2f",
+			orderID, notification.GrossAmount, donation.Amount)
+		return response.NewError(http.StatusConflict, response.CodePaymentMismatch, "Notification amount does not match donation")
+	}
 
-	case "pending":
-		donation.Status = "pending"
+	// Status dari body hanya pemicu; yang dipakai adalah status dari API provider
+	status, err := h.paymentService.GetTransactionStatus(c.Request().Context(), *donation)
+	if err != nil {
+		return response.NewError(http.StatusBadGateway, response.CodePaymentFailed, "Failed to verify transaction status").Wrap(err)
+	}
+	if status.OrderID != orderID || !payment.AmountMatches(status.GrossAmount, donation.Amount) {
+		log.Printf("[Payment] Rejected notification not matching transaction status: order_id=%s status_order_id=%s gross_amount=%.2f amount=%.2f",
+			orderID, status.OrderID, status.GrossAmount, donation.Amount)
+		return response.NewError(http.StatusConflict, response.CodePaymentMismatch, "Transaction status does not match donation")
+	}
+	if status.RawStatus != notification.RawStatus {
+		log.Printf("[Payment] Notification status %q differs from API status %q for order_id=%s, using API status",
+			notification.RawStatus, status.RawStatus, orderID)
+	}
 
-	case "deny", "cancel", "expire":
-		donation.Status = "failed"
+	// Perubahan dari webhook dicatat atas nama provider, bukan user
+	ctx := audit.WithSystemActor(c.Request().Context(), provider)
 
-	default:
-		donation.Status = "unknown"
+	// Refund (termasuk yang dibuat lewat dashboard provider) mengurangi total campaign sebelum status diproses
+	if len(status.Refunds) > 0 {
+		if err := h.refundService.SyncFromGateway(ctx, orderID, provider, status.Refunds); err != nil {
+			return response.Fail(http.StatusInternalServerError, "Failed to record refunds").Wrap(err)
+		}
+	}
+	if refundEvent {
+		return response.Success(c, http.StatusOK, "Refund notification processed")
 	}
 
-	donation.PaymentMethod = paymentType
-
-	// Update donation
-	if err := h.donationRepository.Update(donation); err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to update donation status",
-		})
+	donationStatus := services.DonationStatusFromPayment(status.Status)
+	if donationStatus == "" {
+		log.Printf("[Payment] Ignoring unhandled transaction status %q for order_id=%s", status.RawStatus, orderID)
+		return response.Success(c, http.StatusOK, "Notification ignored")
 	}
 
-	// Kalau sukses, update campaign total_collected
-	if donation.Status == "success" {
-		campaign, err := h.campaignRepository.GetByID(uint(donation.CampaignID))
-		if err != nil {
-			return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-				Code:    http.StatusInternalServerError,
-				Message: "Failed to get campaign",
-			})
-		}
+	processed := &models.PaymentNotification{
+		OrderID:           orderID,
+		TransactionID:     status.TransactionID,
+		TransactionStatus: status.RawStatus,
+		FraudStatus:       status.FraudStatus,
+		GrossAmount:       strconv.FormatFloat(status.GrossAmount, 'f', 2, 64),
+		DonationStatus:    donationStatus,
+		Source:            models.NotificationSourceWebhook,
+	}
 
-		campaign.TotalCollected += donation.Amount
-		if err := h.campaignRepository.Update(campaign); err != nil {
-			return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-				Code:    http.StatusInternalServerError,
-				Message: "Failed to update campaign total",
-			})
-		}
+	// Status donasi dan total campaign diubah dalam satu transaksi; retry notifikasi yang sama diabaikan
+	duplicate, err := h.donationService.ApplyPaymentNotification(ctx, processed, status.PaymentType)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to update donation status").Wrap(err)
+	}
+	if duplicate {
+		return response.Success(c, http.StatusOK, "Notification already processed")
+	}
+	if processed.Result == models.NotificationResultIgnored {
+		log.Printf("[Payment] Ignored status transition to %q for order_id=%s (current status is final)", donationStatus, orderID)
 	}
 
-	return c.JSON(http.StatusOK, dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: "Notification processed successfully",
-	})
+	return response.Success(c, http.StatusOK, "Notification processed successfully")
 }
 
+// GetDonationSummary rekap donasi sukses. by_fund memisahkan setiap jenis dana;
+// dengan ?fund_type= seluruh angka hanya untuk jenis dana tersebut.
 func (h *Handler) GetDonationSummary(c echo.Context) error {
-	count, err := h.donationRepository.CountPaid()
+	fundType := c.QueryParam("fund_type")
+	if fundType != "" && !models.IsValidFundType(fundType) {
+		return response.Fail(http.StatusBadRequest, "Invalid fund type")
+	}
+
+	byFund, err := h.fundSummaries(nil, nil)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    500,
-			Message: "Failed to count donations",
+		return response.Fail(http.StatusInternalServerError, "Failed to sum donation amount per fund type")
+	}
+
+	if fundType != "" {
+		// Jenis dana tanpa transaksi tetap direkap 0, bukan jatuh ke total semua dana
+		fund := fundSummary{FundType: fundType, ByChannel: []repositories.ChannelTotal{}}
+		for _, f := range byFund {
+			if f.FundType == fundType {
+				fund = f
+				break
+			}
+		}
+		return response.Success(c, http.StatusOK, map[string]interface{}{
+			"fund_type":          fund.FundType,
+			"total_transactions": fund.Count,
+			"total_amount":       fund.Amount,
+			"net_amount":         fund.Net,
+			"amil_amount":        fund.Amil,
+			"gateway_fee_amount": fund.GatewayFee,
+			"by_channel":         fund.ByChannel,
 		})
 	}
 
+	count, err := h.donationRepository.CountPaid()
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to count donations")
+	}
+
 	total, err := h.donationRepository.SumPaidAmount()
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    500,
-			Message: "Failed to sum donation amount",
-		})
+		return response.Fail(http.StatusInternalServerError, "Failed to sum donation amount")
 	}
 
-	return c.JSON(http.StatusOK, dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: map[string]interface{}{
-			"total_transactions": count,
-			"total_amount":       total,
-		},
+	byChannel, err := h.donationRepository.SumPaidByChannel()
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to sum donation amount per channel")
+	}
+
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"total_transactions": count,
+		"total_amount":       total,
+		"by_channel":         byChannel,
+		"by_fund":            byFund,
 	})
 }
 func (h *Handler) GetDonationCountByCampaign(c echo.Context) error {
 	campaignID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, dto.ErrorResult{
-			Code:    http.StatusBadRequest,
-			Message: "Invalid campaign ID format",
-		})
+		return response.Fail(http.StatusBadRequest, "Invalid campaign ID format")
 	}
 
 	count, err := h.donationRepository.CountByCampaign(uint(campaignID))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResult{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to count donations for campaign",
-		})
+		return response.Fail(http.StatusInternalServerError, "Failed to count donations for campaign")
 	}
 
-	return c.JSON(http.StatusOK, dto.SuccessResult{
-		Code: http.StatusOK,
-		Data: map[string]interface{}{
-			"campaign_id": uint(campaignID),
-			"count":       count,
-		},
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"campaign_id": uint(campaignID),
+		"count":       count,
 	})
 }
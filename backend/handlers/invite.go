@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	dtoAuth "zakat/dto/auth"
+	"zakat/models"
+	"zakat/pkg/middleware"
+	"zakat/pkg/phone"
+	"zakat/repositories"
+
+	jwtToken "zakat/pkg/jwt"
+	"zakat/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ==================== Admin Invite Handlers ====================
+
+// adminInviteTTL dibaca dari ADMIN_INVITE_TTL (default 72 jam)
+func adminInviteTTL() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("ADMIN_INVITE_TTL")); err == nil && d > 0 {
+		return d
+	}
+	return 72 * time.Hour
+}
+
+// signInviteToken menandatangani token undangan dengan SECRET_KEY; hanya hasilnya yang disimpan
+func signInviteToken(token string) string {
+	mac := hmac.New(sha256.New, []byte(jwtToken.GetSecretKey()))
+	mac.Write([]byte("admin_invite|" + token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// inviteResponse menambahkan status terhitung ke data undangan
+func inviteResponse(invite *models.AdminInvite) map[string]interface{} {
+	return map[string]interface{}{
+		"id":             invite.ID,
+		"email":          invite.Email,
+		"phone":          invite.Phone,
+		"role":           invite.Role,
+		"channel":        invite.Channel,
+		"status":         invite.Status(),
+		"invited_by_id":  invite.InvitedByID,
+		"invited_by":     strings.TrimSpace(invite.InvitedBy.FirstName + " " + invite.InvitedBy.LastName),
+		"expires_at":     invite.ExpiresAt,
+		"accepted_at":    invite.AcceptedAt,
+		"accepted_by_id": invite.AcceptedByID,
+		"revoked_at":     invite.RevokedAt,
+		"created_at":     invite.CreatedAt,
+	}
+}
+
+func (h *Handler) CreateAdminInvite(c echo.Context) error {
+	inviterID := c.Get("userLogin").(int)
+
+	var req dtoAuth.CreateInviteRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	if !models.IsStaffRole(req.Role) {
+		return response.Fail(http.StatusBadRequest, "Invalid role")
+	}
+
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+	if req.Email == "" && req.Phone == "" {
+		return response.Fail(http.StatusBadRequest, "Email or phone is required")
+	}
+	if req.Phone != "" {
+		phoneNumber, err := phone.Normalize(req.Phone)
+		if err != nil {
+			return response.Fail(http.StatusBadRequest, "Invalid phone number")
+		}
+		req.Phone = phoneNumber
+	}
+
+	if req.Channel == "" {
+		req.Channel = "email"
+		if req.Email == "" {
+			req.Channel = "whatsapp"
+		}
+	}
+	if (req.Channel == "email" && req.Email == "") || (req.Channel == "whatsapp" && req.Phone == "") {
+		return response.Fail(http.StatusBadRequest, "Destination for the selected channel is required")
+	}
+
+	token, err := generateSecureToken(32)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to generate invite token")
+	}
+
+	ttl := adminInviteTTL()
+	invite := &models.AdminInvite{
+		Email:       req.Email,
+		Phone:       req.Phone,
+		Role:        req.Role,
+		Channel:     req.Channel,
+		TokenHash:   signInviteToken(token),
+		InvitedByID: inviterID,
+		ExpiresAt:   time.Now().Add(ttl),
+	}
+	if err := h.adminInviteRepository.Create(invite); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to create invite")
+	}
+
+	ttlHours := int(ttl.Hours())
+	switch req.Channel {
+	case "whatsapp":
+		err = h.whatsappService.SendAdminInviteMessage(req.Phone, req.Role, token, ttlHours)
+	default:
+		if h.emailService == nil {
+			fmt.Printf("=== UNDANGAN ADMIN (SIMULASI) ===\nKepada: %s\nToken: %s\n=================================\n", req.Email, token)
+		} else {
+			err = h.emailService.SendAdminInviteEmail(req.Email, req.Role, token, ttlHours)
+		}
+	}
+	if err != nil {
+		// Undangan yang gagal dikirim dicabut agar tidak ada token menggantung
+		_ = h.adminInviteRepository.Revoke(invite.ID, inviterID)
+		return response.Fail(http.StatusInternalServerError, "Failed to send invite")
+	}
+
+	invite, _ = h.adminInviteRepository.GetByID(invite.ID)
+	return response.Success(c, http.StatusCreated, inviteResponse(invite))
+}
+
+func (h *Handler) GetAdminInvites(c echo.Context) error {
+	invites, err := h.adminInviteRepository.List(c.QueryParam("status"))
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to get invites")
+	}
+
+	data := make([]map[string]interface{}, len(invites))
+	for i := range invites {
+		data[i] = inviteResponse(&invites[i])
+	}
+
+	return response.Success(c, http.StatusOK, data)
+}
+
+func (h *Handler) RevokeAdminInvite(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid invite ID format")
+	}
+
+	if err := h.adminInviteRepository.Revoke(uint(id), c.Get("userLogin").(int)); err != nil {
+		if errors.Is(err, repositories.ErrInviteUnavailable) {
+			return response.NewError(http.StatusConflict, response.CodeInviteUnavailable, "Invite not found or already accepted/revoked")
+		}
+		return response.Fail(http.StatusInternalServerError, "Failed to revoke invite")
+	}
+
+	return response.Success(c, http.StatusOK, "Invite revoked")
+}
+
+// AcceptAdminInvite memberikan role dari undangan ke user yang sedang login.
+// Email/nomor HP user harus sama dengan tujuan undangan dan sudah terverifikasi.
+func (h *Handler) AcceptAdminInvite(c echo.Context) error {
+	userID := c.Get("userLogin").(int)
+
+	var req dtoAuth.AcceptInviteRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	invite, err := h.adminInviteRepository.GetByTokenHash(signInviteToken(strings.TrimSpace(req.Token)))
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to get invite")
+	}
+	if invite == nil {
+		return response.NewError(http.StatusNotFound, response.CodeInviteNotFound, "Invite not found")
+	}
+	if status := invite.Status(); status != models.InviteStatusPending {
+		return response.NewError(http.StatusGone, response.CodeInviteUnavailable, "Invite is "+status)
+	}
+
+	user, err := h.userRepository.GetByID(uint(userID))
+	if err != nil || user == nil {
+		return response.NewError(http.StatusNotFound, response.CodeUserNotFound, "User not found")
+	}
+
+	if invite.Email != "" && !strings.EqualFold(user.Email, invite.Email) ||
+		invite.Email == "" && user.Phone != invite.Phone {
+		return response.Fail(http.StatusForbidden, "This invite was issued to a different account")
+	}
+	if invite.Email != "" && user.EmailVerifiedAt == nil {
+		return response.NewError(http.StatusForbidden, response.CodeEmailNotVerified, "Please verify your email before accepting the invite")
+	}
+	if invite.Email == "" && user.PhoneVerifiedAt == nil {
+		return response.NewError(http.StatusForbidden, response.CodePhoneNotVerified, "Please verify your phone number before accepting the invite")
+	}
+
+	// Undangan dipakai dan role diganti dalam satu transaksi. ErrInviteUnavailable (dipakai bersamaan)
+	// dan ErrInviteDowngrade dipetakan oleh HTTPErrorHandler.
+	user, err = h.adminInviteRepository.WithContext(c.Request().Context()).Accept(invite.ID, user.ID)
+	if err != nil {
+		return err
+	}
+
+	// Access token lama masih membawa role sebelumnya, jadi terbitkan ulang untuk sesi ini
+	data := map[string]interface{}{
+		"role":                    user.EffectiveRole(),
+		"is_admin":                user.IsAdmin,
+		"mfa_enrollment_required": middleware.RoleRequiresMFA(user.EffectiveRole()) && user.TOTPEnabledAt == nil,
+	}
+	sessionID, _ := c.Get("sessionID").(string)
+	if session, err := h.sessionRepository.GetByID(sessionID); err == nil && session != nil {
+		if token, err := generateAccessToken(user, session); err == nil {
+			data["token"] = token
+		}
+	}
+
+	return response.Success(c, http.StatusOK, data)
+}
@@ -0,0 +1,498 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	dtoMustahik "zakat/dto/mustahik"
+	"zakat/models"
+	"zakat/pkg/docstore"
+	"zakat/pkg/response"
+	"zakat/pkg/secretbox"
+	"zakat/repositories"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ==================== Mustahik Handlers ====================
+// Data mustahik hanya untuk role amil (mustahik:read / mustahik:manage). NIK lengkap hanya
+// muncul di detail; daftar hanya menampilkan 4 digit terakhir.
+
+// GetMustahikList daftar mustahik (?asnaf=&status=&city=&q=)
+func (h *Handler) GetMustahikList(c echo.Context) error {
+	page, limit := pagination(c, 20, 100)
+
+	filter := repositories.MustahikFilter{
+		Asnaf:  c.QueryParam("asnaf"),
+		Status: c.QueryParam("status"),
+		City:   strings.TrimSpace(c.QueryParam("city")),
+		Search: strings.TrimSpace(c.QueryParam("q")),
+	}
+	if filter.Asnaf != "" && !models.IsValidAsnaf(filter.Asnaf) {
+		return response.Fail(http.StatusBadRequest, "Invalid asnaf filter")
+	}
+	switch filter.Status {
+	case "", models.MustahikStatusPending, models.MustahikStatusEligible, models.MustahikStatusIneligible, models.MustahikStatusInactive:
+	default:
+		return response.Fail(http.StatusBadRequest, "Invalid status filter")
+	}
+
+	mustahik, total, err := h.mustahikRepository.List(filter, limit, (page-1)*limit)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to fetch mustahik").Wrap(err)
+	}
+
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"mustahik": mustahik,
+		"page":     page,
+		"limit":    limit,
+		"total":    total,
+	})
+}
+
+// CheckMustahikNIK mengecek apakah NIK sudah terdaftar sebelum pendaftaran
+func (h *Handler) CheckMustahikNIK(c echo.Context) error {
+	var req dtoMustahik.MustahikNIKCheckRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	existing, err := h.mustahikRepository.GetByNIKDigest(secretbox.Digest(req.NIK))
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to check NIK").Wrap(err)
+	}
+	if existing == nil {
+		return response.Success(c, http.StatusOK, map[string]interface{}{"registered": false})
+	}
+
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"registered": true,
+		"mustahik":   existing,
+	})
+}
+
+func (h *Handler) CreateMustahik(c echo.Context) error {
+	var req dtoMustahik.MustahikCreateRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	userID, ok := c.Get("userLogin").(int)
+	if !ok {
+		return response.Fail(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	birthDate, err := optionalDate(req.BirthDate)
+	if err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid birth_date, use YYYY-MM-DD")
+	}
+	members, err := mustahikMembers(req.Members)
+	if err != nil {
+		return err
+	}
+
+	mustahik := models.Mustahik{
+		FullName:      strings.TrimSpace(req.FullName),
+		Gender:        req.Gender,
+		BirthDate:     birthDate,
+		Phone:         strings.TrimSpace(req.Phone),
+		Address:       strings.TrimSpace(req.Address),
+		Village:       strings.TrimSpace(req.Village),
+		District:      strings.TrimSpace(req.District),
+		City:          strings.TrimSpace(req.City),
+		Province:      strings.TrimSpace(req.Province),
+		Occupation:    strings.TrimSpace(req.Occupation),
+		MonthlyIncome: req.MonthlyIncome,
+		HouseholdSize: req.HouseholdSize,
+		Asnaf:         req.Asnaf,
+		Status:        models.MustahikStatusPending,
+		Notes:         req.Notes,
+		CreatedByID:   userID,
+		Members:       members,
+	}
+	if err := setMustahikNIK(&mustahik, req.NIK); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to secure NIK").Wrap(err)
+	}
+
+	if err := h.mustahikRepository.WithContext(c.Request().Context()).Create(&mustahik); err != nil {
+		if errors.Is(err, repositories.ErrDuplicateMustahik) {
+			return h.duplicateMustahik(mustahik.NIKDigest)
+		}
+		return response.Fail(http.StatusInternalServerError, "Failed to register mustahik").Wrap(err)
+	}
+
+	return response.Success(c, http.StatusCreated, mustahik)
+}
+
+func (h *Handler) GetMustahikByID(c echo.Context) error {
+	mustahik, err := h.mustahikFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	nik, err := secretbox.Open(mustahik.NIKEncrypted)
+	if err != nil {
+		log.Printf("[Mustahik] Failed to decrypt NIK for mustahik %d: %v", mustahik.ID, err)
+	} else {
+		mustahik.NIK = nik
+	}
+	for i := range mustahik.Documents {
+		h.signDocumentURL(&mustahik.Documents[i])
+	}
+
+	// Data pribadi: jangan disimpan di cache browser/proxy
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return response.Success(c, http.StatusOK, mustahik)
+}
+
+func (h *Handler) UpdateMustahik(c echo.Context) error {
+	mustahik, err := h.mustahikFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	var req dtoMustahik.MustahikUpdateRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	birthDate, err := optionalDate(req.BirthDate)
+	if err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid birth_date, use YYYY-MM-DD")
+	}
+	members, err := mustahikMembers(req.Members)
+	if err != nil {
+		return err
+	}
+
+	if req.NIK != "" {
+		if err := setMustahikNIK(mustahik, req.NIK); err != nil {
+			return response.Fail(http.StatusInternalServerError, "Failed to secure NIK").Wrap(err)
+		}
+	}
+	mustahik.FullName = strings.TrimSpace(req.FullName)
+	mustahik.Gender = req.Gender
+	mustahik.BirthDate = birthDate
+	mustahik.Phone = strings.TrimSpace(req.Phone)
+	mustahik.Address = strings.TrimSpace(req.Address)
+	mustahik.Village = strings.TrimSpace(req.Village)
+	mustahik.District = strings.TrimSpace(req.District)
+	mustahik.City = strings.TrimSpace(req.City)
+	mustahik.Province = strings.TrimSpace(req.Province)
+	mustahik.Occupation = strings.TrimSpace(req.Occupation)
+	mustahik.MonthlyIncome = req.MonthlyIncome
+	mustahik.HouseholdSize = req.HouseholdSize
+	mustahik.Notes = req.Notes
+	mustahik.Members = members
+	if req.Status != "" {
+		mustahik.Status = req.Status
+	}
+	mustahik.UpdatedAt = time.Now()
+
+	if err := h.mustahikRepository.WithContext(c.Request().Context()).Update(mustahik); err != nil {
+		if errors.Is(err, repositories.ErrDuplicateMustahik) {
+			return h.duplicateMustahik(mustahik.NIKDigest)
+		}
+		return response.Fail(http.StatusInternalServerError, "Failed to update mustahik").Wrap(err)
+	}
+
+	// Riwayat asesmen dan dokumen tidak ikut dikirim ulang
+	mustahik.Assessments, mustahik.Documents = nil, nil
+	return response.Success(c, http.StatusOK, mustahik)
+}
+
+// DeleteMustahik menghapus permanen data mustahik (mis. atas permintaan yang bersangkutan)
+func (h *Handler) DeleteMustahik(c echo.Context) error {
+	mustahik, err := h.mustahikFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	if err := h.destroyDocuments(ctx, mustahik.Documents); err != nil {
+		return err
+	}
+	if err := h.mustahikRepository.WithContext(ctx).Delete(mustahik.ID); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to delete mustahik").Wrap(err)
+	}
+
+	return response.Success(c, http.StatusOK, "Mustahik deleted successfully")
+}
+
+// CreateMustahikAssessment mencatat asesmen kelayakan; status dan asnaf mustahik ikut diperbarui
+func (h *Handler) CreateMustahikAssessment(c echo.Context) error {
+	mustahik, err := h.mustahikFromParam(c)
+	if err != nil {
+		return err
+	}
+
+	var req dtoMustahik.MustahikAssessmentRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	userID, ok := c.Get("userLogin").(int)
+	if !ok {
+		return response.Fail(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	validUntil, err := optionalDate(req.ValidUntil)
+	if err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid valid_until, use YYYY-MM-DD")
+	}
+
+	income := mustahik.MonthlyIncome
+	if req.MonthlyIncome != nil {
+		income = *req.MonthlyIncome
+	}
+	household := mustahik.HouseholdSize
+	if req.HouseholdSize > 0 {
+		household = req.HouseholdSize
+	}
+	if household <= 0 {
+		household = 1
+	}
+
+	assessment := models.MustahikAssessment{
+		MustahikID:      mustahik.ID,
+		AssessorID:      userID,
+		Asnaf:           req.Asnaf,
+		Eligible:        *req.Eligible,
+		MonthlyIncome:   income,
+		HouseholdSize:   household,
+		IncomePerCapita: income / float64(household),
+		Notes:           req.Notes,
+		ValidUntil:      validUntil,
+		AssessedAt:      time.Now(),
+	}
+	if err := h.mustahikRepository.WithContext(c.Request().Context()).AddAssessment(&assessment); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to save assessment").Wrap(err)
+	}
+
+	return response.Success(c, http.StatusCreated, assessment)
+}
+
+// UploadMustahikDocument mengunggah dokumen pendukung sebagai aset privat (form field "document")
+func (h *Handler) UploadMustahikDocument(c echo.Context) error {
+	const maxDocumentSize = 10 << 20 // 10MB
+
+	mustahik, err := h.mustahikFromParam(c)
+	if err != nil {
+		return err
+	}
+	if h.documentStore == nil {
+		return response.Fail(http.StatusInternalServerError, "Cloudinary configuration missing")
+	}
+
+	c.Request().Body = http.MaxBytesReader(c.Response(), c.Request().Body, maxDocumentSize)
+	if err := c.Request().ParseMultipartForm(maxDocumentSize); err != nil {
+		return response.NewError(http.StatusBadRequest, response.CodeUploadFailed, "File too large")
+	}
+
+	kind := c.FormValue("kind")
+	switch kind {
+	case models.MustahikDocumentKTP, models.MustahikDocumentKK, models.MustahikDocumentSKTM, models.MustahikDocumentPhoto, models.MustahikDocumentOther:
+	case "":
+		kind = models.MustahikDocumentOther
+	default:
+		return response.Fail(http.StatusBadRequest, "Invalid document kind")
+	}
+	note := strings.TrimSpace(c.FormValue("note"))
+	if len(note) > 255 {
+		return response.Fail(http.StatusBadRequest, "Note is too long")
+	}
+
+	userID, ok := c.Get("userLogin").(int)
+	if !ok {
+		return response.Fail(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	file, _, err := c.Request().FormFile("document")
+	if err != nil {
+		return response.NewError(http.StatusBadRequest, response.CodeUploadFailed, "Document is required")
+	}
+	defer file.Close()
+
+	ctx := c.Request().Context()
+	asset, err := h.documentStore.Upload(ctx, file)
+	if err != nil {
+		return response.NewError(http.StatusBadGateway, response.CodeUploadFailed, "Failed to upload document").Wrap(err)
+	}
+
+	document := models.MustahikDocument{
+		MustahikID:   mustahik.ID,
+		Kind:         kind,
+		PublicID:     asset.PublicID,
+		ResourceType: asset.ResourceType,
+		Format:       asset.Format,
+		Note:         note,
+		UploadedByID: userID,
+		CreatedAt:    time.Now(),
+	}
+	if err := h.mustahikRepository.WithContext(ctx).AddDocument(&document); err != nil {
+		// Aset yang tidak tercatat di database tidak akan pernah terhapus, jadi dibuang sekarang
+		if destroyErr := h.documentStore.Destroy(ctx, *asset); destroyErr != nil {
+			log.Printf("[Mustahik] Failed to destroy orphaned document %s: %v", asset.PublicID, destroyErr)
+		}
+		return response.Fail(http.StatusInternalServerError, "Failed to save document").Wrap(err)
+	}
+
+	h.signDocumentURL(&document)
+	return response.Success(c, http.StatusCreated, document)
+}
+
+// DeleteMustahikDocument menghapus aset di Cloudinary lalu barisnya
+func (h *Handler) DeleteMustahikDocument(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid mustahik ID format")
+	}
+	documentID, err := strconv.Atoi(c.Param("documentId"))
+	if err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid document ID format")
+	}
+
+	ctx := c.Request().Context()
+	repo := h.mustahikRepository.WithContext(ctx)
+	document, err := repo.GetDocument(uint(id), uint(documentID))
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to get document").Wrap(err)
+	}
+	if document == nil {
+		return response.NewError(http.StatusNotFound, response.CodeNotFound, "Document not found")
+	}
+
+	if err := h.destroyDocuments(ctx, []models.MustahikDocument{*document}); err != nil {
+		return err
+	}
+	if err := repo.DeleteDocument(document.ID); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to delete document").Wrap(err)
+	}
+
+	return response.Success(c, http.StatusOK, "Document deleted successfully")
+}
+
+// destroyDocuments menghapus aset dokumen; baris database baru dihapus setelah semua aset hilang
+// agar dokumen pribadi tidak tertinggal tanpa jejak
+func (h *Handler) destroyDocuments(ctx context.Context, documents []models.MustahikDocument) error {
+	if len(documents) == 0 {
+		return nil
+	}
+	if h.documentStore == nil {
+		return response.Fail(http.StatusInternalServerError, "Cloudinary configuration missing")
+	}
+	for _, document := range documents {
+		if document.PublicID == "" {
+			continue
+		}
+		err := h.documentStore.Destroy(ctx, docstore.Asset{PublicID: document.PublicID, ResourceType: document.ResourceType})
+		if err != nil {
+			return response.Fail(http.StatusBadGateway, "Failed to delete document file").Wrap(err)
+		}
+	}
+	return nil
+}
+
+// signDocumentURL mengisi URL unduhan bertanda tangan yang berlaku singkat
+func (h *Handler) signDocumentURL(document *models.MustahikDocument) {
+	if h.documentStore == nil || document.PublicID == "" {
+		return
+	}
+	url, err := h.documentStore.SignedURL(docstore.Asset{
+		PublicID:     document.PublicID,
+		ResourceType: document.ResourceType,
+		Format:       document.Format,
+	})
+	if err != nil {
+		log.Printf("[Mustahik] Failed to sign document %d: %v", document.ID, err)
+		return
+	}
+	document.URL = url
+}
+
+func (h *Handler) mustahikFromParam(c echo.Context) (*models.Mustahik, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return nil, response.Fail(http.StatusBadRequest, "Invalid mustahik ID format")
+	}
+
+	mustahik, err := h.mustahikRepository.GetByID(uint(id))
+	if err != nil {
+		return nil, response.Fail(http.StatusInternalServerError, "Failed to get mustahik").Wrap(err)
+	}
+	if mustahik == nil {
+		return nil, response.NewError(http.StatusNotFound, response.CodeNotFound, "Mustahik not found")
+	}
+	return mustahik, nil
+}
+
+// duplicateMustahik 409 beserta ID mustahik yang sudah terdaftar agar petugas bisa membuka datanya
+func (h *Handler) duplicateMustahik(digest string) error {
+	err := response.NewError(http.StatusConflict, response.CodeConflict, "NIK is already registered")
+	existing, lookupErr := h.mustahikRepository.GetByNIKDigest(digest)
+	if lookupErr != nil || existing == nil {
+		return err
+	}
+	return err.WithDetails(map[string]interface{}{
+		"mustahik_id": existing.ID,
+		"full_name":   existing.FullName,
+	})
+}
+
+// setMustahikNIK menyimpan NIK terenkripsi beserta digest untuk deduplikasi dan 4 digit terakhir
+func setMustahikNIK(mustahik *models.Mustahik, nik string) error {
+	sealed, err := secretbox.Seal(nik)
+	if err != nil {
+		return err
+	}
+	mustahik.NIKEncrypted = sealed
+	mustahik.NIKDigest = secretbox.Digest(nik)
+	mustahik.NIKLast4 = nik[len(nik)-4:]
+	return nil
+}
+
+func mustahikMembers(reqs []dtoMustahik.MustahikMemberRequest) ([]models.MustahikMember, error) {
+	members := make([]models.MustahikMember, 0, len(reqs))
+	for _, req := range reqs {
+		birthDate, err := optionalDate(req.BirthDate)
+		if err != nil {
+			return nil, response.Fail(http.StatusBadRequest, "Invalid member birth_date, use YYYY-MM-DD")
+		}
+		members = append(members, models.MustahikMember{
+			Name:          strings.TrimSpace(req.Name),
+			Relationship:  strings.TrimSpace(req.Relationship),
+			BirthDate:     birthDate,
+			Occupation:    strings.TrimSpace(req.Occupation),
+			MonthlyIncome: req.MonthlyIncome,
+		})
+	}
+	return members, nil
+}
+
+// optionalDate tanggal YYYY-MM-DD; kosong berarti nil
+func optionalDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
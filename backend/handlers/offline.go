@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	dtoDonation "zakat/dto/donations"
+	"zakat/models"
+	"zakat/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ==================== Offline Donation Handlers ====================
+
+// CreateOfflineDonation amil mencatat satu donasi tunai/barang di luar sesi pengumpulan
+func (h *Handler) CreateOfflineDonation(c echo.Context) error {
+	var req dtoDonation.OfflineDonationCreateRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	recorderID, ok := c.Get("userLogin").(int)
+	if !ok {
+		return response.Fail(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	if err := h.checkOfflineCampaign(req.CampaignID); err != nil {
+		return err
+	}
+	entries := []dtoDonation.OfflineDonationEntry{req.OfflineDonationEntry}
+	if err := h.checkOfflineDonors(entries); err != nil {
+		return err
+	}
+
+	donations := offlineDonations(entries, req.CampaignID, req.CollectorName, req.Location, recorderID, time.Now())
+	if err := h.donationService.RecordOfflineDonations(c.Request().Context(), nil, donations); err != nil {
+		return err
+	}
+
+	return response.Success(c, http.StatusCreated, donations[0])
+}
+
+// CreateCollectionSession membuka sesi pengumpulan offline, sekaligus mencatat entri batch jika ada
+func (h *Handler) CreateCollectionSession(c echo.Context) error {
+	var req dtoDonation.CollectionSessionCreateRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	recorderID, ok := c.Get("userLogin").(int)
+	if !ok {
+		return response.Fail(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	if err := h.checkOfflineCampaign(req.CampaignID); err != nil {
+		return err
+	}
+	if err := h.checkOfflineDonors(req.Entries); err != nil {
+		return err
+	}
+
+	collectedAt := time.Now()
+	if req.CollectedAt != nil {
+		collectedAt = *req.CollectedAt
+	}
+	session := models.CollectionSession{
+		CampaignID:    req.CampaignID,
+		Name:          strings.TrimSpace(req.Name),
+		Location:      strings.TrimSpace(req.Location),
+		CollectorName: strings.TrimSpace(req.CollectorName),
+		CollectedAt:   collectedAt,
+		Note:          req.Note,
+		CreatedByID:   recorderID,
+	}
+
+	donations := offlineDonations(req.Entries, req.CampaignID, session.CollectorName, session.Location, recorderID, collectedAt)
+	if err := h.donationService.RecordOfflineDonations(c.Request().Context(), &session, donations); err != nil {
+		return err
+	}
+
+	return response.Success(c, http.StatusCreated, map[string]interface{}{
+		"session":   session,
+		"donations": donations,
+	})
+}
+
+// AddCollectionSessionDonations input batch donasi ke sesi yang sudah ada
+func (h *Handler) AddCollectionSessionDonations(c echo.Context) error {
+	session, err := h.collectionSession(c)
+	if err != nil {
+		return err
+	}
+
+	var req dtoDonation.CollectionSessionEntriesRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	recorderID, ok := c.Get("userLogin").(int)
+	if !ok {
+		return response.Fail(http.StatusUnauthorized, "Unauthorized")
+	}
+	if err := h.checkOfflineDonors(req.Entries); err != nil {
+		return err
+	}
+
+	donations := offlineDonations(req.Entries, session.CampaignID, session.CollectorName, session.Location, recorderID, session.CollectedAt)
+	if err := h.donationService.RecordOfflineDonations(c.Request().Context(), session, donations); err != nil {
+		return err
+	}
+
+	return response.Success(c, http.StatusCreated, donations)
+}
+
+// GetCollectionSessions daftar sesi pengumpulan, bisa difilter per campaign
+func (h *Handler) GetCollectionSessions(c echo.Context) error {
+	page, limit := pagination(c, 20, 100)
+
+	var campaignID uint64
+	if raw := c.QueryParam("campaign_id"); raw != "" {
+		var err error
+		campaignID, err = strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			return response.Fail(http.StatusBadRequest, "Invalid campaign ID")
+		}
+	}
+
+	sessions, total, err := h.offlineDonationRepository.ListSessions(uint(campaignID), limit, (page-1)*limit)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to fetch collection sessions").Wrap(err)
+	}
+
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"sessions": sessions,
+		"page":     page,
+		"limit":    limit,
+		"total":    total,
+	})
+}
+
+// GetCollectionSession rekap satu sesi: daftar donasi serta total tunai dan taksiran barang
+func (h *Handler) GetCollectionSession(c echo.Context) error {
+	session, err := h.collectionSession(c)
+	if err != nil {
+		return err
+	}
+
+	donations, err := h.offlineDonationRepository.SessionDonations(session.ID)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to fetch session donations").Wrap(err)
+	}
+
+	var cashTotal, goodsTotal float64
+	for _, d := range donations {
+		if d.Status != models.DonationStatusSuccess || d.Offline == nil {
+			continue
+		}
+		if d.Offline.Kind == models.OfflineKindGoods {
+			goodsTotal += d.Amount
+		} else {
+			cashTotal += d.Amount
+		}
+	}
+
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"session":     session,
+		"donations":   donations,
+		"count":       len(donations),
+		"cash_total":  cashTotal,
+		"goods_total": goodsTotal,
+	})
+}
+
+func (h *Handler) collectionSession(c echo.Context) (*models.CollectionSession, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return nil, response.Fail(http.StatusBadRequest, "Invalid collection session ID format")
+	}
+
+	session, err := h.offlineDonationRepository.GetSession(uint(id))
+	if err != nil {
+		return nil, response.Fail(http.StatusInternalServerError, "Failed to get collection session").Wrap(err)
+	}
+	if session == nil {
+		return nil, response.NewError(http.StatusNotFound, response.CodeNotFound, "Collection session not found")
+	}
+	return session, nil
+}
+
+func (h *Handler) checkOfflineCampaign(campaignID int) error {
+	campaign, err := h.campaignRepository.GetByID(uint(campaignID))
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to get campaign")
+	}
+	if campaign == nil {
+		return response.NewError(http.StatusNotFound, response.CodeCampaignNotFound, "Campaign not found")
+	}
+	return nil
+}
+
+// checkOfflineDonors memastikan donor_user_id (jika diisi) merujuk ke akun yang ada
+func (h *Handler) checkOfflineDonors(entries []dtoDonation.OfflineDonationEntry) error {
+	for _, entry := range entries {
+		if entry.DonorUserID == nil {
+			continue
+		}
+		user, err := h.userRepository.GetByID(uint(*entry.DonorUserID))
+		if err != nil {
+			return response.Fail(http.StatusInternalServerError, "Failed to get user information")
+		}
+		if user == nil {
+			return response.NewError(http.StatusNotFound, response.CodeUserNotFound, "Donor user not found")
+		}
+	}
+	return nil
+}
+
+// offlineDonations mengubah entri request menjadi Donation beserta detail offline-nya
+func offlineDonations(entries []dtoDonation.OfflineDonationEntry, campaignID int, collector, location string, recorderID int, date time.Time) []models.Donation {
+	donations := make([]models.Donation, 0, len(entries))
+	for _, entry := range entries {
+		donationDate := date
+		if entry.Date != nil {
+			donationDate = *entry.Date
+		}
+
+		detail := &models.OfflineDonation{
+			Kind:          entry.Kind,
+			CollectorName: collector,
+			Location:      location,
+			ReceiptNumber: strings.TrimSpace(entry.ReceiptNumber),
+			RecordedByID:  recorderID,
+		}
+		if entry.Kind == models.OfflineKindGoods {
+			detail.GoodsDescription = strings.TrimSpace(entry.GoodsDescription)
+			detail.Quantity = entry.Quantity
+			detail.Unit = strings.TrimSpace(entry.Unit)
+		}
+
+		donations = append(donations, models.Donation{
+			Amount:     entry.Amount,
+			Date:       donationDate,
+			UserID:     entry.DonorUserID,
+			DonorName:  strings.TrimSpace(entry.DonorName),
+			CampaignID: campaignID,
+			Offline:    detail,
+		})
+	}
+	return donations
+}
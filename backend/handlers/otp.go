@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+	dtoAuth "zakat/dto/auth"
+	"zakat/models"
+	"zakat/pkg/phone"
+
+	jwtToken "zakat/pkg/jwt"
+	"zakat/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ==================== Phone OTP Handlers ====================
+
+const (
+	otpLength         = 6
+	otpTTL            = 5 * time.Minute
+	otpMaxAttempts    = 5
+	otpResendCooldown = time.Minute
+)
+
+// generateOTPCode membuat kode angka acak dari crypto/rand
+func generateOTPCode() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < otpLength; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", otpLength, n), nil
+}
+
+// hashOTP memakai HMAC dengan SECRET_KEY karena ruang kode 6 digit terlalu kecil untuk hash biasa
+func hashOTP(phoneNumber, purpose, code string) string {
+	mac := hmac.New(sha256.New, []byte(jwtToken.GetSecretKey()))
+	mac.Write([]byte(phoneNumber + "|" + purpose + "|" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// sendOTP membuat kode OTP baru (menonaktifkan kode lama) dan mengirimkannya
+func (h *Handler) sendOTP(phoneNumber, purpose, channel string, userID *int) error {
+	code, err := generateOTPCode()
+	if err != nil {
+		return err
+	}
+
+	if err := h.otpRepository.InvalidateActive(phoneNumber, purpose); err != nil {
+		return err
+	}
+
+	if channel == "" {
+		channel = "whatsapp"
+	}
+
+	otp := &models.PhoneOTP{
+		Phone:       phoneNumber,
+		Purpose:     purpose,
+		UserID:      userID,
+		CodeHash:    hashOTP(phoneNumber, purpose, code),
+		Channel:     channel,
+		MaxAttempts: otpMaxAttempts,
+		ExpiresAt:   time.Now().Add(otpTTL),
+	}
+	if err := h.otpRepository.Create(otp); err != nil {
+		return err
+	}
+
+	ttlMinutes := int(otpTTL.Minutes())
+	if channel == "sms" && h.smsService != nil {
+		return h.smsService.SendOTPMessage(phoneNumber, code, ttlMinutes)
+	}
+	if h.whatsappService != nil {
+		return h.whatsappService.SendOTPMessage(phoneNumber, code, ttlMinutes)
+	}
+	fmt.Printf("=== OTP (SIMULASI) ===\nKepada: %s\nKode: %s\n======================\n", phoneNumber, code)
+	return nil
+}
+
+// otpCooldown mengembalikan sisa waktu sebelum kode baru boleh dikirim ke nomor yang sama
+func (h *Handler) otpCooldown(phoneNumber, purpose string) (time.Duration, error) {
+	latest, err := h.otpRepository.GetLatest(phoneNumber, purpose)
+	if err != nil || latest == nil {
+		return 0, err
+	}
+	return time.Until(latest.CreatedAt.Add(otpResendCooldown)), nil
+}
+
+// checkOTP mencocokkan kode dengan OTP terakhir. Kode salah menambah hitungan percobaan.
+func (h *Handler) checkOTP(phoneNumber, purpose, code string) (*models.PhoneOTP, bool) {
+	otp, err := h.otpRepository.GetLatest(phoneNumber, purpose)
+	if err != nil || otp == nil || !otp.IsUsable() {
+		return nil, false
+	}
+
+	expected := hashOTP(phoneNumber, purpose, code)
+	if !hmac.Equal([]byte(expected), []byte(otp.CodeHash)) {
+		if err := h.otpRepository.IncrementAttempts(otp.ID); err != nil {
+			fmt.Printf("Gagal menambah percobaan OTP: %v\n", err)
+		}
+		return nil, false
+	}
+
+	if err := h.otpRepository.MarkConsumed(otp.ID); err != nil {
+		return nil, false
+	}
+	return otp, true
+}
+
+func (h *Handler) RequestLoginOTP(c echo.Context) error {
+	var req dtoAuth.OTPRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Format request tidak valid")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	phoneNumber, err := phone.Normalize(req.Phone)
+	if err != nil {
+		return response.Fail(http.StatusBadRequest, "Nomor HP tidak valid")
+	}
+
+	// Semua batas dicek sebelum lookup user, jadi nomor tidak terdaftar dibatasi dengan cara yang sama
+	targets := []throttleTarget{
+		{ThrottleOTPResend, phoneNumber},
+		{ThrottleOTPRequest, phoneNumber},
+		{ThrottleOTPIP, c.RealIP()},
+	}
+	if wait := h.throttleCheck(targets...); wait > 0 {
+		return tooManyRequests(c, wait)
+	}
+	h.throttleFail(targets...)
+
+	// Hanya nomor yang sudah diverifikasi yang bisa login dengan OTP.
+	// Response tetap sama agar tidak membocorkan nomor mana yang terdaftar.
+	user, err := h.userRepository.GetByVerifiedPhone(phoneNumber)
+	if err == nil && user != nil {
+		if err := h.sendOTP(phoneNumber, models.OTPPurposeLogin, req.Channel, &user.ID); err != nil {
+			fmt.Printf("Gagal mengirim OTP login: %v\n", err)
+		}
+	}
+
+	return response.SuccessMessage(c, http.StatusOK, "Jika nomor terdaftar dan terverifikasi, kode OTP telah dikirim", map[string]interface{}{
+		"phone":      phoneNumber,
+		"expires_in": int(otpTTL.Seconds()),
+	})
+}
+
+func (h *Handler) VerifyLoginOTP(c echo.Context) error {
+	var req dtoAuth.OTPVerifyRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Format request tidak valid")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	phoneNumber, err := phone.Normalize(req.Phone)
+	if err != nil {
+		return response.Fail(http.StatusBadRequest, "Nomor HP tidak valid")
+	}
+
+	targets := []throttleTarget{
+		{ThrottleOTPVerify, phoneNumber},
+		{ThrottleOTPIP, c.RealIP()},
+	}
+	if wait := h.throttleCheck(targets...); wait > 0 {
+		return tooManyRequests(c, wait)
+	}
+
+	if _, ok := h.checkOTP(phoneNumber, models.OTPPurposeLogin, req.Code); !ok {
+		h.throttleFail(targets...)
+		return response.NewError(http.StatusUnauthorized, response.CodeInvalidOTP, "Kode OTP salah atau sudah kadaluarsa")
+	}
+	h.throttleReset(ThrottleOTPVerify, phoneNumber)
+
+	user, err := h.userRepository.GetByVerifiedPhone(phoneNumber)
+	if err != nil || user == nil {
+		return response.Fail(http.StatusUnauthorized, "Nomor HP tidak terdaftar")
+	}
+
+	return h.completeLogin(c, user)
+}
+
+func (h *Handler) RequestPhoneVerification(c echo.Context) error {
+	userID := c.Get("userLogin").(int)
+
+	var req dtoAuth.PhoneVerificationRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Format request tidak valid")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	user, err := h.userRepository.GetByID(uint(userID))
+	if err != nil || user == nil {
+		return response.NewError(http.StatusNotFound, response.CodeUserNotFound, "User tidak ditemukan")
+	}
+
+	// Jika phone kosong, verifikasi nomor yang tersimpan di profil
+	if req.Phone == "" {
+		req.Phone = user.Phone
+	}
+	phoneNumber, err := phone.Normalize(req.Phone)
+	if err != nil {
+		return response.Fail(http.StatusBadRequest, "Nomor HP tidak valid")
+	}
+
+	if owner, err := h.userRepository.GetByVerifiedPhone(phoneNumber); err == nil && owner != nil && owner.ID != user.ID {
+		return response.NewError(http.StatusConflict, response.CodePhoneTaken, "Nomor HP sudah diverifikasi oleh akun lain")
+	}
+
+	targets := []throttleTarget{
+		{ThrottleOTPRequest, phoneNumber},
+		{ThrottleOTPIP, c.RealIP()},
+	}
+	if wait := h.throttleCheck(targets...); wait > 0 {
+		return tooManyRequests(c, wait)
+	}
+	if wait, err := h.otpCooldown(phoneNumber, models.OTPPurposeVerifyPhone); err == nil && wait > 0 {
+		return tooManyRequests(c, wait)
+	}
+	h.throttleFail(targets...)
+
+	if err := h.sendOTP(phoneNumber, models.OTPPurposeVerifyPhone, req.Channel, &user.ID); err != nil {
+		fmt.Printf("Gagal mengirim OTP verifikasi: %v\n", err)
+		return response.Fail(http.StatusInternalServerError, "Gagal mengirim kode OTP")
+	}
+
+	return response.SuccessMessage(c, http.StatusOK, "Kode OTP telah dikirim", map[string]interface{}{
+		"phone":      phoneNumber,
+		"expires_in": int(otpTTL.Seconds()),
+	})
+}
+
+func (h *Handler) ConfirmPhoneVerification(c echo.Context) error {
+	userID := c.Get("userLogin").(int)
+
+	var req dtoAuth.OTPVerifyRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Format request tidak valid")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	phoneNumber, err := phone.Normalize(req.Phone)
+	if err != nil {
+		return response.Fail(http.StatusBadRequest, "Nomor HP tidak valid")
+	}
+
+	target := throttleTarget{ThrottleOTPVerify, phoneNumber}
+	if wait := h.throttleCheck(target); wait > 0 {
+		return tooManyRequests(c, wait)
+	}
+
+	otp, ok := h.checkOTP(phoneNumber, models.OTPPurposeVerifyPhone, req.Code)
+	if !ok || otp.UserID == nil || *otp.UserID != userID {
+		h.throttleFail(target)
+		return response.NewError(http.StatusBadRequest, response.CodeInvalidOTP, "Kode OTP salah atau sudah kadaluarsa")
+	}
+	h.throttleReset(ThrottleOTPVerify, phoneNumber)
+
+	if owner, err := h.userRepository.GetByVerifiedPhone(phoneNumber); err == nil && owner != nil && owner.ID != userID {
+		return response.NewError(http.StatusConflict, response.CodePhoneTaken, "Nomor HP sudah diverifikasi oleh akun lain")
+	}
+
+	user, err := h.userRepository.GetByID(uint(userID))
+	if err != nil || user == nil {
+		return response.NewError(http.StatusNotFound, response.CodeUserNotFound, "User tidak ditemukan")
+	}
+
+	now := time.Now()
+	user.Phone = phoneNumber
+	user.PhoneVerifiedAt = &now
+	user.UpdatedAt = now
+
+	if err := h.userRepository.WithContext(c.Request().Context()).Update(user); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Gagal memverifikasi nomor HP")
+	}
+
+	return response.SuccessMessage(c, http.StatusOK, "Nomor HP berhasil diverifikasi", map[string]interface{}{
+		"phone":             user.Phone,
+		"phone_verified_at": user.PhoneVerifiedAt,
+	})
+}
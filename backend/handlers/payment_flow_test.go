@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"zakat/models"
+	"zakat/pkg/payment"
+	"zakat/pkg/response"
+	"zakat/repositories"
+	"zakat/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ==================== Penyimpanan in-memory ====================
+
+// memoryStore pengganti database untuk alur donasi. Do memegang kunci selama fn berjalan,
+// meniru transaksi yang mengunci baris donasi dan campaign.
+type memoryStore struct {
+	mu            sync.Mutex
+	donations     map[string]models.Donation
+	campaigns     map[int]models.Campaign
+	allocations   []models.DonationAllocation
+	notifications map[string]bool
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		donations:     map[string]models.Donation{},
+		campaigns:     map[int]models.Campaign{},
+		notifications: map[string]bool{},
+	}
+}
+
+func (s *memoryStore) Do(ctx context.Context, fn func(repos repositories.Repositories) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fn(repositories.Repositories{
+		Donations:   memoryDonations{store: s},
+		Campaigns:   memoryCampaigns{store: s},
+		Allocations: memoryAllocations{store: s},
+	})
+}
+
+func (s *memoryStore) donation(orderID string) models.Donation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.donations[orderID]
+}
+
+func (s *memoryStore) campaign(id int) models.Campaign {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.campaigns[id]
+}
+
+// memoryDonations hanya mengimplementasikan method yang dipakai alur webhook;
+// method lain memanggil interface nil dan langsung panic
+type memoryDonations struct {
+	repositories.DonationRepository
+	store *memoryStore
+}
+
+func (r memoryDonations) find(orderID string) (*models.Donation, error) {
+	donation, ok := r.store.donations[orderID]
+	if !ok {
+		return nil, nil
+	}
+	return &donation, nil
+}
+
+func (r memoryDonations) GetByOrderIDForUpdate(orderID string) (*models.Donation, error) {
+	return r.find(orderID)
+}
+
+func (r memoryDonations) HasOtherSuccessful(campaignID int, userID *int, excludeID int) (bool, error) {
+	for _, d := range r.store.donations {
+		if d.ID != excludeID && d.CampaignID == campaignID && d.Status == models.DonationStatusSuccess &&
+			d.UserID != nil && userID != nil && *d.UserID == *userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r memoryDonations) UpdateStatus(donation *models.Donation) error {
+	r.store.donations[donation.OrderID] = *donation
+	return nil
+}
+
+func (r memoryDonations) RecordNotification(notification *models.PaymentNotification) (bool, error) {
+	key := notification.OrderID + "|" + notification.TransactionID + "|" + notification.TransactionStatus
+	if r.store.notifications[key] {
+		return false, nil
+	}
+	r.store.notifications[key] = true
+	return true, nil
+}
+
+// memoryReader dipakai handler di luar transaksi
+type memoryReader struct {
+	repositories.DonationRepository
+	store *memoryStore
+}
+
+func (r memoryReader) GetByOrderID(orderID string) (*models.Donation, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return memoryDonations{store: r.store}.find(orderID)
+}
+
+type memoryCampaigns struct {
+	repositories.CampaignRepository
+	store *memoryStore
+}
+
+func (r memoryCampaigns) GetForUpdate(id uint) (*models.Campaign, error) {
+	campaign := r.store.campaigns[int(id)]
+	return &campaign, nil
+}
+
+func (r memoryCampaigns) AdjustTotals(id uint, gross, net float64, donors int) error {
+	campaign := r.store.campaigns[int(id)]
+	campaign.TotalCollected += gross
+	campaign.NetCollected += net
+	campaign.DonorCount += donors
+	r.store.campaigns[int(id)] = campaign
+	return nil
+}
+
+type memoryAllocations struct {
+	repositories.AllocationRepository
+	store *memoryStore
+}
+
+func (r memoryAllocations) Create(lines []models.DonationAllocation) error {
+	r.store.allocations = append(r.store.allocations, lines...)
+	return nil
+}
+
+// ==================== Alur donasi lewat FakeGateway ====================
+
+// webhookRecorder meneruskan webhook ke aplikasi dan menyimpan salinan terakhirnya untuk diputar ulang
+type webhookRecorder struct {
+	next   http.Handler
+	mu     sync.Mutex
+	body   []byte
+	header http.Header
+	status int
+}
+
+func (w *webhookRecorder) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	w.next.ServeHTTP(rec, r)
+
+	w.mu.Lock()
+	w.body, w.header, w.status = body, r.Header.Clone(), rec.Code
+	w.mu.Unlock()
+
+	for k, v := range rec.Header() {
+		rw.Header()[k] = v
+	}
+	rw.WriteHeader(rec.Code)
+	rw.Write(rec.Body.Bytes())
+}
+
+func TestFakeGatewayPaymentCreditsCampaign(t *testing.T) {
+	store := newMemoryStore()
+	userID := 7
+	store.campaigns[1] = models.Campaign{ID: 1, Title: "Sumur Wakaf"}
+	store.donations["ORDER-1"] = models.Donation{
+		ID:              10,
+		Amount:          100000,
+		Status:          models.DonationStatusPending,
+		UserID:          &userID,
+		Channel:         models.DonationChannelOnline,
+		FundType:        models.FundInfaq,
+		OrderID:         "ORDER-1",
+		PaymentProvider: models.PaymentProviderFake,
+		CampaignID:      1,
+	}
+
+	e := echo.New()
+	e.HTTPErrorHandler = response.HTTPErrorHandler
+	recorder := &webhookRecorder{next: e}
+	app := httptest.NewServer(recorder)
+	defer app.Close()
+
+	gateway := payment.NewFakeGateway("http://gateway.test", app.URL+"/notifications/"+models.PaymentProviderFake, "", "test-webhook-secret")
+	registry := payment.NewRegistry()
+	registry.Register(gateway)
+
+	h := &Handler{
+		donationRepository: memoryReader{store: store},
+		paymentService:     services.NewPaymentService(registry),
+		donationService:    services.NewDonationService(store, services.NewAllocator(services.AllocationConfig{})),
+	}
+	e.POST("/notifications/:provider", h.HandlePaymentNotification)
+
+	// 1. Buat transaksi di gateway
+	charge, err := h.paymentService.CreateTransaction(context.Background(), store.donation("ORDER-1"))
+	if err != nil {
+		t.Fatalf("CreateTransaction: %v", err)
+	}
+
+	// 2. Donatur "membayar" di halaman gateway, yang mengirim webhook bertanda tangan ke aplikasi
+	payURL, err := url.Parse(charge.RedirectURL)
+	if err != nil {
+		t.Fatalf("parse redirect url: %v", err)
+	}
+	form := url.Values{"action": {"pay"}}
+	req := httptest.NewRequest(http.MethodPost, payURL.Path, bytes.NewBufferString(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	gateway.ServeHTTP(rec, req)
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("pay: status %d, want %d", rec.Code, http.StatusSeeOther)
+	}
+	if recorder.status != http.StatusOK {
+		t.Fatalf("webhook: status %d, want %d", recorder.status, http.StatusOK)
+	}
+
+	// 3. Donasi sukses dan total campaign bertambah tepat sekali
+	donation := store.donation("ORDER-1")
+	if donation.Status != models.DonationStatusSuccess || donation.CreditedAt == nil {
+		t.Fatalf("donation status %q credited_at %v, want success and credited", donation.Status, donation.CreditedAt)
+	}
+	if donation.PaymentMethod != "fake_transfer" {
+		t.Errorf("payment method %q, want fake_transfer", donation.PaymentMethod)
+	}
+	assertTotals := func(when string) {
+		t.Helper()
+		campaign := store.campaign(1)
+		if campaign.TotalCollected != 100000 || campaign.DonorCount != 1 {
+			t.Errorf("%s: total %.2f donors %d, want 100000 and 1", when, campaign.TotalCollected, campaign.DonorCount)
+		}
+		if campaign.NetCollected <= 0 || campaign.NetCollected > campaign.TotalCollected {
+			t.Errorf("%s: net %.2f outside (0, %.2f]", when, campaign.NetCollected, campaign.TotalCollected)
+		}
+	}
+	assertTotals("after webhook")
+	if len(store.allocations) == 0 {
+		t.Error("no allocation lines recorded")
+	}
+
+	// 4. Webhook yang sama diputar ulang tidak mengkredit dua kali
+	send := func(body []byte, header http.Header) int {
+		req := httptest.NewRequest(http.MethodPost, "/notifications/"+models.PaymentProviderFake, bytes.NewReader(body))
+		req.Header = header.Clone()
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := send(recorder.body, recorder.header); code != http.StatusOK {
+		t.Errorf("replayed webhook: status %d, want %d", code, http.StatusOK)
+	}
+	assertTotals("after replay")
+
+	// 5. Tanda tangan yang tidak cocok ditolak
+	forged := recorder.header.Clone()
+	forged.Set(payment.FakeSignatureHeader, strings.Repeat("0", 64))
+	if code := send(recorder.body, forged); code != http.StatusUnauthorized {
+		t.Errorf("forged signature: status %d, want %d", code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	dtoDonation "zakat/dto/donations"
+	"zakat/models"
+	"zakat/pkg/middleware"
+	"zakat/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ==================== Donation Plan Handlers ====================
+
+// CreateDonationPlan donatur membuat donasi rutin untuk campaign tertentu atau dana umum
+func (h *Handler) CreateDonationPlan(c echo.Context) error {
+	var req dtoDonation.DonationPlanCreateRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	userID, ok := c.Get("userLogin").(int)
+	if !ok {
+		return response.Fail(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	user, err := h.userRepository.GetByID(uint(userID))
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to get user information")
+	}
+	if user == nil {
+		return response.NewError(http.StatusNotFound, response.CodeUserNotFound, "User not found")
+	}
+	if emailVerificationBlocked(user, VerificationActionDonation) {
+		return response.NewError(http.StatusForbidden, response.CodeEmailNotVerified, "Silakan verifikasi email Anda sebelum berdonasi")
+	}
+
+	campaignID := h.planScheduler.GeneralFundCampaignID()
+	if req.CampaignID != nil {
+		campaignID = *req.CampaignID
+	}
+	if campaignID == 0 {
+		return response.Fail(http.StatusUnprocessableEntity, "General fund is not available, please choose a campaign")
+	}
+	campaign, err := h.campaignRepository.GetByID(uint(campaignID))
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to get campaign")
+	}
+	if campaign == nil {
+		return response.NewError(http.StatusNotFound, response.CodeCampaignNotFound, "Campaign not found")
+	}
+
+	startAt := time.Now()
+	if req.StartAt != nil {
+		if req.StartAt.Before(startAt.Add(-time.Minute)) {
+			return response.Fail(http.StatusBadRequest, "Start date cannot be in the past")
+		}
+		startAt = *req.StartAt
+	}
+	anchorDay := startAt.Day()
+	if anchorDay > 28 {
+		anchorDay = 28
+	}
+
+	plan := models.DonationPlan{
+		UserID:       userID,
+		CampaignID:   req.CampaignID,
+		Amount:       req.Amount,
+		Interval:     req.Interval,
+		AnchorDay:    anchorDay,
+		Status:       models.DonationPlanActive,
+		NextChargeAt: startAt,
+	}
+	if err := h.donationPlanRepository.Create(&plan); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to create donation plan").Wrap(err)
+	}
+
+	return response.Success(c, http.StatusCreated, plan)
+}
+
+// GetMyDonationPlans daftar donasi rutin milik user yang login
+func (h *Handler) GetMyDonationPlans(c echo.Context) error {
+	userID, ok := c.Get("userLogin").(int)
+	if !ok {
+		return response.Fail(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	plans, err := h.donationPlanRepository.ListByUser(userID)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to fetch donation plans").Wrap(err)
+	}
+	return response.Success(c, http.StatusOK, plans)
+}
+
+// GetAllDonationPlans daftar seluruh donasi rutin untuk admin
+func (h *Handler) GetAllDonationPlans(c echo.Context) error {
+	page, limit := pagination(c, 20, 100)
+
+	status := c.QueryParam("status")
+	switch status {
+	case "", models.DonationPlanActive, models.DonationPlanPaused, models.DonationPlanCancelled:
+	default:
+		return response.Fail(http.StatusBadRequest, "Invalid status filter")
+	}
+
+	plans, total, err := h.donationPlanRepository.List(status, limit, (page-1)*limit)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to fetch donation plans").Wrap(err)
+	}
+
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"plans": plans,
+		"page":  page,
+		"limit": limit,
+		"total": total,
+	})
+}
+
+// GetDonationPlan detail rencana beserta riwayat tagihan per periode
+func (h *Handler) GetDonationPlan(c echo.Context) error {
+	plan, err := h.ownDonationPlan(c, true)
+	if err != nil {
+		return err
+	}
+
+	charges, err := h.donationPlanRepository.Charges(plan.ID)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to fetch plan charges").Wrap(err)
+	}
+
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"plan":    plan,
+		"charges": charges,
+	})
+}
+
+// UpdateDonationPlan mengubah nominal/interval; berlaku mulai tagihan berikutnya
+func (h *Handler) UpdateDonationPlan(c echo.Context) error {
+	plan, err := h.ownDonationPlan(c, false)
+	if err != nil {
+		return err
+	}
+	if plan.Status == models.DonationPlanCancelled {
+		return response.NewError(http.StatusConflict, response.CodeConflict, "Donation plan has been cancelled")
+	}
+
+	var req dtoDonation.DonationPlanUpdateRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	if req.Amount > 0 {
+		plan.Amount = req.Amount
+	}
+	if req.Interval != "" {
+		plan.Interval = req.Interval
+	}
+	return h.saveDonationPlan(c, plan)
+}
+
+// PauseDonationPlan menghentikan sementara tagihan
+func (h *Handler) PauseDonationPlan(c echo.Context) error {
+	plan, err := h.ownDonationPlan(c, false)
+	if err != nil {
+		return err
+	}
+	if plan.Status != models.DonationPlanActive {
+		return response.NewError(http.StatusConflict, response.CodeConflict, "Only active donation plans can be paused")
+	}
+
+	now := time.Now()
+	plan.Status = models.DonationPlanPaused
+	plan.PausedAt = &now
+	return h.saveDonationPlan(c, plan)
+}
+
+// ResumeDonationPlan melanjutkan tagihan; periode yang terlewat selama jeda tidak ditagih
+func (h *Handler) ResumeDonationPlan(c echo.Context) error {
+	plan, err := h.ownDonationPlan(c, false)
+	if err != nil {
+		return err
+	}
+	if plan.Status != models.DonationPlanPaused {
+		return response.NewError(http.StatusConflict, response.CodeConflict, "Only paused donation plans can be resumed")
+	}
+
+	now := time.Now()
+	for plan.NextChargeAt.Before(now) {
+		plan.NextChargeAt = plan.NextAfter(plan.NextChargeAt)
+	}
+	plan.Status = models.DonationPlanActive
+	plan.PausedAt = nil
+	return h.saveDonationPlan(c, plan)
+}
+
+// CancelDonationPlan menghentikan donasi rutin secara permanen
+func (h *Handler) CancelDonationPlan(c echo.Context) error {
+	plan, err := h.ownDonationPlan(c, false)
+	if err != nil {
+		return err
+	}
+	if plan.Status == models.DonationPlanCancelled {
+		return response.NewError(http.StatusConflict, response.CodeConflict, "Donation plan has already been cancelled")
+	}
+
+	now := time.Now()
+	plan.Status = models.DonationPlanCancelled
+	plan.CancelledAt = &now
+	return h.saveDonationPlan(c, plan)
+}
+
+// ownDonationPlan rencana milik user yang login; allowAdmin mengizinkan user dengan donations:read_all
+func (h *Handler) ownDonationPlan(c echo.Context, allowAdmin bool) (*models.DonationPlan, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return nil, response.Fail(http.StatusBadRequest, "Invalid donation plan ID format")
+	}
+
+	plan, err := h.donationPlanRepository.GetByID(uint(id))
+	if err != nil {
+		return nil, response.Fail(http.StatusInternalServerError, "Failed to get donation plan").Wrap(err)
+	}
+
+	userID, _ := c.Get("userLogin").(int)
+	allowed := plan != nil && plan.UserID == userID
+	if plan != nil && !allowed && allowAdmin {
+		allowed = middleware.HasPermission(c, middleware.PermDonationReadAll)
+	}
+	// Rencana orang lain diperlakukan seperti tidak ada
+	if !allowed {
+		return nil, response.NewError(http.StatusNotFound, response.CodeNotFound, "Donation plan not found")
+	}
+	return plan, nil
+}
+
+func (h *Handler) saveDonationPlan(c echo.Context, plan *models.DonationPlan) error {
+	plan.UpdatedAt = time.Now()
+	if err := h.donationPlanRepository.Update(plan); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to update donation plan").Wrap(err)
+	}
+	return response.Success(c, http.StatusOK, plan)
+}
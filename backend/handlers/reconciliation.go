@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"zakat/models"
+	"zakat/pkg/response"
+	"zakat/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ==================== Reconciliation Handlers ====================
+
+// GetReconciliationRuns daftar run rekonsiliasi terbaru beserta ringkasannya
+func (h *Handler) GetReconciliationRuns(c echo.Context) error {
+	page, limit := pagination(c, 20, 100)
+
+	runs, total, err := h.reconciliationRepository.ListRuns(limit, (page-1)*limit)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to fetch reconciliation runs").Wrap(err)
+	}
+
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"runs":  runs,
+		"page":  page,
+		"limit": limit,
+		"total": total,
+	})
+}
+
+// GetReconciliationRun laporan satu run beserta hasil per donasi
+func (h *Handler) GetReconciliationRun(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid reconciliation run ID")
+	}
+
+	run, err := h.reconciliationRepository.GetRun(uint(id))
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to fetch reconciliation run").Wrap(err)
+	}
+	if run == nil {
+		return response.Fail(http.StatusNotFound, "Reconciliation run not found")
+	}
+
+	return response.Success(c, http.StatusOK, run)
+}
+
+// TriggerReconciliation menjalankan rekonsiliasi di luar jadwal; hasilnya dilihat lewat GetReconciliationRun
+func (h *Handler) TriggerReconciliation(c echo.Context) error {
+	run, err := h.reconciler.Trigger(c.Request().Context(), models.ReconciliationTriggerManual)
+	if err != nil {
+		if errors.Is(err, services.ErrReconcileRunning) {
+			return err
+		}
+		return response.Fail(http.StatusInternalServerError, "Failed to start reconciliation").Wrap(err)
+	}
+
+	return response.SuccessMessage(c, http.StatusAccepted, "Reconciliation started", run)
+}
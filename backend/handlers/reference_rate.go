@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	dtoZakat "zakat/dto/zakat"
+	"zakat/models"
+	"zakat/pkg/response"
+	"zakat/repositories"
+	"zakat/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ==================== Reference Rate Handlers ====================
+
+// maxRateFeedSize batas ukuran feed yang diunggah admin
+const maxRateFeedSize = 5 << 20
+
+// GetReferenceRates harga emas, perak, beras dan fitrah yang berlaku (?region=&date=YYYY-MM-DD)
+func (h *Handler) GetReferenceRates(c echo.Context) error {
+	on, err := queryDate(c, "date")
+	if err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid date, use YYYY-MM-DD")
+	}
+	region := services.NormalizeRegion(c.QueryParam("region"))
+
+	rates, err := h.referenceRates.Effective(c.Request().Context(), region, on)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to get reference rates").Wrap(err)
+	}
+
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"region": region,
+		"date":   on,
+		"rates":  rates,
+	})
+}
+
+// GetReferenceRateHistory riwayat harga (?kind=&region=&source=&from=&to=)
+func (h *Handler) GetReferenceRateHistory(c echo.Context) error {
+	page, limit := pagination(c, 50, 500)
+
+	filter := repositories.ReferenceRateFilter{
+		Kind:   c.QueryParam("kind"),
+		Region: services.NormalizeRegion(c.QueryParam("region")),
+		Source: c.QueryParam("source"),
+	}
+	if filter.Kind != "" && !models.IsValidRateKind(filter.Kind) {
+		return response.Fail(http.StatusBadRequest, "Invalid rate kind")
+	}
+	switch filter.Source {
+	case "", models.RateSourceImport, models.RateSourceOfficial:
+	default:
+		return response.Fail(http.StatusBadRequest, "Invalid source filter")
+	}
+	for key, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if c.QueryParam(key) == "" {
+			continue
+		}
+		t, err := queryDate(c, key)
+		if err != nil {
+			return response.Fail(http.StatusBadRequest, "Invalid date, use YYYY-MM-DD")
+		}
+		*target = &t
+	}
+
+	rates, total, err := h.referenceRateRepository.List(filter, limit, (page-1)*limit)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to fetch reference rates").Wrap(err)
+	}
+
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"rates": rates,
+		"page":  page,
+		"limit": limit,
+		"total": total,
+	})
+}
+
+// SetReferenceRate admin menetapkan tarif resmi; didahulukan dari hasil impor bertanggal sama atau lebih lama
+func (h *Handler) SetReferenceRate(c echo.Context) error {
+	var req dtoZakat.ReferenceRateCreateRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	userID, ok := c.Get("userLogin").(int)
+	if !ok {
+		return response.Fail(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	effective, err := time.ParseInLocation("2006-01-02", req.EffectiveFrom, time.Local)
+	if err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid effective_from, use YYYY-MM-DD")
+	}
+
+	rate := models.ReferenceRate{
+		Kind:          req.Kind,
+		Region:        services.NormalizeRegion(req.Region),
+		EffectiveFrom: effective,
+		Price:         req.Price,
+		Authority:     strings.TrimSpace(req.Authority),
+		Note:          strings.TrimSpace(req.Note),
+		CreatedByID:   &userID,
+	}
+	if err := h.referenceRateRepository.WithContext(c.Request().Context()).SetOfficial(&rate); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to save reference rate").Wrap(err)
+	}
+
+	return response.Success(c, http.StatusCreated, rate)
+}
+
+// DeleteReferenceRate menghapus satu baris riwayat (mis. salah input atau feed yang keliru)
+func (h *Handler) DeleteReferenceRate(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid reference rate ID format")
+	}
+
+	rate, err := h.referenceRateRepository.GetByID(uint(id))
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to get reference rate").Wrap(err)
+	}
+	if rate == nil {
+		return response.NewError(http.StatusNotFound, response.CodeNotFound, "Reference rate not found")
+	}
+
+	if err := h.referenceRateRepository.WithContext(c.Request().Context()).Delete(rate.ID); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to delete reference rate").Wrap(err)
+	}
+
+	return response.Success(c, http.StatusOK, "Reference rate deleted successfully")
+}
+
+// ImportReferenceRates mengimpor feed CSV/JSON yang diunggah (field "feed"),
+// atau feed lokal yang dikonfigurasi (REFERENCE_RATE_FEED) jika tidak ada file
+func (h *Handler) ImportReferenceRates(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	fileHeader, err := c.FormFile("feed")
+	if errors.Is(err, http.ErrMissingFile) || errors.Is(err, http.ErrNotMultipart) {
+		if h.rateImporter.FeedPath() == "" {
+			return response.Fail(http.StatusBadRequest, "Upload a feed file or configure REFERENCE_RATE_FEED")
+		}
+		result, err := h.rateImporter.ImportFile(ctx, h.rateImporter.FeedPath())
+		if err != nil {
+			return rateImportError(err)
+		}
+		return response.Success(c, http.StatusOK, result)
+	}
+	if err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid feed upload")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return response.Fail(http.StatusBadRequest, "Failed to read feed file")
+	}
+	defer file.Close()
+
+	result, err := h.rateImporter.Import(ctx, io.LimitReader(file, maxRateFeedSize), filepath.Ext(fileHeader.Filename))
+	if err != nil {
+		return rateImportError(err)
+	}
+
+	return response.Success(c, http.StatusOK, result)
+}
+
+func rateImportError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrRateImportRunning):
+		return response.NewError(http.StatusConflict, response.CodeConflict, "Reference rate import is already running")
+	case errors.Is(err, services.ErrUnsupportedFeed):
+		return response.Fail(http.StatusBadRequest, "Feed must be a .csv or .json file")
+	}
+	return response.Fail(http.StatusUnprocessableEntity, "Failed to import reference rates").Wrap(err)
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"zakat/models"
+	"zakat/pkg/middleware"
+	"zakat/pkg/response"
+	"zakat/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ==================== Refund Handlers ====================
+
+// CreateRefund mengembalikan sebagian/seluruh donasi lewat gateway; amount kosong berarti seluruh sisa
+func (h *Handler) CreateRefund(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid donation ID format")
+	}
+
+	var req struct {
+		Amount float64 `json:"amount" form:"amount" validate:"omitempty,gt=0"`
+		Reason string  `json:"reason" form:"reason" validate:"required,max=500"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	requestedBy, ok := c.Get("userLogin").(int)
+	if !ok {
+		return response.Fail(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	donation, err := h.donationRepository.GetByID(uint(id))
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to get donation")
+	}
+	if donation == nil {
+		return response.NewError(http.StatusNotFound, response.CodeDonationNotFound, "Donation not found")
+	}
+
+	refund, err := h.refundService.Refund(c.Request().Context(), uint(id), req.Amount, strings.TrimSpace(req.Reason), requestedBy)
+	if errors.Is(err, services.ErrRefundGateway) {
+		return response.NewError(http.StatusBadGateway, response.CodePaymentFailed, "Payment gateway rejected the refund").Wrap(err)
+	}
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusCreated
+	if refund.Status == models.RefundStatusPending {
+		// Gateway memproses refund secara asinkron; hasilnya datang lewat webhook
+		status = http.StatusAccepted
+	}
+	return response.Success(c, status, refund)
+}
+
+// GetDonationRefunds riwayat refund satu donasi: pemilik donasi atau user dengan donations:read_all
+func (h *Handler) GetDonationRefunds(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid donation ID format")
+	}
+
+	donation, err := h.donationRepository.GetByID(uint(id))
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to get donation")
+	}
+	if donation == nil {
+		return response.NewError(http.StatusNotFound, response.CodeDonationNotFound, "Donation not found")
+	}
+
+	ownerID := 0
+	if donation.UserID != nil {
+		ownerID = *donation.UserID
+	}
+	if !middleware.IsSelfOrHasPermission(c, ownerID, middleware.PermDonationReadAll) {
+		return response.Fail(http.StatusForbidden, "Access denied")
+	}
+
+	refunds, err := h.refundRepository.ListByDonation(donation.ID)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to fetch refunds").Wrap(err)
+	}
+
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"donation_id":     donation.ID,
+		"amount":          donation.Amount,
+		"refunded_amount": donation.RefundedAmount,
+		"refunds":         refunds,
+	})
+}
+
+// GetRefunds daftar refund untuk admin, bisa difilter status
+func (h *Handler) GetRefunds(c echo.Context) error {
+	page, limit := pagination(c, 20, 100)
+
+	status := c.QueryParam("status")
+	switch status {
+	case "", models.RefundStatusPending, models.RefundStatusSucceeded, models.RefundStatusFailed:
+	default:
+		return response.Fail(http.StatusBadRequest, "Invalid status filter")
+	}
+
+	refunds, total, err := h.refundRepository.List(status, limit, (page-1)*limit)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to fetch refunds").Wrap(err)
+	}
+
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"refunds": refunds,
+		"page":    page,
+		"limit":   limit,
+		"total":   total,
+	})
+}
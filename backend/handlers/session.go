@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+	dtoAuth "zakat/dto/auth"
+	"zakat/models"
+	"zakat/pkg/middleware"
+
+	jwtToken "zakat/pkg/jwt"
+	"zakat/pkg/response"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ==================== Session Handlers ====================
+
+// generateSecureToken membuat token acak dari crypto/rand
+func generateSecureToken(size int) (string, error) {
+	bytes := make([]byte, size)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// hashToken menyimpan token sebagai SHA-256 agar token asli tidak ada di database
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateAccessToken membuat JWT berumur pendek yang terikat ke sesi
+func generateAccessToken(user *models.User, session *models.Session) (string, error) {
+	now := time.Now()
+	claims := jwtToken.MapClaims{
+		"id":       user.ID,
+		"email":    user.Email,
+		"username": user.Username,
+		"is_admin": user.IsAdmin,
+		"role":     user.EffectiveRole(),
+		"sid":      session.ID,
+		"typ":      jwtToken.TokenTypeAccess,
+		"mfa":      session.MFAVerified,
+		"iat":      now.Unix(),
+		"exp":      now.Add(jwtToken.AccessTokenTTL()).Unix(),
+	}
+	return jwtToken.GenerateToken(claims)
+}
+
+// issueSession membuat sesi baru beserta access token dan refresh token.
+// mfaVerified true jika login diselesaikan dengan 2FA.
+func (h *Handler) issueSession(c echo.Context, user *models.User, mfaVerified bool) (*dtoAuth.TokenPair, error) {
+	refreshToken, err := generateSecureToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		ID:               uuid.NewString(),
+		UserID:           user.ID,
+		RefreshTokenHash: hashToken(refreshToken),
+		UserAgent:        c.Request().UserAgent(),
+		IPAddress:        c.RealIP(),
+		ExpiresAt:        now.Add(jwtToken.RefreshTokenTTL()),
+		LastUsedAt:       now,
+		MFAVerified:      mfaVerified,
+	}
+	if err := h.sessionRepository.Create(session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	accessToken, err := generateAccessToken(user, session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	return &dtoAuth.TokenPair{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(jwtToken.AccessTokenTTL().Seconds()),
+		SessionID:    session.ID,
+	}, nil
+}
+
+// respondWithLogin membuat sesi dan mengirim response login (dipakai SignIn, login OTP dan 2FA)
+func (h *Handler) respondWithLogin(c echo.Context, user *models.User, mfaVerified bool) error {
+	tokens, err := h.issueSession(c, user, mfaVerified)
+	if err != nil {
+		log.Printf("❌ Error issue session: %v", err)
+		return response.Fail(http.StatusInternalServerError, "Failed to generate authentication token")
+	}
+
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"token":         tokens.Token,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+		// Role wajib 2FA tapi belum mengaktifkan: arahkan ke /2fa/setup
+		"mfa_enrollment_required": !mfaVerified && middleware.RoleRequiresMFA(user.EffectiveRole()) && user.TOTPEnabledAt == nil,
+		"user": models.UserResponseJWT{
+			ID:            user.ID,
+			Name:          user.FirstName + " " + user.LastName,
+			Email:         user.Email,
+			Username:      user.Username,
+			Gender:        user.Gender,
+			Phone:         user.Phone,
+			Address:       user.Address,
+			Photo:         user.Photo,
+			Token:         tokens.Token,
+			IsAdmin:       user.IsAdmin,
+			Role:          user.EffectiveRole(),
+			EmailVerified: user.EmailVerifiedAt != nil,
+			PhoneVerified: user.PhoneVerifiedAt != nil,
+			TwoFactor:     user.TOTPEnabledAt != nil,
+		},
+	})
+}
+
+// revokeUserSessions mencabut semua sesi user, kecuali sesi yang sedang dipakai jika keepCurrent
+func (h *Handler) revokeUserSessions(c echo.Context, userID int, reason string, keepCurrent bool) {
+	var err error
+	currentSessionID, _ := c.Get("sessionID").(string)
+	if keepCurrent && currentSessionID != "" {
+		err = h.sessionRepository.RevokeAllForUserExcept(userID, currentSessionID, reason)
+	} else {
+		err = h.sessionRepository.RevokeAllForUser(userID, reason)
+	}
+	if err != nil {
+		fmt.Printf("Gagal mencabut sesi user %d: %v\n", userID, err)
+	}
+}
+
+func (h *Handler) RefreshToken(c echo.Context) error {
+	var req dtoAuth.RefreshTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Refresh token is required")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	hash := hashToken(req.RefreshToken)
+
+	session, err := h.sessionRepository.GetByRefreshTokenHash(hash)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to get session")
+	}
+
+	if session == nil {
+		// Refresh token lama dipakai ulang: kemungkinan token dicuri, cabut sesinya
+		if reused, _ := h.sessionRepository.GetByPreviousTokenHash(hash); reused != nil {
+			_ = h.sessionRepository.Revoke(reused.ID, "refresh_token_reuse")
+		}
+		return response.NewError(http.StatusUnauthorized, response.CodeInvalidToken, "Invalid refresh token")
+	}
+
+	if !session.IsActive() {
+		return response.NewError(http.StatusUnauthorized, response.CodeSessionRevoked, "Session has been revoked or expired")
+	}
+
+	user, err := h.userRepository.GetByID(uint(session.UserID))
+	if err != nil || user == nil {
+		_ = h.sessionRepository.Revoke(session.ID, "user_not_found")
+		return response.NewError(http.StatusUnauthorized, response.CodeInvalidToken, "Invalid refresh token")
+	}
+
+	newRefreshToken, err := generateSecureToken(32)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to generate refresh token")
+	}
+
+	expiresAt := time.Now().Add(jwtToken.RefreshTokenTTL())
+	if err := h.sessionRepository.Rotate(session, hashToken(newRefreshToken), expiresAt); err != nil {
+		return response.NewError(http.StatusUnauthorized, response.CodeInvalidToken, "Invalid refresh token")
+	}
+
+	accessToken, err := generateAccessToken(user, session)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to generate authentication token")
+	}
+
+	return response.Success(c, http.StatusOK, dtoAuth.TokenPair{
+		Token:        accessToken,
+		RefreshToken: newRefreshToken,
+		ExpiresIn:    int64(jwtToken.AccessTokenTTL().Seconds()),
+		SessionID:    session.ID,
+	})
+}
+
+func (h *Handler) Logout(c echo.Context) error {
+	sessionID, _ := c.Get("sessionID").(string)
+	if err := h.sessionRepository.Revoke(sessionID, "logout"); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to logout")
+	}
+
+	return response.Success(c, http.StatusOK, "Logged out successfully")
+}
+
+func (h *Handler) LogoutAll(c echo.Context) error {
+	userID := c.Get("userLogin").(int)
+	if err := h.sessionRepository.RevokeAllForUser(userID, "logout_all"); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to logout from all devices")
+	}
+
+	return response.Success(c, http.StatusOK, "Logged out from all devices")
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"zakat/pkg/response"
+	"zakat/pkg/throttle"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ==================== Throttling ====================
+
+// Scope throttling yang dipakai handler
+const (
+	ThrottleLoginAccount     = "login_account"
+	ThrottleLoginIP          = "login_ip"
+	ThrottlePasswordRecovery = "password_recovery"
+	ThrottleRecoveryIP       = "password_recovery_ip"
+	ThrottleResetToken       = "reset_token"
+	ThrottleOTPRequest       = "otp_request"
+	ThrottleOTPResend        = "otp_resend"
+	ThrottleOTPVerify        = "otp_verify"
+	ThrottleOTPIP            = "otp_ip"
+	ThrottleMFAVerify        = "mfa_verify"
+	ThrottleGuestDonationIP  = "guest_donation_ip"
+)
+
+// ThrottlePolicies adalah kebijakan default untuk setiap scope
+var ThrottlePolicies = map[string]throttle.Policy{
+	// Login per akun: 3 kali bebas, lalu jeda 2s, 4s, 8s..., lockout 15 menit setelah 10 kali gagal
+	ThrottleLoginAccount: {
+		Window:       15 * time.Minute,
+		FreeAttempts: 3,
+		BaseDelay:    2 * time.Second,
+		MaxDelay:     time.Minute,
+		MaxAttempts:  10,
+		Lockout:      15 * time.Minute,
+	},
+	// Login per IP: lebih longgar karena satu IP bisa dipakai banyak orang (NAT, wifi masjid)
+	ThrottleLoginIP: {
+		Window:       15 * time.Minute,
+		FreeAttempts: 10,
+		BaseDelay:    time.Second,
+		MaxDelay:     30 * time.Second,
+		MaxAttempts:  50,
+		Lockout:      30 * time.Minute,
+	},
+	// Lupa password per email/nomor WhatsApp: setiap permintaan dihitung
+	ThrottlePasswordRecovery: {
+		Window:       time.Hour,
+		FreeAttempts: 2,
+		BaseDelay:    time.Minute,
+		MaxDelay:     10 * time.Minute,
+		MaxAttempts:  5,
+		Lockout:      time.Hour,
+	},
+	ThrottleRecoveryIP: {
+		Window:       time.Hour,
+		FreeAttempts: 10,
+		BaseDelay:    10 * time.Second,
+		MaxDelay:     5 * time.Minute,
+		MaxAttempts:  30,
+		Lockout:      time.Hour,
+	},
+	// Token reset salah per IP (mencegah menebak token)
+	ThrottleResetToken: {
+		Window:       time.Hour,
+		FreeAttempts: 5,
+		BaseDelay:    5 * time.Second,
+		MaxDelay:     time.Minute,
+		MaxAttempts:  20,
+		Lockout:      time.Hour,
+	},
+	// Permintaan OTP per nomor: setiap permintaan dihitung (biaya kirim WhatsApp/SMS)
+	ThrottleOTPRequest: {
+		Window:       time.Hour,
+		FreeAttempts: 3,
+		BaseDelay:    time.Minute,
+		MaxDelay:     10 * time.Minute,
+		MaxAttempts:  6,
+		Lockout:      time.Hour,
+	},
+	// Jeda kirim ulang OTP login per nomor, berlaku sama untuk nomor terdaftar maupun tidak
+	// agar 429 tidak membocorkan nomor mana yang terdaftar
+	ThrottleOTPResend: {
+		Window:    otpResendCooldown,
+		BaseDelay: otpResendCooldown,
+		MaxDelay:  otpResendCooldown,
+	},
+	// Kode OTP salah per nomor, di luar batas percobaan per kode
+	ThrottleOTPVerify: {
+		Window:       time.Hour,
+		FreeAttempts: 5,
+		BaseDelay:    5 * time.Second,
+		MaxDelay:     time.Minute,
+		MaxAttempts:  10,
+		Lockout:      time.Hour,
+	},
+	// Kode 2FA salah per akun
+	ThrottleMFAVerify: {
+		Window:       15 * time.Minute,
+		FreeAttempts: 3,
+		BaseDelay:    2 * time.Second,
+		MaxDelay:     time.Minute,
+		MaxAttempts:  10,
+		Lockout:      30 * time.Minute,
+	},
+	// Checkout tamu per IP: setiap permintaan dihitung karena tiap checkout membuat transaksi di gateway
+	ThrottleGuestDonationIP: {
+		Window:       time.Hour,
+		FreeAttempts: 10,
+		BaseDelay:    10 * time.Second,
+		MaxDelay:     5 * time.Minute,
+		MaxAttempts:  30,
+		Lockout:      time.Hour,
+	},
+	ThrottleOTPIP: {
+		Window:       time.Hour,
+		FreeAttempts: 20,
+		BaseDelay:    5 * time.Second,
+		MaxDelay:     5 * time.Minute,
+		MaxAttempts:  60,
+		Lockout:      time.Hour,
+	},
+}
+
+// throttleTarget adalah pasangan scope dan key yang dicek bersamaan
+type throttleTarget struct {
+	scope string
+	key   string
+}
+
+// throttleKey menormalkan email/username/nomor agar variasi huruf besar tidak lolos
+func throttleKey(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// throttleCheck mengecek beberapa scope sekaligus dan mengembalikan waktu tunggu terlama
+func (h *Handler) throttleCheck(targets ...throttleTarget) time.Duration {
+	var longest time.Duration
+	for _, t := range targets {
+		wait, err := h.limiter.Check(t.scope, t.key)
+		if err != nil {
+			fmt.Printf("Throttle check error (%s): %v\n", t.scope, err)
+			continue
+		}
+		if wait > longest {
+			longest = wait
+		}
+	}
+	return longest
+}
+
+// throttleFail mencatat kegagalan di beberapa scope sekaligus
+func (h *Handler) throttleFail(targets ...throttleTarget) time.Duration {
+	var longest time.Duration
+	for _, t := range targets {
+		wait, err := h.limiter.Fail(t.scope, t.key)
+		if err != nil {
+			fmt.Printf("Throttle fail error (%s): %v\n", t.scope, err)
+			continue
+		}
+		if wait > longest {
+			longest = wait
+		}
+	}
+	return longest
+}
+
+func (h *Handler) throttleReset(scope, key string) {
+	if err := h.limiter.Reset(scope, key); err != nil {
+		fmt.Printf("Throttle reset error (%s): %v\n", scope, err)
+	}
+}
+
+// tooManyRequests mengirim 429 dengan header Retry-After (detik)
+func tooManyRequests(c echo.Context, wait time.Duration) error {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Response().Header().Set("Retry-After", strconv.Itoa(seconds))
+	return response.Fail(http.StatusTooManyRequests, fmt.Sprintf("Terlalu banyak percobaan. Silakan coba lagi dalam %d detik", seconds)).
+		WithDetails(map[string]int{"retry_after": seconds})
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	dtoDonation "zakat/dto/donations"
+	"zakat/models"
+	"zakat/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ==================== Manual Transfer Handlers ====================
+
+// Kode unik 3 digit yang ditambahkan ke nominal agar mutasi rekening bisa dicocokkan
+const (
+	uniqueCodeMin      = 1
+	uniqueCodeMax      = 999
+	uniqueCodeAttempts = 20
+)
+
+// createManualTransferDonation membuat donasi pending dengan nominal + kode unik,
+// tanpa memanggil payment gateway
+func (h *Handler) createManualTransferDonation(c echo.Context, req dtoDonation.DonationCreateRequest, user *models.User, campaign *models.Campaign) error {
+	if strings.TrimSpace(campaign.CPocket) == "" {
+		return response.Fail(http.StatusUnprocessableEntity, "Campaign does not accept manual transfers")
+	}
+
+	// Nominal + kode unik harus unik di antara transfer pending ke rekening yang sama. Pengecekan awal
+	// hanya mempercepat; yang menjamin adalah unique index, jadi bentrok saat insert dicoba dengan kode lain.
+	account := strings.TrimSpace(campaign.CPocket)
+	ctx := c.Request().Context()
+	var donation models.Donation
+	created := false
+	for i := 0; i < uniqueCodeAttempts && !created; i++ {
+		code := uniqueCodeMin + rand.Intn(uniqueCodeMax-uniqueCodeMin+1)
+		amount := req.Amount + float64(code)
+		inUse, err := h.donationRepository.ManualAmountInUse(account, amount)
+		if err != nil {
+			return response.Fail(http.StatusInternalServerError, "Failed to allocate unique code").Wrap(err)
+		}
+		if inUse {
+			continue
+		}
+
+		now := time.Now()
+		donation = models.Donation{
+			Amount:          amount,
+			Date:            now,
+			Status:          models.DonationStatusPending,
+			UserID:          &req.UserID,
+			CampaignID:      req.CampaignID,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+			OrderID:         fmt.Sprintf("TRANSFER-%d-%d", req.UserID, now.UnixNano()),
+			Channel:         models.DonationChannelManualTransfer,
+			PaymentMethod:   models.PaymentMethodBankTransfer,
+			PaymentProvider: models.PaymentProviderManual,
+			UniqueCode:      code,
+			TransferAccount: account,
+			FundType:        campaign.FundType,
+			IsAnonymous:     req.IsAnonymous,
+		}
+		created, err = h.donationRepository.WithContext(ctx).CreateManualTransfer(&donation)
+		if err != nil {
+			return response.Fail(http.StatusInternalServerError, "Failed to create donation").Wrap(err)
+		}
+	}
+	if !created {
+		return response.NewError(http.StatusConflict, response.CodeConflict, "No unique code available, please try again later")
+	}
+
+	donation.User = user
+	donation.Campaign = *campaign
+
+	return response.Success(c, http.StatusCreated, map[string]interface{}{
+		"donation": donation,
+		"transfer": map[string]interface{}{
+			"account":     campaign.CPocket,
+			"amount":      donation.Amount,
+			"unique_code": donation.UniqueCode,
+		},
+	})
+}
+
+// UploadTransferProof donatur mengunggah bukti transfer untuk donasi manual miliknya
+func (h *Handler) UploadTransferProof(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid donation ID format")
+	}
+
+	userID, ok := c.Get("userLogin").(int)
+	if !ok {
+		return response.Fail(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	donation, err := h.donationRepository.GetByID(uint(id))
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to get donation")
+	}
+	// Donasi orang lain diperlakukan seperti tidak ada
+	if donation == nil || donation.UserID == nil || *donation.UserID != userID {
+		return response.NewError(http.StatusNotFound, response.CodeDonationNotFound, "Donation not found")
+	}
+
+	if donation.PaymentProvider != models.PaymentProviderManual {
+		return response.Fail(http.StatusUnprocessableEntity, "Donation is not a manual transfer")
+	}
+	if !models.CanTransitionDonation(donation.Status, models.DonationStatusSuccess) {
+		return response.NewError(http.StatusConflict, response.CodeInvalidStatusTransition, "Donation is no longer awaiting payment")
+	}
+
+	fileURL, ok := c.Get("dataFile").(string)
+	if !ok || fileURL == "" {
+		return response.Fail(http.StatusBadRequest, "No proof file provided")
+	}
+
+	proof := models.TransferProof{
+		DonationID:   donation.ID,
+		UploadedByID: userID,
+		FileURL:      fileURL,
+		SenderBank:   strings.TrimSpace(c.FormValue("sender_bank")),
+		SenderName:   strings.TrimSpace(c.FormValue("sender_name")),
+		Note:         strings.TrimSpace(c.FormValue("note")),
+		Status:       models.TransferProofSubmitted,
+	}
+	if err := h.transferProofRepository.Create(&proof); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to save transfer proof").Wrap(err)
+	}
+
+	return response.Success(c, http.StatusCreated, proof)
+}
+
+// GetTransferProofs antrian verifikasi bukti transfer, default yang masih submitted
+func (h *Handler) GetTransferProofs(c echo.Context) error {
+	page, limit := pagination(c, 20, 100)
+
+	status := c.QueryParam("status")
+	switch status {
+	case "":
+		status = models.TransferProofSubmitted
+	case "all":
+		status = ""
+	case models.TransferProofSubmitted, models.TransferProofApproved, models.TransferProofRejected:
+	default:
+		return response.Fail(http.StatusBadRequest, "Invalid status filter")
+	}
+
+	proofs, total, err := h.transferProofRepository.List(status, limit, (page-1)*limit)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to fetch transfer proofs").Wrap(err)
+	}
+
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"proofs": proofs,
+		"page":   page,
+		"limit":  limit,
+		"total":  total,
+	})
+}
+
+// ApproveTransferProof menyetujui bukti transfer dan mengkredit donasinya
+func (h *Handler) ApproveTransferProof(c echo.Context) error {
+	return h.reviewTransferProof(c, true, "")
+}
+
+// RejectTransferProof menolak bukti transfer; donasi tetap pending sampai bukti baru diunggah
+func (h *Handler) RejectTransferProof(c echo.Context) error {
+	var req struct {
+		Reason string `json:"reason" form:"reason" validate:"required,max=500"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	return h.reviewTransferProof(c, false, strings.TrimSpace(req.Reason))
+}
+
+func (h *Handler) reviewTransferProof(c echo.Context, approve bool, reason string) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid transfer proof ID format")
+	}
+
+	reviewerID, ok := c.Get("userLogin").(int)
+	if !ok {
+		return response.Fail(http.StatusUnauthorized, "Unauthorized")
+	}
+
+	proof, err := h.transferProofRepository.GetByID(uint(id))
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to get transfer proof").Wrap(err)
+	}
+	if proof == nil {
+		return response.NewError(http.StatusNotFound, response.CodeNotFound, "Transfer proof not found")
+	}
+
+	// ErrProofAlreadyReviewed / ErrInvalidStatusTransition dipetakan di errors.go
+	if err := h.donationService.ReviewTransferProof(c.Request().Context(), uint(id), reviewerID, approve, reason); err != nil {
+		return err
+	}
+
+	proof, err = h.transferProofRepository.GetByID(uint(id))
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to get transfer proof").Wrap(err)
+	}
+
+	return response.Success(c, http.StatusOK, proof)
+}
@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	dtoAuth "zakat/dto/auth"
+	"zakat/models"
+	"zakat/pkg/bcrypt"
+	"zakat/pkg/secretbox"
+	"zakat/pkg/totp"
+
+	jwtToken "zakat/pkg/jwt"
+	"zakat/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ==================== Two-Factor (TOTP) Handlers ====================
+
+const (
+	totpIssuer        = "AmalSAS"
+	recoveryCodeCount = 10
+)
+
+// completeLogin dipanggil setelah langkah pertama login berhasil (password / OTP).
+// Jika 2FA aktif, yang dikembalikan adalah challenge token, bukan sesi.
+func (h *Handler) completeLogin(c echo.Context, user *models.User) error {
+	if user.TOTPEnabledAt == nil {
+		return h.respondWithLogin(c, user, false)
+	}
+
+	challenge, err := jwtToken.GenerateToken(jwtToken.MapClaims{
+		"id":  user.ID,
+		"typ": jwtToken.TokenTypeMFAChallenge,
+		"exp": time.Now().Add(jwtToken.MFAChallengeTTL).Unix(),
+	})
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to generate authentication token")
+	}
+
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"mfa_required":    true,
+		"challenge_token": challenge,
+		"expires_in":      int(jwtToken.MFAChallengeTTL.Seconds()),
+	})
+}
+
+// validateTOTP mengecek kode dan menolak kode yang sudah pernah dipakai
+func (h *Handler) validateTOTP(c echo.Context, user *models.User, code string) bool {
+	secret, err := secretbox.Open(user.TOTPSecret)
+	if err != nil {
+		fmt.Printf("Gagal membuka secret TOTP user %d: %v\n", user.ID, err)
+		return false
+	}
+
+	counter, ok := totp.Validate(secret, strings.TrimSpace(code), time.Now())
+	if !ok || counter <= user.TOTPLastCounter {
+		return false
+	}
+
+	user.TOTPLastCounter = counter
+	if err := h.userRepository.WithContext(c.Request().Context()).Update(user); err != nil {
+		fmt.Printf("Gagal menyimpan counter TOTP user %d: %v\n", user.ID, err)
+		return false
+	}
+	return true
+}
+
+// normalizeRecoveryCode agar "ABCD-1234", "abcd1234" dan "abcd 1234" dianggap sama
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	return code
+}
+
+// generateRecoveryCodes membuat kode cadangan baru, menyimpan hash-nya, dan mengembalikan kode asli
+func (h *Handler) generateRecoveryCodes(userID int) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw, err := generateSecureToken(5)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashToken(normalizeRecoveryCode(raw))
+	}
+
+	if err := h.recoveryCodeRepository.ReplaceForUser(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (h *Handler) SetupTwoFactor(c echo.Context) error {
+	userID := c.Get("userLogin").(int)
+
+	var req dtoAuth.TwoFactorSetupRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	user, err := h.userRepository.GetByID(uint(userID))
+	if err != nil || user == nil {
+		return response.NewError(http.StatusNotFound, response.CodeUserNotFound, "User not found")
+	}
+
+	if user.TOTPEnabledAt != nil {
+		return response.Fail(http.StatusConflict, "Two-factor authentication is already enabled")
+	}
+
+	// Sama seperti DisableTwoFactor: percobaan password salah ikut dibatasi
+	target := throttleTarget{ThrottleMFAVerify, strconv.Itoa(user.ID)}
+	if wait := h.throttleCheck(target); wait > 0 {
+		return tooManyRequests(c, wait)
+	}
+	if !bcrypt.CheckPasswordHash(req.Password, user.Password) {
+		h.throttleFail(target)
+		return response.Fail(http.StatusBadRequest, "Invalid password")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to generate secret")
+	}
+
+	sealed, err := secretbox.Seal(secret)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to store secret")
+	}
+
+	// Secret disimpan tapi 2FA belum aktif sampai user memasukkan kode pertama
+	user.TOTPSecret = sealed
+	user.TOTPLastCounter = 0
+	user.UpdatedAt = time.Now()
+	if err := h.userRepository.WithContext(c.Request().Context()).Update(user); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to store secret")
+	}
+
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"secret":           secret,
+		"provisioning_uri": totp.ProvisioningURI(secret, totpIssuer, user.Email),
+		"digits":           totp.Digits,
+		"period":           int(totp.Period.Seconds()),
+	})
+}
+
+func (h *Handler) EnableTwoFactor(c echo.Context) error {
+	userID := c.Get("userLogin").(int)
+
+	var req dtoAuth.TwoFactorCodeRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	user, err := h.userRepository.GetByID(uint(userID))
+	if err != nil || user == nil {
+		return response.NewError(http.StatusNotFound, response.CodeUserNotFound, "User not found")
+	}
+
+	if user.TOTPEnabledAt != nil {
+		return response.Fail(http.StatusConflict, "Two-factor authentication is already enabled")
+	}
+	if user.TOTPSecret == "" {
+		return response.Fail(http.StatusBadRequest, "Call /2fa/setup first")
+	}
+
+	target := throttleTarget{ThrottleMFAVerify, strconv.Itoa(user.ID)}
+	if wait := h.throttleCheck(target); wait > 0 {
+		return tooManyRequests(c, wait)
+	}
+
+	if !h.validateTOTP(c, user, req.Code) {
+		h.throttleFail(target)
+		return response.NewError(http.StatusBadRequest, response.CodeInvalidMFACode, "Invalid two-factor code")
+	}
+	h.throttleReset(target.scope, target.key)
+
+	now := time.Now()
+	user.TOTPEnabledAt = &now
+	user.UpdatedAt = now
+	if err := h.userRepository.WithContext(c.Request().Context()).Update(user); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to enable two-factor authentication")
+	}
+
+	codes, err := h.generateRecoveryCodes(user.ID)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to generate recovery codes")
+	}
+
+	// Sesi saat ini sudah membuktikan faktor kedua; sesi lain harus login ulang dengan 2FA
+	sessionID, _ := c.Get("sessionID").(string)
+	if err := h.sessionRepository.MarkMFAVerified(sessionID); err != nil {
+		fmt.Printf("Gagal menandai sesi MFA: %v\n", err)
+	}
+	h.revokeUserSessions(c, user.ID, "two_factor_enabled", true)
+
+	data := map[string]interface{}{
+		"enabled":        true,
+		"recovery_codes": codes,
+	}
+	if session, err := h.sessionRepository.GetByID(sessionID); err == nil && session != nil {
+		if token, err := generateAccessToken(user, session); err == nil {
+			data["token"] = token
+		}
+	}
+
+	return response.Success(c, http.StatusOK, data)
+}
+
+func (h *Handler) VerifyTwoFactor(c echo.Context) error {
+	var req dtoAuth.TwoFactorVerifyRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	claims, err := jwtToken.DecodeToken(req.ChallengeToken)
+	if err != nil || claims["typ"] != jwtToken.TokenTypeMFAChallenge {
+		return response.NewError(http.StatusUnauthorized, response.CodeInvalidToken, "Invalid or expired challenge token")
+	}
+	id, ok := claims["id"].(float64)
+	if !ok {
+		return response.NewError(http.StatusUnauthorized, response.CodeInvalidToken, "Invalid or expired challenge token")
+	}
+
+	user, err := h.userRepository.GetByID(uint(id))
+	if err != nil || user == nil || user.TOTPEnabledAt == nil {
+		return response.NewError(http.StatusUnauthorized, response.CodeInvalidToken, "Invalid or expired challenge token")
+	}
+
+	target := throttleTarget{ThrottleMFAVerify, strconv.Itoa(user.ID)}
+	if wait := h.throttleCheck(target); wait > 0 {
+		return tooManyRequests(c, wait)
+	}
+
+	valid := false
+	switch {
+	case req.Code != "":
+		valid = h.validateTOTP(c, user, req.Code)
+	case req.RecoveryCode != "":
+		valid = h.recoveryCodeRepository.Consume(user.ID, hashToken(normalizeRecoveryCode(req.RecoveryCode))) == nil
+	}
+
+	if !valid {
+		h.throttleFail(target)
+		return response.NewError(http.StatusUnauthorized, response.CodeInvalidMFACode, "Invalid two-factor code")
+	}
+	h.throttleReset(target.scope, target.key)
+
+	return h.respondWithLogin(c, user, true)
+}
+
+func (h *Handler) RegenerateRecoveryCodes(c echo.Context) error {
+	userID := c.Get("userLogin").(int)
+
+	var req dtoAuth.TwoFactorCodeRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	user, err := h.userRepository.GetByID(uint(userID))
+	if err != nil || user == nil || user.TOTPEnabledAt == nil {
+		return response.Fail(http.StatusBadRequest, "Two-factor authentication is not enabled")
+	}
+
+	target := throttleTarget{ThrottleMFAVerify, strconv.Itoa(user.ID)}
+	if wait := h.throttleCheck(target); wait > 0 {
+		return tooManyRequests(c, wait)
+	}
+	if !h.validateTOTP(c, user, req.Code) {
+		h.throttleFail(target)
+		return response.NewError(http.StatusBadRequest, response.CodeInvalidMFACode, "Invalid two-factor code")
+	}
+
+	codes, err := h.generateRecoveryCodes(user.ID)
+	if err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to generate recovery codes")
+	}
+
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"recovery_codes": codes,
+	})
+}
+
+func (h *Handler) DisableTwoFactor(c echo.Context) error {
+	userID := c.Get("userLogin").(int)
+
+	var req dtoAuth.TwoFactorDisableRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	user, err := h.userRepository.GetByID(uint(userID))
+	if err != nil || user == nil || user.TOTPEnabledAt == nil {
+		return response.Fail(http.StatusBadRequest, "Two-factor authentication is not enabled")
+	}
+
+	target := throttleTarget{ThrottleMFAVerify, strconv.Itoa(user.ID)}
+	if wait := h.throttleCheck(target); wait > 0 {
+		return tooManyRequests(c, wait)
+	}
+	if !bcrypt.CheckPasswordHash(req.Password, user.Password) || !h.validateTOTP(c, user, req.Code) {
+		h.throttleFail(target)
+		return response.NewError(http.StatusBadRequest, response.CodeInvalidMFACode, "Invalid password or two-factor code")
+	}
+
+	user.TOTPSecret = ""
+	user.TOTPEnabledAt = nil
+	user.TOTPLastCounter = 0
+	user.UpdatedAt = time.Now()
+	if err := h.userRepository.WithContext(c.Request().Context()).Update(user); err != nil {
+		return response.Fail(http.StatusInternalServerError, "Failed to disable two-factor authentication")
+	}
+
+	if err := h.recoveryCodeRepository.DeleteForUser(user.ID); err != nil {
+		fmt.Printf("Gagal menghapus recovery code user %d: %v\n", user.ID, err)
+	}
+	// Perlindungan akun berkurang: sesi lain harus login ulang, hanya sesi ini yang dipertahankan
+	h.revokeUserSessions(c, user.ID, "two_factor_disabled", true)
+
+	return response.Success(c, http.StatusOK, "Two-factor authentication disabled")
+}
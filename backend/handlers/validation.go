@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"net/http"
+	"zakat/pkg/response"
+	"zakat/pkg/validator"
+
+	"github.com/labstack/echo/v4"
+)
+
+// validationError meneruskan hasil c.Validate ke HTTPErrorHandler (422 dengan daftar kesalahan per field)
+func validationError(c echo.Context, err error) error {
+	if _, ok := err.(validator.Errors); ok {
+		return err
+	}
+	return response.Fail(http.StatusBadRequest, "Invalid request body")
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+	dtoZakat "zakat/dto/zakat"
+	"zakat/pkg/response"
+	"zakat/pkg/zakat"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ==================== Zakat Calculator Handlers ====================
+
+// CalculateZakat menghitung zakat dan mengembalikan rincian beserta usulan donasi
+// yang bisa langsung dikirim ke POST /donations
+func (h *Handler) CalculateZakat(c echo.Context) error {
+	var req dtoZakat.CalculateRequest
+	if err := c.Bind(&req); err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	calc := zakat.Request{
+		Type:       req.Type,
+		Madhhab:    req.Madhhab,
+		NisabBasis: req.NisabBasis,
+		HaulStart:  req.HaulStart,
+	}
+	if req.Date != nil {
+		calc.On = *req.Date
+	}
+	switch req.Type {
+	case zakat.TypeMaal:
+		in := zakat.MaalInput(*req.Maal)
+		calc.Maal = &in
+	case zakat.TypeIncome:
+		in := zakat.IncomeInput(*req.Income)
+		calc.Income = &in
+	case zakat.TypeTrade:
+		in := zakat.TradeInput(*req.Trade)
+		calc.Trade = &in
+	case zakat.TypeFitrah:
+		in := zakat.FitrahInput(*req.Fitrah)
+		calc.Fitrah = &in
+	}
+
+	result, err := h.zakatService.Calculate(c.Request().Context(), strings.TrimSpace(req.Region), calc)
+	if err != nil {
+		return err
+	}
+
+	donation := map[string]interface{}{
+		"amount": result.Zakat,
+	}
+	if campaignID := h.zakatService.CampaignID(); campaignID != 0 {
+		donation["campaign_id"] = campaignID
+	}
+
+	return response.Success(c, http.StatusOK, map[string]interface{}{
+		"result":   result,
+		"donation": donation,
+	})
+}
+
+// GetZakatNisab ambang nisab dan nominal fitrah yang berlaku (?region=&date=YYYY-MM-DD)
+func (h *Handler) GetZakatNisab(c echo.Context) error {
+	on, err := queryDate(c, "date")
+	if err != nil {
+		return response.Fail(http.StatusBadRequest, "Invalid date, use YYYY-MM-DD")
+	}
+
+	info, err := h.zakatService.Nisab(c.Request().Context(), strings.TrimSpace(c.QueryParam("region")), on)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, http.StatusOK, info)
+}
+
+// queryDate membaca tanggal YYYY-MM-DD dari query string; kosong berarti sekarang
+func queryDate(c echo.Context, key string) (time.Time, error) {
+	value := c.QueryParam(key)
+	if value == "" {
+		return time.Now(), nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
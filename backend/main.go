@@ -11,6 +11,8 @@ import (
 	"zakat/database"
 	"zakat/pkg/midtrans"
 	"zakat/pkg/postgres"
+	"zakat/pkg/response"
+	"zakat/pkg/validator"
 	"zakat/routes"
 
 	"github.com/joho/godotenv"
@@ -39,7 +41,18 @@ func main() {
 	// Create Echo instance
 	e := echo.New()
 
+	// Ambil IP client dari X-Forwarded-For yang ditambahkan proxy (Railway),
+	// bukan dari nilai yang bisa dipalsukan client. Dipakai untuk throttling per IP.
+	e.IPExtractor = echo.ExtractIPFromXFFHeader()
+
+	// Validasi tag `validate` pada DTO lewat c.Validate
+	e.Validator = validator.New()
+
+	// Semua error dari handler/middleware diubah menjadi response JSON di satu tempat
+	e.HTTPErrorHandler = response.HTTPErrorHandler
+
 	// Middleware
+	e.Use(middleware.RequestID())
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 
@@ -84,7 +97,8 @@ func main() {
 			// Set CORS headers
 			c.Response().Header().Set("Access-Control-Allow-Origin", allowOrigin)
 			c.Response().Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-			c.Response().Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, Accept, Origin, X-CSRF-Token")
+			c.Response().Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, Accept, Origin, X-CSRF-Token, X-Request-ID")
+			c.Response().Header().Set("Access-Control-Expose-Headers", "X-Request-ID, Retry-After")
 			c.Response().Header().Set("Access-Control-Allow-Credentials", "true")
 			c.Response().Header().Set("Access-Control-Max-Age", "3600") // 1 hour
 
@@ -123,9 +137,10 @@ func main() {
 	e.GET("/api/health/db", func(c echo.Context) error {
 		err := postgres.DB.Raw("SELECT 1").Error
 		if err != nil {
+			log.Printf("❌ DB health check failed: %v", err)
 			return c.JSON(http.StatusServiceUnavailable, map[string]string{
 				"status":  "DB_ERROR",
-				"message": err.Error(),
+				"message": "Database unavailable",
 			})
 		}
 
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Porsi pembagian setiap penerimaan
+const (
+	AllocationProgram    = "program"     // disalurkan ke penerima/program campaign
+	AllocationAmil       = "amil"        // operasional amil (zakat maks. 1/8, infaq/sedekah sesuai konfigurasi)
+	AllocationGatewayFee = "gateway_fee" // biaya payment gateway
+)
+
+// Jenis baris alokasi: kredit saat donasi sukses, pembalik (nominal negatif) saat refund
+const (
+	AllocationEntryCredit = "credit"
+	AllocationEntryRefund = "refund"
+)
+
+// DonationAllocation satu baris pembagian donasi; jumlah semua baris satu donasi
+// selalu sama dengan nominal yang masih dikredit (Amount - RefundedAmount)
+type DonationAllocation struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	DonationID int       `gorm:"index;not null" json:"donation_id"`
+	CampaignID int       `gorm:"index;not null" json:"campaign_id"`
+	FundType   string    `gorm:"type:varchar(20);not null;index" json:"fund_type"`
+	Portion    string    `gorm:"type:varchar(20);not null" json:"portion"`
+	Entry      string    `gorm:"type:varchar(10);not null" json:"entry"`
+	Rate       float64   `json:"rate"` // persentase yang dipakai saat kredit; 0 untuk sisa (program) dan biaya tetap
+	Amount     float64   `gorm:"not null" json:"amount"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
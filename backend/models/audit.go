@@ -0,0 +1,49 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Aksi yang dicatat di audit log
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// Jenis entity di audit log
+const (
+	AuditEntityUser             = "user"
+	AuditEntityCampaign         = "campaign"
+	AuditEntityDonation         = "donation"
+	AuditEntityReferenceRate    = "reference_rate"
+	AuditEntityMustahik         = "mustahik"
+	AuditEntityMustahikDocument = "mustahik_document"
+)
+
+// JSONText teks JSON yang dikirim apa adanya (bukan sebagai string) di response
+type JSONText string
+
+func (j JSONText) MarshalJSON() ([]byte, error) {
+	if j == "" || !json.Valid([]byte(j)) {
+		return []byte("null"), nil
+	}
+	return []byte(j), nil
+}
+
+// AuditEvent satu perubahan data administratif/keuangan beserta pelakunya
+type AuditEvent struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ActorID    *int      `gorm:"index" json:"actor_id,omitempty"`
+	ActorRole  string    `gorm:"type:varchar(30)" json:"actor_role,omitempty"`
+	ActorName  string    `gorm:"type:varchar(50)" json:"actor_name,omitempty"` // pelaku non-user, mis. "midtrans"
+	Action     string    `gorm:"type:varchar(20);index" json:"action"`
+	EntityType string    `gorm:"type:varchar(30);index:idx_audit_entity" json:"entity_type"`
+	EntityID   string    `gorm:"type:varchar(64);index:idx_audit_entity" json:"entity_id"`
+	Changes    JSONText  `gorm:"type:text" json:"changes"` // {"field": {"before": ..., "after": ...}}
+	IPAddress  string    `gorm:"type:varchar(45)" json:"ip_address,omitempty"`
+	UserAgent  string    `gorm:"type:text" json:"user_agent,omitempty"`
+	RequestID  string    `gorm:"type:varchar(64)" json:"request_id,omitempty"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
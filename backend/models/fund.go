@@ -0,0 +1,122 @@
+package models
+
+// Jenis dana; dana zakat wajib dicatat dan disalurkan terpisah dari dana lain
+const (
+	FundZakat   = "zakat"
+	FundInfaq   = "infaq"
+	FundSedekah = "sedekah"
+	FundWakaf   = "wakaf"
+	FundQurban  = "qurban"
+	FundFidyah  = "fidyah"
+)
+
+// DefaultFundType dipakai untuk campaign lama yang kategorinya tidak menyebut jenis dana
+const DefaultFundType = FundInfaq
+
+// Delapan golongan penerima zakat (asnaf)
+const (
+	AsnafFakir        = "fakir"
+	AsnafMiskin       = "miskin"
+	AsnafAmil         = "amil"
+	AsnafMualaf       = "mualaf"
+	AsnafRiqab        = "riqab"
+	AsnafGharimin     = "gharimin"
+	AsnafFisabilillah = "fisabilillah"
+	AsnafIbnuSabil    = "ibnu_sabil"
+)
+
+// Asnaf daftar lengkap golongan penerima zakat
+var Asnaf = []string{AsnafFakir, AsnafMiskin, AsnafAmil, AsnafMualaf, AsnafRiqab, AsnafGharimin, AsnafFisabilillah, AsnafIbnuSabil}
+
+// TargetPublic penyaluran untuk kemaslahatan umum (mis. pembangunan, pendidikan, bencana)
+const TargetPublic = "umum"
+
+// FundRule aturan setiap jenis dana
+type FundRule struct {
+	Type  string `json:"type"`
+	Label string `json:"label"`
+	// Targets tujuan penyaluran yang diperbolehkan (asnaf atau TargetPublic)
+	Targets []string `json:"targets"`
+	// AmilShareMax batas atas bagian amil/nazhir dari setiap penerimaan
+	AmilShareMax float64 `json:"amil_share_max"`
+	// ReceiptTitle dan ReceiptWording dipakai di bukti setor donasi
+	ReceiptTitle   string `json:"receipt_title"`
+	ReceiptWording string `json:"receipt_wording"`
+}
+
+var fundRules = map[string]FundRule{
+	FundZakat: {
+		Type:           FundZakat,
+		Label:          "Zakat",
+		Targets:        Asnaf,
+		AmilShareMax:   0.125, // 1/8, bagian asnaf amil
+		ReceiptTitle:   "Bukti Setor Zakat",
+		ReceiptWording: "Telah kami terima zakat Anda. Aajarakallahu fiimaa a'thaita, wa baaraka laka fiimaa abqaita, wa ja'alahu laka thahuuran. Semoga Allah memberi pahala atas apa yang Anda berikan, memberkahi harta yang tersisa, dan menjadikannya penyuci bagi Anda.",
+	},
+	FundInfaq: {
+		Type:           FundInfaq,
+		Label:          "Infaq",
+		Targets:        append(append([]string{}, Asnaf...), TargetPublic),
+		AmilShareMax:   0.2,
+		ReceiptTitle:   "Bukti Setor Infaq",
+		ReceiptWording: "Telah kami terima infaq Anda. Jazakumullahu khairan, semoga Allah melipatgandakan pahala dan memberkahi rezeki Anda.",
+	},
+	FundSedekah: {
+		Type:           FundSedekah,
+		Label:          "Sedekah",
+		Targets:        append(append([]string{}, Asnaf...), TargetPublic),
+		AmilShareMax:   0.2,
+		ReceiptTitle:   "Bukti Setor Sedekah",
+		ReceiptWording: "Telah kami terima sedekah Anda. Jazakumullahu khairan, semoga menjadi amal jariyah dan penolak bala bagi Anda sekeluarga.",
+	},
+	FundWakaf: {
+		Type:    FundWakaf,
+		Label:   "Wakaf",
+		Targets: []string{TargetPublic},
+		// Hak nazhir paling banyak 10% dari hasil bersih pengelolaan, bukan dari pokok wakaf
+		AmilShareMax:   0,
+		ReceiptTitle:   "Bukti Setor Wakaf Uang",
+		ReceiptWording: "Telah kami terima wakaf Anda. Pokok wakaf dijaga keutuhannya dan hasil pengelolaannya disalurkan untuk kemaslahatan umat. Semoga menjadi sedekah jariyah yang pahalanya terus mengalir.",
+	},
+	FundQurban: {
+		Type:           FundQurban,
+		Label:          "Qurban",
+		Targets:        []string{AsnafFakir, AsnafMiskin, TargetPublic},
+		AmilShareMax:   0,
+		ReceiptTitle:   "Bukti Setor Qurban",
+		ReceiptWording: "Telah kami terima dana qurban Anda. Hewan qurban akan disembelih pada hari raya Idul Adha dan hari tasyrik, dan dagingnya dibagikan kepada yang berhak. Semoga Allah menerima qurban Anda.",
+	},
+	FundFidyah: {
+		Type:           FundFidyah,
+		Label:          "Fidyah",
+		Targets:        []string{AsnafFakir, AsnafMiskin},
+		AmilShareMax:   0,
+		ReceiptTitle:   "Bukti Setor Fidyah",
+		ReceiptWording: "Telah kami terima fidyah Anda. Fidyah akan disalurkan dalam bentuk makanan kepada fakir miskin sesuai jumlah hari puasa yang ditinggalkan.",
+	},
+}
+
+// FundTypes urutan jenis dana untuk tampilan dan laporan
+var FundTypes = []string{FundZakat, FundInfaq, FundSedekah, FundWakaf, FundQurban, FundFidyah}
+
+// IsValidFundType mengecek jenis dana
+func IsValidFundType(fundType string) bool {
+	_, ok := fundRules[fundType]
+	return ok
+}
+
+// FundRuleFor aturan jenis dana; ok=false jika jenis tidak dikenal
+func FundRuleFor(fundType string) (FundRule, bool) {
+	rule, ok := fundRules[fundType]
+	return rule, ok
+}
+
+// AllowsTarget mengecek apakah dana boleh disalurkan ke tujuan tersebut
+func (r FundRule) AllowsTarget(target string) bool {
+	for _, t := range r.Targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package models
+
+import "strings"
+
+// AnonymousDonorName nama yang ditampilkan untuk donatur anonim di listing publik
+const AnonymousDonorName = "Hamba Allah"
+
+// DonorContact nama, email dan nomor donatur: dari akun jika ada, selain itu dari data tamu/offline
+func (d *Donation) DonorContact() (name, email, phone string) {
+	if d.User != nil {
+		name = strings.TrimSpace(d.User.FirstName + " " + d.User.LastName)
+		if name == "" {
+			name = d.User.Username
+		}
+		email, phone = d.User.Email, d.User.Phone
+	}
+	if name == "" {
+		name = d.DonorName
+	}
+	if email == "" {
+		email = d.DonorEmail
+	}
+	if phone == "" {
+		phone = d.DonorPhone
+	}
+	return name, email, phone
+}
+
+// MaskDonor menyembunyikan kontak donatur (tamu maupun akun), detail petugas offline, dan
+// identitas donatur anonim, untuk tampilan publik
+func (d *Donation) MaskDonor() {
+	d.DonorEmail, d.DonorPhone = "", ""
+	if d.User != nil {
+		d.User = d.User.PublicProfile()
+	}
+	if d.Offline != nil {
+		d.Offline = d.Offline.PublicDetail()
+	}
+	if d.IsAnonymous {
+		d.UserID, d.User = nil, nil
+		d.DonorName = AnonymousDonorName
+	}
+}
+
+// PublicProfile hanya nama tampilan dan foto; kontak, alamat dan data akun tidak ikut
+func (u *User) PublicProfile() *User {
+	return &User{ID: u.ID, FirstName: u.FirstName, LastName: u.LastName, Photo: u.Photo}
+}
+
+// PublicDetail jenis dan barang donasi offline tanpa petugas, lokasi dan nomor kwitansi
+func (o *OfflineDonation) PublicDetail() *OfflineDonation {
+	return &OfflineDonation{
+		ID:               o.ID,
+		DonationID:       o.DonationID,
+		Kind:             o.Kind,
+		GoodsDescription: o.GoodsDescription,
+		Quantity:         o.Quantity,
+		Unit:             o.Unit,
+		CreatedAt:        o.CreatedAt,
+	}
+}
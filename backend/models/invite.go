@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Status undangan admin
+const (
+	InviteStatusPending  = "pending"
+	InviteStatusAccepted = "accepted"
+	InviteStatusRevoked  = "revoked"
+	InviteStatusExpired  = "expired"
+)
+
+// AdminInvite adalah undangan untuk mendapatkan role staf. Token hanya disimpan dalam bentuk HMAC.
+type AdminInvite struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	Email        string     `gorm:"type:varchar(100);index" json:"email,omitempty"`
+	Phone        string     `gorm:"type:varchar(20);index" json:"phone,omitempty"`
+	Role         string     `gorm:"type:varchar(30);not null" json:"role"`
+	Channel      string     `gorm:"type:varchar(20)" json:"channel"`
+	TokenHash    string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	InvitedByID  int        `gorm:"index;not null" json:"invited_by_id"`
+	InvitedBy    User       `gorm:"foreignKey:InvitedByID" json:"invited_by,omitempty"`
+	ExpiresAt    time.Time  `gorm:"not null" json:"expires_at"`
+	AcceptedAt   *time.Time `json:"accepted_at,omitempty"`
+	AcceptedByID *int       `json:"accepted_by_id,omitempty"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	RevokedByID  *int       `json:"revoked_by_id,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Status menghitung status undangan dari kolom waktu
+func (i *AdminInvite) Status() string {
+	switch {
+	case i.AcceptedAt != nil:
+		return InviteStatusAccepted
+	case i.RevokedAt != nil:
+		return InviteStatusRevoked
+	case time.Now().After(i.ExpiresAt):
+		return InviteStatusExpired
+	default:
+		return InviteStatusPending
+	}
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// SchemaMigration penanda backfill data yang sudah dijalankan, agar tidak diulang saat start berikutnya
+type SchemaMigration struct {
+	Name      string    `gorm:"primaryKey;type:varchar(100)" json:"name"`
+	Skipped   bool      `gorm:"not null;default:false" json:"skipped"` // data sudah sesuai skema baru saat penanda dibuat
+	AppliedAt time.Time `gorm:"not null" json:"applied_at"`
+}
@@ -7,20 +7,26 @@ import (
 )
 
 type User struct {
-	ID        int            `gorm:"primaryKey" json:"id"`
-	FirstName string         `json:"first_name" form:"first_name"`
-	LastName  string         `json:"last_name" form:"last_name"`
-	Username  string         `json:"username" form:"username" gorm:"unique"`
-	Gender    string         `json:"gender" form:"gender"`
-	Phone     string         `json:"phone" form:"phone"`
-	Address   string         `json:"address" form:"address"`
-	Email     string         `json:"email" form:"email" gorm:"unique"`
-	Password  string         `json:"-" form:"password"`
-	Photo     string         `json:"photo" form:"photo"`
-	IsAdmin   bool           `json:"is_admin" form:"is_admin"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID              int            `gorm:"primaryKey" json:"id"`
+	FirstName       string         `json:"first_name" form:"first_name"`
+	LastName        string         `json:"last_name" form:"last_name"`
+	Username        string         `json:"username" form:"username" gorm:"unique"`
+	Gender          string         `json:"gender" form:"gender"`
+	Phone           string         `json:"phone" form:"phone"`
+	Address         string         `json:"address" form:"address"`
+	Email           string         `json:"email" form:"email" gorm:"unique"`
+	Password        string         `json:"-" form:"password" audit:"redact"`
+	Photo           string         `json:"photo" form:"photo"`
+	IsAdmin         bool           `json:"is_admin" form:"is_admin"`
+	Role            string         `json:"role" gorm:"type:varchar(30);default:donor"`
+	EmailVerifiedAt *time.Time     `json:"email_verified_at"` // terisi setelah link verifikasi email dibuka
+	PhoneVerifiedAt *time.Time     `json:"phone_verified_at"` // terisi setelah nomor HP diverifikasi lewat OTP
+	TOTPSecret      string         `json:"-" audit:"redact"`  // terenkripsi (pkg/secretbox)
+	TOTPEnabledAt   *time.Time     `json:"totp_enabled_at"`   // 2FA aktif jika terisi
+	TOTPLastCounter uint64         `json:"-"`                 // mencegah kode TOTP yang sama dipakai ulang
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relasi
 	Campaigns []Campaign `gorm:"foreignKey:UserID" json:"campaigns,omitempty"`
@@ -28,16 +34,20 @@ type User struct {
 }
 
 type UserResponseJWT struct {
-	ID       int    `json:"id"`
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Username string `json:"username"`
-	Gender   string `json:"gender" form:"gender"`
-	Phone    string `json:"phone" form:"phone"`
-	Address  string `json:"address" form:"address"`
-	Photo    string `json:"photo" form:"photo"`
-	Token    string `json:"token"`
-	IsAdmin  bool   `json:"is_admin" form:"is_admin"`
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	Username      string `json:"username"`
+	Gender        string `json:"gender" form:"gender"`
+	Phone         string `json:"phone" form:"phone"`
+	Address       string `json:"address" form:"address"`
+	Photo         string `json:"photo" form:"photo"`
+	Token         string `json:"token"`
+	IsAdmin       bool   `json:"is_admin" form:"is_admin"`
+	Role          string `json:"role"`
+	EmailVerified bool   `json:"email_verified"`
+	PhoneVerified bool   `json:"phone_verified"`
+	TwoFactor     bool   `json:"two_factor_enabled"`
 }
 
 type Campaign struct {
@@ -51,8 +61,10 @@ type Campaign struct {
 	Status         string         `json:"status" form:"status"`
 	Photo          string         `json:"photo" form:"photo"`
 	TargetTotal    float64        `json:"target_total" form:"target_total"`
-	TotalCollected float64        `json:"total_collected" form:"total_collected"`
+	TotalCollected float64        `json:"total_collected" form:"total_collected"` // bruto, sudah dikurangi refund
+	NetCollected   float64        `json:"net_collected"`                          // bagian program setelah hak amil dan biaya gateway
 	Category       string         `json:"category" form:"category"`
+	FundType       string         `gorm:"type:varchar(20);not null;default:infaq;index" json:"fund_type" form:"fund_type"` // lihat models/fund.go
 	Location       string         `json:"location" form:"location"`
 	UserID         int            `json:"user_id"`
 	User           User           `gorm:"foreignKey:UserID" json:"user"`
@@ -64,20 +76,47 @@ type Campaign struct {
 }
 
 type Donation struct {
-	ID            int            `gorm:"primaryKey" json:"id" form:"id"`
-	Amount        float64        `json:"amount" form:"amount"`
-	Date          time.Time      `json:"date" form:"date"`
-	Status        string         `json:"status" form:"status"`
-	UserID        int            `json:"user_id"`
-	User          User           `gorm:"foreignKey:UserID" json:"user"`
-	OrderID       string         `json:"order_id" gorm:"type:varchar(100);uniqueIndex"`
-	PaymentURL    string         `json:"payment_url" gorm:"type:text"`
-	PaymentMethod string         `json:"payment_method"`
-	CampaignID    int            `json:"campaign_id"`
-	Campaign      Campaign       `gorm:"foreignKey:CampaignID" json:"campaign"`
-	CreatedAt     time.Time      `json:"created_at"`
-	UpdatedAt     time.Time      `json:"updated_at"`
-	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+	ID              int              `gorm:"primaryKey" json:"id" form:"id"`
+	Amount          float64          `json:"amount" form:"amount"`
+	Date            time.Time        `json:"date" form:"date"`
+	Status          string           `json:"status" form:"status"`
+	UserID          *int             `json:"user_id"` // kosong untuk donatur offline tanpa akun
+	User            *User            `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	DonorName       string           `gorm:"type:varchar(150)" json:"donor_name,omitempty"`        // nama donatur tanpa akun (offline/tamu)
+	DonorEmail      string           `gorm:"type:varchar(100);index" json:"donor_email,omitempty"` // kontak donatur tamu; dipakai menautkan ke akun
+	DonorPhone      string           `gorm:"type:varchar(20)" json:"donor_phone,omitempty"`
+	IsAnonymous     bool             `gorm:"not null;default:false" json:"is_anonymous"` // nama disembunyikan di listing publik
+	Channel         string           `gorm:"type:varchar(20);not null;default:online" json:"channel"`
+	FundType        string           `gorm:"type:varchar(20);not null;default:infaq;index" json:"fund_type"` // selalu sama dengan jenis dana campaign
+	OrderID         string           `json:"order_id" gorm:"type:varchar(100);uniqueIndex"`
+	PaymentURL      string           `json:"payment_url" gorm:"type:text"`
+	PaymentMethod   string           `json:"payment_method"`
+	PaymentProvider string           `gorm:"type:varchar(20)" json:"payment_provider"` // kosong untuk data lama (Midtrans)
+	UniqueCode      int              `json:"unique_code,omitempty"`                    // kode 3 digit penanda transfer manual
+	TransferAccount string           `gorm:"type:varchar(255)" json:"-"`               // rekening tujuan transfer manual; nominal pending unik per rekening
+	CreditedAt      *time.Time       `json:"credited_at"`                              // kapan nominal ditambahkan ke total campaign; hanya sekali
+	ReconciledAt    *time.Time       `json:"-"`                                        // terakhir dicek reconciler; yang paling lama dicek didahulukan
+	RefundedAmount  float64          `json:"refunded_amount"`                          // total refund yang berhasil; sisa nominal tetap dihitung di campaign
+	CampaignID      int              `json:"campaign_id"`
+	Campaign        Campaign         `gorm:"foreignKey:CampaignID" json:"campaign"`
+	Offline         *OfflineDonation `gorm:"foreignKey:DonationID" json:"offline,omitempty"` // hanya untuk channel offline
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt   `gorm:"index" json:"-"`
+}
+
+// EmailVerification menyimpan token verifikasi email, dibuat saat sign-up,
+// kirim ulang, atau saat user mengganti email
+type EmailVerification struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	UserID    int            `gorm:"index;not null" json:"user_id"`
+	Email     string         `gorm:"not null" json:"email"`
+	Token     string         `gorm:"not null;uniqueIndex" json:"token"`
+	ExpiresAt time.Time      `gorm:"not null" json:"expires_at"`
+	Used      bool           `gorm:"default:false" json:"used"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 type PasswordReset struct {
@@ -0,0 +1,114 @@
+package models
+
+import "time"
+
+// Status kelayakan mustahik; ditentukan oleh asesmen terakhir
+const (
+	MustahikStatusPending    = "pending"    // belum diasesmen
+	MustahikStatusEligible   = "eligible"   // layak menerima
+	MustahikStatusIneligible = "ineligible" // tidak layak
+	MustahikStatusInactive   = "inactive"   // tidak lagi dibantu (pindah, meninggal, mampu)
+)
+
+// Jenis dokumen pendukung mustahik
+const (
+	MustahikDocumentKTP   = "ktp"
+	MustahikDocumentKK    = "kk"
+	MustahikDocumentSKTM  = "sktm" // surat keterangan tidak mampu
+	MustahikDocumentPhoto = "photo"
+	MustahikDocumentOther = "other"
+)
+
+// IsValidAsnaf mengecek golongan penerima zakat
+func IsValidAsnaf(asnaf string) bool {
+	for _, a := range Asnaf {
+		if a == asnaf {
+			return true
+		}
+	}
+	return false
+}
+
+// Mustahik penerima manfaat. NIK disimpan terenkripsi; pencarian dan deduplikasi memakai
+// NIKDigest (HMAC), sedangkan tampilan hanya memakai 4 digit terakhir. Tidak memakai soft delete:
+// data pribadi yang dihapus benar-benar hilang.
+type Mustahik struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	NIKDigest     string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	NIKEncrypted  string     `gorm:"type:text;not null" json:"-"`
+	NIKLast4      string     `gorm:"type:varchar(4)" json:"nik_last4"`
+	NIK           string     `gorm:"-" json:"nik,omitempty" audit:"redact"` // hanya diisi di detail, tidak disimpan
+	FullName      string     `gorm:"type:varchar(150);not null;index" json:"full_name" audit:"redact"`
+	Gender        string     `gorm:"type:varchar(10)" json:"gender"`
+	BirthDate     *time.Time `gorm:"type:date" json:"birth_date" audit:"redact"`
+	Phone         string     `gorm:"type:varchar(20)" json:"phone,omitempty" audit:"redact"`
+	Address       string     `gorm:"type:text" json:"address" audit:"redact"`
+	Village       string     `gorm:"type:varchar(100)" json:"village"` // desa/kelurahan
+	District      string     `gorm:"type:varchar(100)" json:"district"`
+	City          string     `gorm:"type:varchar(100);index" json:"city"`
+	Province      string     `gorm:"type:varchar(100)" json:"province"`
+	Occupation    string     `gorm:"type:varchar(100)" json:"occupation"`
+	MonthlyIncome float64    `json:"monthly_income"` // penghasilan rumah tangga per bulan
+	HouseholdSize int        `gorm:"not null;default:1" json:"household_size"`
+	Asnaf         string     `gorm:"type:varchar(20);index" json:"asnaf"`
+	Status        string     `gorm:"type:varchar(20);not null;default:pending;index" json:"status"`
+	Notes         string     `gorm:"type:text" json:"notes"`
+	AssessedAt    *time.Time `json:"assessed_at"`
+	CreatedByID   int        `json:"created_by_id"`
+
+	Members     []MustahikMember     `gorm:"foreignKey:MustahikID" json:"members,omitempty"`
+	Assessments []MustahikAssessment `gorm:"foreignKey:MustahikID" json:"assessments,omitempty"`
+	Documents   []MustahikDocument   `gorm:"foreignKey:MustahikID" json:"documents,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IncomePerCapita penghasilan per anggota rumah tangga
+func (m *Mustahik) IncomePerCapita() float64 {
+	if m.HouseholdSize <= 0 {
+		return m.MonthlyIncome
+	}
+	return m.MonthlyIncome / float64(m.HouseholdSize)
+}
+
+// MustahikMember anggota rumah tangga (selain kepala keluarga)
+type MustahikMember struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	MustahikID    uint       `gorm:"index;not null" json:"mustahik_id"`
+	Name          string     `gorm:"type:varchar(150);not null" json:"name"`
+	Relationship  string     `gorm:"type:varchar(30)" json:"relationship"` // istri, suami, anak, orang tua, ...
+	BirthDate     *time.Time `gorm:"type:date" json:"birth_date"`
+	Occupation    string     `gorm:"type:varchar(100)" json:"occupation"`
+	MonthlyIncome float64    `json:"monthly_income"`
+}
+
+// MustahikAssessment hasil asesmen kelayakan; status dan asnaf mustahik mengikuti asesmen terakhir
+type MustahikAssessment struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	MustahikID      uint       `gorm:"index;not null" json:"mustahik_id"`
+	AssessorID      int        `gorm:"not null" json:"assessor_id"`
+	Asnaf           string     `gorm:"type:varchar(20);not null" json:"asnaf"`
+	Eligible        bool       `json:"eligible"`
+	MonthlyIncome   float64    `json:"monthly_income"`
+	HouseholdSize   int        `json:"household_size"`
+	IncomePerCapita float64    `json:"income_per_capita"`
+	Notes           string     `gorm:"type:text" json:"notes"`
+	ValidUntil      *time.Time `gorm:"type:date" json:"valid_until"` // asesmen ulang setelah tanggal ini
+	AssessedAt      time.Time  `json:"assessed_at"`
+}
+
+// MustahikDocument dokumen pendukung (KTP, KK, SKTM, foto rumah), disimpan sebagai aset privat
+// Cloudinary (pkg/docstore). URL tidak disimpan; diisi URL bertanda tangan berumur pendek saat dibaca.
+type MustahikDocument struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	MustahikID   uint      `gorm:"index;not null" json:"mustahik_id"`
+	Kind         string    `gorm:"type:varchar(20);not null" json:"kind"`
+	PublicID     string    `gorm:"type:varchar(255)" json:"-"`
+	ResourceType string    `gorm:"type:varchar(10)" json:"-"`
+	Format       string    `gorm:"type:varchar(10)" json:"format"`
+	URL          string    `gorm:"-" json:"url,omitempty"`
+	Note         string    `gorm:"type:varchar(255)" json:"note"`
+	UploadedByID int       `json:"uploaded_by_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
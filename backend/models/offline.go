@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Channel donasi, ditampilkan di riwayat donatur dan laporan
+const (
+	DonationChannelOnline         = "online"          // payment gateway
+	DonationChannelManualTransfer = "manual_transfer" // transfer bank, diverifikasi admin
+	DonationChannelOffline        = "offline"         // tunai/barang yang dicatat amil
+)
+
+// Jenis donasi offline
+const (
+	OfflineKindCash  = "cash"
+	OfflineKindGoods = "goods" // mis. beras zakat fitrah; Amount berisi taksiran nilai
+)
+
+// PaymentMethodOffline metode pembayaran untuk donasi yang dicatat langsung oleh amil
+const PaymentMethodOffline = "offline"
+
+// CollectionSession satu sesi pengumpulan offline (mis. penerimaan zakat fitrah di masjid
+// pada malam tertentu), dipakai untuk input donasi secara batch
+type CollectionSession struct {
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	CampaignID    int            `gorm:"index;not null" json:"campaign_id"`
+	Campaign      Campaign       `gorm:"foreignKey:CampaignID" json:"campaign,omitempty"`
+	Name          string         `gorm:"type:varchar(150);not null" json:"name"`
+	Location      string         `gorm:"type:varchar(255)" json:"location"`
+	CollectorName string         `gorm:"type:varchar(150)" json:"collector_name"`
+	CollectedAt   time.Time      `json:"collected_at"`
+	Note          string         `gorm:"type:text" json:"note"`
+	CreatedByID   int            `gorm:"not null" json:"created_by_id"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// OfflineDonation detail donasi tunai/barang yang dicatat amil; satu baris per Donation
+// dengan channel offline
+type OfflineDonation struct {
+	ID                  uint      `gorm:"primaryKey" json:"id"`
+	DonationID          int       `gorm:"uniqueIndex;not null" json:"donation_id"`
+	CollectionSessionID *uint     `gorm:"index" json:"collection_session_id"`
+	Kind                string    `gorm:"type:varchar(10);not null" json:"kind"`
+	GoodsDescription    string    `gorm:"type:varchar(255)" json:"goods_description,omitempty"`
+	Quantity            float64   `json:"quantity,omitempty"`
+	Unit                string    `gorm:"type:varchar(20)" json:"unit,omitempty"` // mis. kg, liter
+	CollectorName       string    `gorm:"type:varchar(150)" json:"collector_name"`
+	Location            string    `gorm:"type:varchar(255)" json:"location"`
+	ReceiptNumber       string    `gorm:"type:varchar(50);uniqueIndex;not null" json:"receipt_number"`
+	RecordedByID        int       `gorm:"not null" json:"recorded_by_id"`
+	CreatedAt           time.Time `json:"created_at"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Tujuan kode OTP
+const (
+	OTPPurposeLogin       = "login"
+	OTPPurposeVerifyPhone = "verify_phone"
+)
+
+// PhoneOTP menyimpan kode OTP yang dikirim lewat WhatsApp/SMS. Kode hanya disimpan dalam bentuk hash.
+type PhoneOTP struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Phone       string     `gorm:"type:varchar(20);index;not null" json:"phone"`
+	Purpose     string     `gorm:"type:varchar(20);not null" json:"purpose"`
+	UserID      *int       `gorm:"index" json:"user_id,omitempty"`
+	CodeHash    string     `gorm:"type:varchar(64);not null" json:"-"`
+	Channel     string     `gorm:"type:varchar(20)" json:"channel"`
+	Attempts    int        `gorm:"default:0" json:"attempts"`
+	MaxAttempts int        `json:"max_attempts"`
+	ExpiresAt   time.Time  `gorm:"not null" json:"expires_at"`
+	ConsumedAt  *time.Time `json:"consumed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// IsUsable mengembalikan true jika kode belum dipakai, belum kadaluarsa, dan sisa percobaan masih ada
+func (o *PhoneOTP) IsUsable() bool {
+	return o.ConsumedAt == nil && o.Attempts < o.MaxAttempts && time.Now().Before(o.ExpiresAt)
+}
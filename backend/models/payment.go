@@ -0,0 +1,79 @@
+package models
+
+import "time"
+
+// Payment provider yang didukung (lihat pkg/payment)
+const (
+	PaymentProviderMidtrans = "midtrans"
+	PaymentProviderXendit   = "xendit"
+	PaymentProviderFake     = "fake"
+	PaymentProviderManual   = "manual" // transfer bank manual, diverifikasi admin
+)
+
+// Status donasi
+const (
+	DonationStatusPending  = "pending"
+	DonationStatusSuccess  = "success"
+	DonationStatusFailed   = "failed"
+	DonationStatusExpired  = "expired"
+	DonationStatusRefunded = "refunded"
+)
+
+// donationTransitions perpindahan status yang diizinkan; status lain bersifat final.
+// Expired hanya berarti berhenti menunggu: pembayaran yang tetap masuk sesudahnya masih dikredit.
+var donationTransitions = map[string][]string{
+	DonationStatusPending: {DonationStatusSuccess, DonationStatusFailed, DonationStatusExpired},
+	DonationStatusExpired: {DonationStatusSuccess},
+	DonationStatusSuccess: {DonationStatusRefunded},
+}
+
+// CanTransitionDonation mengecek apakah status donasi boleh berpindah dari `from` ke `to`.
+// Status kosong/"unknown" dari data lama diperlakukan sebagai pending.
+func CanTransitionDonation(from, to string) bool {
+	if from == "" || from == "unknown" {
+		from = DonationStatusPending
+	}
+	for _, next := range donationTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidDonationStatus mengecek apakah status dikenal
+func IsValidDonationStatus(status string) bool {
+	switch status {
+	case DonationStatusPending, DonationStatusSuccess, DonationStatusFailed, DonationStatusExpired, DonationStatusRefunded:
+		return true
+	}
+	return false
+}
+
+// Hasil pemrosesan notifikasi pembayaran
+const (
+	NotificationResultApplied   = "applied"   // status donasi berubah
+	NotificationResultUnchanged = "unchanged" // status sama dengan sebelumnya
+	NotificationResultIgnored   = "ignored"   // perpindahan status tidak diizinkan
+)
+
+// Sumber status pembayaran
+const (
+	NotificationSourceWebhook   = "webhook"
+	NotificationSourceReconcile = "reconcile"
+)
+
+// PaymentNotification notifikasi Midtrans yang sudah diproses. Unik per order, transaksi dan
+// status, sehingga retry notifikasi yang sama tidak diproses dua kali.
+type PaymentNotification struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	OrderID           string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_payment_notification" json:"order_id"`
+	TransactionID     string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_payment_notification" json:"transaction_id"`
+	TransactionStatus string    `gorm:"type:varchar(30);not null;uniqueIndex:idx_payment_notification" json:"transaction_status"`
+	FraudStatus       string    `gorm:"type:varchar(30)" json:"fraud_status"`
+	GrossAmount       string    `gorm:"type:varchar(30)" json:"gross_amount"`
+	DonationStatus    string    `gorm:"type:varchar(20)" json:"donation_status"` // status donasi hasil mapping
+	Result            string    `gorm:"type:varchar(20)" json:"result"`
+	Source            string    `gorm:"type:varchar(20);default:webhook" json:"source"`
+	CreatedAt         time.Time `json:"created_at"`
+}
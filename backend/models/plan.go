@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status rencana donasi rutin
+const (
+	DonationPlanActive    = "active"
+	DonationPlanPaused    = "paused"
+	DonationPlanCancelled = "cancelled"
+)
+
+// Interval rencana donasi rutin
+const (
+	DonationPlanWeekly  = "weekly"
+	DonationPlanMonthly = "monthly"
+)
+
+// DonationPlan donasi rutin (infaq/sedekah bulanan). Setiap periode scheduler membuat satu
+// Donation pending beserta link pembayarannya; donatur tetap membayar lewat payment gateway.
+type DonationPlan struct {
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	UserID       int            `gorm:"index;not null" json:"user_id"`
+	User         *User          `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	CampaignID   *int           `gorm:"index" json:"campaign_id"` // kosong berarti dana umum (GENERAL_FUND_CAMPAIGN_ID)
+	Campaign     *Campaign      `gorm:"foreignKey:CampaignID" json:"campaign,omitempty"`
+	Amount       float64        `gorm:"not null" json:"amount"`
+	Interval     string         `gorm:"type:varchar(10);not null" json:"interval"`
+	AnchorDay    int            `json:"anchor_day"` // tanggal tagihan bulanan (1-28), dari tanggal mulai
+	Status       string         `gorm:"type:varchar(20);index;not null" json:"status"`
+	NextChargeAt time.Time      `gorm:"index" json:"next_charge_at"`
+	PausedAt     *time.Time     `json:"paused_at"`
+	CancelledAt  *time.Time     `json:"cancelled_at"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// NextAfter tanggal tagihan berikutnya setelah `from` sesuai interval rencana
+func (p *DonationPlan) NextAfter(from time.Time) time.Time {
+	if p.Interval == DonationPlanWeekly {
+		return from.AddDate(0, 0, 7)
+	}
+	// Hari diambil dari AnchorDay agar tanggal 28 tidak bergeser ke awal bulan berikutnya
+	year, month, _ := from.Date()
+	next := time.Date(year, month+1, 1, from.Hour(), from.Minute(), 0, 0, from.Location())
+	day := p.AnchorDay
+	if day < 1 || day > 28 {
+		day = 1
+	}
+	return next.AddDate(0, 0, day-1)
+}
+
+// DonationPlanCharge donasi yang dibuat untuk satu periode rencana. Unik per (plan, periode)
+// sehingga periode yang sama tidak ditagih dua kali walaupun scheduler berjalan bersamaan.
+type DonationPlanCharge struct {
+	ID             uint          `gorm:"primaryKey" json:"id"`
+	PlanID         uint          `gorm:"uniqueIndex:idx_plan_period;not null" json:"plan_id"`
+	Plan           *DonationPlan `gorm:"foreignKey:PlanID" json:"plan,omitempty"`
+	PeriodAt       time.Time     `gorm:"uniqueIndex:idx_plan_period;not null" json:"period_at"`
+	DonationID     int           `gorm:"index;not null" json:"donation_id"`
+	Donation       *Donation     `gorm:"foreignKey:DonationID" json:"donation,omitempty"`
+	ReminderSentAt *time.Time    `json:"reminder_sent_at"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Pemicu proses rekonsiliasi
+const (
+	ReconciliationTriggerSchedule = "schedule"
+	ReconciliationTriggerManual   = "manual"
+)
+
+// Hasil pengecekan satu donasi saat rekonsiliasi
+const (
+	ReconciliationResultUpdated   = "updated"   // status diperbarui dari payment gateway
+	ReconciliationResultExpired   = "expired"   // belum dibayar di gateway dan sudah terlalu lama
+	ReconciliationResultUnchanged = "unchanged" // masih pending di gateway
+	ReconciliationResultMismatch  = "mismatch"  // nominal/order di gateway tidak cocok, perlu dicek manual
+	ReconciliationResultError     = "error"
+)
+
+// ReconciliationRun satu kali proses pencocokan donasi pending dengan payment gateway
+type ReconciliationRun struct {
+	ID          uint                 `gorm:"primaryKey" json:"id"`
+	Trigger     string               `gorm:"type:varchar(20)" json:"trigger"`
+	TriggeredBy *int                 `json:"triggered_by,omitempty"`
+	StartedAt   time.Time            `json:"started_at"`
+	FinishedAt  *time.Time           `json:"finished_at"`
+	Checked     int                  `json:"checked"`
+	Updated     int                  `json:"updated"`
+	Expired     int                  `json:"expired"`
+	Unchanged   int                  `json:"unchanged"`
+	Mismatched  int                  `json:"mismatched"`
+	Errors      int                  `json:"errors"`
+	Items       []ReconciliationItem `gorm:"foreignKey:RunID" json:"items,omitempty"`
+}
+
+// ReconciliationItem hasil pengecekan satu donasi dalam sebuah run
+type ReconciliationItem struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	RunID          uint      `gorm:"index;not null" json:"run_id"`
+	DonationID     int       `gorm:"index" json:"donation_id"`
+	OrderID        string    `gorm:"type:varchar(100)" json:"order_id"`
+	Amount         float64   `json:"amount"`
+	PreviousStatus string    `gorm:"type:varchar(20)" json:"previous_status"`
+	GatewayStatus  string    `gorm:"type:varchar(30)" json:"gateway_status"`
+	NewStatus      string    `gorm:"type:varchar(20)" json:"new_status"`
+	Result         string    `gorm:"type:varchar(20);index" json:"result"`
+	Error          string    `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
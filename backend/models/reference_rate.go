@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Jenis harga referensi untuk nisab dan zakat fitrah
+const (
+	RateKindGold   = "gold"   // harga emas per gram
+	RateKindSilver = "silver" // harga perak per gram
+	RateKindRice   = "rice"   // harga beras per kg
+	RateKindFitrah = "fitrah" // nominal fitrah resmi per jiwa
+)
+
+// Asal harga referensi; tarif resmi dari admin didahulukan dari hasil impor (lihat ReferenceRateRepository.Effective)
+const (
+	RateSourceImport   = "import"
+	RateSourceOfficial = "official"
+)
+
+// IsValidRateKind mengecek jenis harga referensi
+func IsValidRateKind(kind string) bool {
+	switch kind {
+	case RateKindGold, RateKindSilver, RateKindRice, RateKindFitrah:
+		return true
+	}
+	return false
+}
+
+// ReferenceRate riwayat harga referensi per wilayah; berlaku sejak EffectiveFrom sampai ada
+// harga yang lebih baru. Region kosong berarti berlaku nasional.
+type ReferenceRate struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	Kind          string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_reference_rate_key,priority:1" json:"kind"`
+	Region        string    `gorm:"type:varchar(50);not null;default:'';uniqueIndex:idx_reference_rate_key,priority:2" json:"region"`
+	EffectiveFrom time.Time `gorm:"type:date;not null;uniqueIndex:idx_reference_rate_key,priority:3" json:"effective_from"`
+	Source        string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_reference_rate_key,priority:4" json:"source"`
+	Price         float64   `gorm:"not null" json:"price"`
+	Authority     string    `gorm:"type:varchar(100)" json:"authority,omitempty"` // mis. "BAZNAS", "BAZNAS Kota Bandung", nama feed
+	Note          string    `gorm:"type:text" json:"note,omitempty"`
+	CreatedByID   *int      `json:"created_by_id,omitempty"` // kosong untuk hasil impor
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
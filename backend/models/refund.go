@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Status pengembalian dana
+const (
+	RefundStatusPending   = "pending"   // sudah dikirim ke gateway, menunggu konfirmasi
+	RefundStatusSucceeded = "succeeded" // dana kembali; total campaign sudah dikurangi
+	RefundStatusFailed    = "failed"
+)
+
+// Asal pencatatan refund
+const (
+	RefundSourceAdmin   = "admin"   // diajukan admin lewat API
+	RefundSourceGateway = "gateway" // ditemukan dari webhook/status gateway, mis. refund lewat dashboard provider
+)
+
+// Refund satu transaksi pengembalian dana untuk sebuah donasi. Satu donasi bisa punya
+// beberapa refund parsial; jumlah yang berhasil tercatat di Donation.RefundedAmount.
+type Refund struct {
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	DonationID       int        `gorm:"index;not null" json:"donation_id"`
+	Donation         *Donation  `gorm:"foreignKey:DonationID" json:"donation,omitempty"`
+	Amount           float64    `gorm:"not null" json:"amount"`
+	Reason           string     `gorm:"type:text" json:"reason"`
+	Status           string     `gorm:"type:varchar(20);index;not null" json:"status"`
+	Source           string     `gorm:"type:varchar(20);not null" json:"source"`
+	Provider         string     `gorm:"type:varchar(20)" json:"provider"`                         // kosong untuk transfer manual/offline
+	RefundKey        string     `gorm:"type:varchar(100);uniqueIndex;not null" json:"refund_key"` // idempotency key ke gateway
+	ProviderRefundID string     `gorm:"type:varchar(100);index" json:"provider_refund_id"`
+	RequestedByID    *int       `json:"requested_by_id"`
+	FailureReason    string     `gorm:"type:text" json:"failure_reason,omitempty"`
+	ProcessedAt      *time.Time `json:"processed_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,63 @@
+package models
+
+// Role menentukan hak akses user di seluruh API
+const (
+	RoleSuperAdmin      = "super_admin"
+	RoleAmil            = "amil"
+	RoleCampaignManager = "campaign_manager"
+	RoleDonor           = "donor"
+)
+
+// Roles berisi semua role yang valid
+var Roles = []string{
+	RoleSuperAdmin,
+	RoleAmil,
+	RoleCampaignManager,
+	RoleDonor,
+}
+
+// roleRank urutan hak akses role, dari yang paling rendah
+var roleRank = map[string]int{
+	RoleDonor:           0,
+	RoleCampaignManager: 1,
+	RoleAmil:            2,
+	RoleSuperAdmin:      3,
+}
+
+// IsRoleDowngrade mengecek apakah pindah dari role from ke role to menurunkan hak akses
+func IsRoleDowngrade(from, to string) bool {
+	return roleRank[to] < roleRank[from]
+}
+
+// IsValidRole mengecek apakah role dikenal
+func IsValidRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// IsStaffRole mengembalikan true untuk role selain donatur
+func IsStaffRole(role string) bool {
+	return IsValidRole(role) && role != RoleDonor
+}
+
+// EffectiveRole mengembalikan role user, dengan fallback ke IsAdmin
+// untuk akun lama yang belum memiliki kolom role
+func (u *User) EffectiveRole() string {
+	if IsValidRole(u.Role) && (u.Role != RoleDonor || !u.IsAdmin) {
+		return u.Role
+	}
+	if u.IsAdmin {
+		return RoleSuperAdmin
+	}
+	return RoleDonor
+}
+
+// SetRole mengubah role sekaligus menyelaraskan flag IsAdmin
+func (u *User) SetRole(role string) {
+	u.Role = role
+	u.IsAdmin = IsStaffRole(role)
+}
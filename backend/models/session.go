@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Session menyimpan satu sesi login. Access token membawa ID sesi (klaim "sid"),
+// sedangkan refresh token hanya disimpan dalam bentuk hash.
+type Session struct {
+	ID                string     `gorm:"primaryKey;type:varchar(36)" json:"id"`
+	UserID            int        `gorm:"index;not null" json:"user_id"`
+	RefreshTokenHash  string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	PreviousTokenHash string     `gorm:"type:varchar(64);index" json:"-"`
+	UserAgent         string     `json:"user_agent"`
+	IPAddress         string     `gorm:"type:varchar(64)" json:"ip_address"`
+	ExpiresAt         time.Time  `gorm:"not null" json:"expires_at"`
+	LastUsedAt        time.Time  `json:"last_used_at"`
+	RevokedAt         *time.Time `json:"revoked_at,omitempty"`
+	RevokedReason     string     `gorm:"type:varchar(50)" json:"revoked_reason,omitempty"`
+	MFAVerified       bool       `gorm:"default:false" json:"mfa_verified"` // login diselesaikan dengan 2FA
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// IsActive mengembalikan true jika sesi belum dicabut dan belum kadaluarsa
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
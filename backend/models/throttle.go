@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// ThrottleRecord menyimpan jumlah percobaan gagal per akun/IP (dipakai jika THROTTLE_STORE=database)
+type ThrottleRecord struct {
+	Key         string    `gorm:"primaryKey;type:varchar(191)" json:"key"`
+	Failures    int       `json:"failures"`
+	WindowStart time.Time `json:"window_start"`
+	LockedUntil time.Time `gorm:"index" json:"locked_until"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
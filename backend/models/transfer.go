@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Status bukti transfer manual
+const (
+	TransferProofSubmitted = "submitted"
+	TransferProofApproved  = "approved"
+	TransferProofRejected  = "rejected"
+)
+
+// PaymentMethodBankTransfer metode pembayaran untuk donasi transfer manual
+const PaymentMethodBankTransfer = "bank_transfer"
+
+// TransferProof bukti transfer yang diunggah donatur untuk donasi transfer manual.
+// Donasi baru dikredit setelah admin menyetujui salah satu bukti.
+type TransferProof struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	DonationID   int        `gorm:"index;not null" json:"donation_id"`
+	Donation     Donation   `gorm:"foreignKey:DonationID" json:"donation,omitempty"`
+	UploadedByID int        `gorm:"not null" json:"uploaded_by_id"`
+	FileURL      string     `gorm:"type:text;not null" json:"file_url"`
+	SenderBank   string     `gorm:"type:varchar(100)" json:"sender_bank"`
+	SenderName   string     `gorm:"type:varchar(100)" json:"sender_name"`
+	Note         string     `gorm:"type:text" json:"note"`
+	Status       string     `gorm:"type:varchar(20);index;not null" json:"status"`
+	ReviewedByID *int       `json:"reviewed_by_id"`
+	ReviewedAt   *time.Time `json:"reviewed_at"`
+	RejectReason string     `gorm:"type:text" json:"reject_reason,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// RecoveryCode adalah kode cadangan 2FA sekali pakai, disimpan dalam bentuk hash
+type RecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    int        `gorm:"index;not null" json:"user_id"`
+	CodeHash  string     `gorm:"type:varchar(64);not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
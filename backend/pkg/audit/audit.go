@@ -0,0 +1,130 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+// Actor pelaku perubahan, dibawa lewat context request sampai ke repository
+type Actor struct {
+	UserID    *int
+	Role      string
+	Name      string // pelaku non-user, mis. "midtrans" untuk webhook
+	IPAddress string
+	UserAgent string
+	RequestID string
+}
+
+type actorKey struct{}
+
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom mengembalikan actor dari context; kosong berarti proses sistem
+func ActorFrom(ctx context.Context) Actor {
+	if ctx == nil {
+		return Actor{}
+	}
+	actor, _ := ctx.Value(actorKey{}).(Actor)
+	return actor
+}
+
+// WithSystemActor menandai perubahan dilakukan oleh sistem (webhook, job), tetap menyimpan IP/request ID
+func WithSystemActor(ctx context.Context, name string) context.Context {
+	actor := ActorFrom(ctx)
+	actor.Name = name
+	return WithActor(ctx, actor)
+}
+
+// Change nilai sebelum dan sesudah untuk satu field
+type Change struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Field yang tidak dicatat karena berubah di setiap update
+var ignoredFields = map[string]bool{
+	"updated_at": true,
+}
+
+var naming = schema.NamingStrategy{}
+
+// Snapshot mengubah entity menjadi map field -> nilai mengikuti tag json.
+// Relasi (objek/array) dilewati. Field bertag `audit:"redact"` hanya dicatat hash-nya,
+// sehingga perubahan (mis. password) terlihat tanpa membocorkan nilainya.
+func Snapshot(entity interface{}) map[string]interface{} {
+	if entity == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(entity)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	raw, err := json.Marshal(entity)
+	if err != nil {
+		return nil
+	}
+	var snapshot map[string]interface{}
+	if err := json.Unmarshal(raw, &snapshot); err != nil {
+		return nil
+	}
+	for key, value := range snapshot {
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			delete(snapshot, key)
+		}
+	}
+
+	if rv.Kind() == reflect.Struct {
+		rt := rv.Type()
+		for i := 0; i < rt.NumField(); i++ {
+			if rt.Field(i).Tag.Get("audit") != "redact" {
+				continue
+			}
+			value := fmt.Sprint(rv.Field(i).Interface())
+			if value != "" {
+				sum := sha256.Sum256([]byte(value))
+				value = "sha256:" + hex.EncodeToString(sum[:])[:12]
+			}
+			snapshot[naming.ColumnName("", rt.Field(i).Name)] = value
+		}
+	}
+
+	return snapshot
+}
+
+// Diff membandingkan dua snapshot; before nil berarti create, after nil berarti delete
+func Diff(before, after map[string]interface{}) map[string]Change {
+	changes := map[string]Change{}
+	for key, value := range after {
+		if ignoredFields[key] {
+			continue
+		}
+		if old, ok := before[key]; !ok || !reflect.DeepEqual(old, value) {
+			if !ok && value == nil {
+				continue
+			}
+			changes[key] = Change{Before: before[key], After: value}
+		}
+	}
+	for key, value := range before {
+		if ignoredFields[key] || value == nil {
+			continue
+		}
+		if _, ok := after[key]; !ok {
+			changes[key] = Change{Before: value, After: nil}
+		}
+	}
+	return changes
+}
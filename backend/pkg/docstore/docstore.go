@@ -0,0 +1,102 @@
+// Package docstore menyimpan dokumen pribadi (KTP, KK, SKTM) di Cloudinary sebagai aset
+// "authenticated": tidak bisa dibuka lewat URL publik, hanya lewat URL unduhan bertanda tangan
+// yang kedaluwarsa. Public ID acak sehingga nama file asli tidak ikut tersimpan.
+package docstore
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+)
+
+// ErrNotConfigured kredensial Cloudinary belum diatur
+var ErrNotConfigured = errors.New("cloudinary configuration missing")
+
+// Asset dokumen yang sudah diunggah
+type Asset struct {
+	PublicID     string
+	ResourceType string // image atau raw (PDF dan lainnya)
+	Format       string
+}
+
+type Store struct {
+	cld    *cloudinary.Cloudinary
+	folder string
+	ttl    time.Duration
+}
+
+// NewFromEnv memakai CLOUDINARY_CLOUD_NAME, CLOUDINARY_API_KEY dan CLOUDINARY_API_SECRET;
+// ttl masa berlaku URL unduhan
+func NewFromEnv(folder string, ttl time.Duration) (*Store, error) {
+	cloudName := os.Getenv("CLOUDINARY_CLOUD_NAME")
+	apiKey := os.Getenv("CLOUDINARY_API_KEY")
+	apiSecret := os.Getenv("CLOUDINARY_API_SECRET")
+	if cloudName == "" || apiKey == "" || apiSecret == "" {
+		return nil, ErrNotConfigured
+	}
+
+	cld, err := cloudinary.NewFromParams(cloudName, apiKey, apiSecret)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{cld: cld, folder: folder, ttl: ttl}, nil
+}
+
+func (s *Store) Upload(ctx context.Context, file io.Reader) (*Asset, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	result, err := s.cld.Upload.Upload(ctx, file, uploader.UploadParams{
+		PublicID:     s.folder + "/" + hex.EncodeToString(id),
+		ResourceType: api.Auto,
+		Type:         api.Authenticated,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Error.Message != "" {
+		return nil, fmt.Errorf("cloudinary upload failed: %s", result.Error.Message)
+	}
+	return &Asset{PublicID: result.PublicID, ResourceType: result.ResourceType, Format: result.Format}, nil
+}
+
+// SignedURL URL unduhan bertanda tangan yang berlaku selama ttl
+func (s *Store) SignedURL(asset Asset) (string, error) {
+	expiresAt := time.Now().Add(s.ttl)
+	return s.cld.Upload.PrivateDownloadURL(uploader.PrivateDownloadURLParams{
+		PublicID:     asset.PublicID,
+		Format:       asset.Format,
+		DeliveryType: api.Authenticated,
+		ExpiresAt:    &expiresAt,
+		ResourceType: api.AssetType(asset.ResourceType),
+	})
+}
+
+// Destroy menghapus aset permanen dan membersihkan cache CDN; aset yang sudah tidak ada bukan error
+func (s *Store) Destroy(ctx context.Context, asset Asset) error {
+	invalidate := true
+	result, err := s.cld.Upload.Destroy(ctx, uploader.DestroyParams{
+		PublicID:     asset.PublicID,
+		Type:         api.Authenticated,
+		ResourceType: asset.ResourceType,
+		Invalidate:   &invalidate,
+	})
+	if err != nil {
+		return err
+	}
+	if result.Result != "ok" && result.Result != "not found" {
+		return fmt.Errorf("cloudinary destroy %s: %s %s", asset.PublicID, result.Result, result.Error.Message)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package jwtToken
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 )
@@ -44,3 +45,34 @@ func DecodeToken(tokenString string) (MapClaims, error) {
 
 	return claims, nil
 }
+
+// Token type yang disimpan di klaim "typ"
+const (
+	TokenTypeAccess       = "access"
+	TokenTypeMFAChallenge = "mfa_challenge"
+)
+
+// MFAChallengeTTL masa berlaku challenge token langkah kedua login 2FA
+const MFAChallengeTTL = 5 * time.Minute
+
+// AccessTokenTTL masa berlaku access token (ACCESS_TOKEN_TTL, default 15 menit)
+func AccessTokenTTL() time.Duration {
+	return durationFromEnv("ACCESS_TOKEN_TTL", 15*time.Minute)
+}
+
+// RefreshTokenTTL masa berlaku refresh token (REFRESH_TOKEN_TTL, default 30 hari)
+func RefreshTokenTTL() time.Duration {
+	return durationFromEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+}
+
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"zakat/pkg/audit"
+	"zakat/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AuditContext menyimpan IP, user agent dan request ID ke context request
+// agar perubahan data di repository bisa dicatat beserta asal request-nya
+func AuditContext(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		actor := audit.Actor{
+			IPAddress: c.RealIP(),
+			UserAgent: req.UserAgent(),
+			RequestID: response.RequestID(c),
+		}
+		c.SetRequest(req.WithContext(audit.WithActor(req.Context(), actor)))
+		return next(c)
+	}
+}
+
+// setAuditUser melengkapi actor audit dengan user yang sudah terautentikasi
+func setAuditUser(c echo.Context, userID int, role string) {
+	req := c.Request()
+	actor := audit.ActorFrom(req.Context())
+	actor.UserID = &userID
+	actor.Role = role
+	c.SetRequest(req.WithContext(audit.WithActor(req.Context(), actor)))
+}
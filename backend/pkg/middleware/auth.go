@@ -5,29 +5,36 @@ import (
 	"net/http"
 	"strings"
 
-	dto "zakat/dto/result"
+	"zakat/models"
 	jwtToken "zakat/pkg/jwt"
+	"zakat/pkg/response"
 
 	"github.com/labstack/echo/v4"
 )
 
+// SessionVerifier dipakai Auth untuk mengecek apakah sesi pada token masih aktif
+type SessionVerifier interface {
+	IsSessionActive(sessionID string) (bool, error)
+}
+
+var sessionVerifier SessionVerifier
+
+// SetSessionVerifier mendaftarkan pengecek sesi (biasanya SessionRepository)
+func SetSessionVerifier(v SessionVerifier) {
+	sessionVerifier = v
+}
+
 // Auth is an Echo middleware
 func Auth(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		authHeader := c.Request().Header.Get("Authorization")
 		if authHeader == "" {
-			return c.JSON(http.StatusUnauthorized, dto.ErrorResult{
-				Code:    http.StatusUnauthorized,
-				Message: "Authorization header missing",
-			})
+			return response.Fail(http.StatusUnauthorized, "Authorization header missing")
 		}
 
 		splitToken := strings.Split(authHeader, " ")
 		if len(splitToken) != 2 || strings.ToLower(splitToken[0]) != "bearer" {
-			return c.JSON(http.StatusUnauthorized, dto.ErrorResult{
-				Code:    http.StatusUnauthorized,
-				Message: "Invalid token format",
-			})
+			return response.NewError(http.StatusUnauthorized, response.CodeInvalidToken, "Invalid token format")
 		}
 
 		token := splitToken[1]
@@ -35,17 +42,67 @@ func Auth(next echo.HandlerFunc) echo.HandlerFunc {
 		claims, err := jwtToken.DecodeToken(token)
 		if err != nil {
 			fmt.Println("Decode error:", err)
-			return c.JSON(http.StatusUnauthorized, dto.ErrorResult{
-				Code:    http.StatusUnauthorized,
-				Message: "Invalid or expired token",
-			})
+			return response.NewError(http.StatusUnauthorized, response.CodeInvalidToken, "Invalid or expired token")
 		}
 
-		fmt.Println("Decoded claims:", claims)
+		id, ok := claims["id"].(float64)
+		if !ok {
+			return response.NewError(http.StatusUnauthorized, response.CodeInvalidToken, "Invalid or expired token")
+		}
+
+		// Hanya access token yang terikat ke sesi aktif yang diterima
+		sessionID, _ := claims["sid"].(string)
+		tokenType, _ := claims["typ"].(string)
+		if sessionID == "" || tokenType != jwtToken.TokenTypeAccess {
+			return response.NewError(http.StatusUnauthorized, response.CodeInvalidToken, "Invalid or expired token")
+		}
+
+		if sessionVerifier != nil {
+			active, err := sessionVerifier.IsSessionActive(sessionID)
+			if err != nil {
+				fmt.Println("Session check error:", err)
+				return response.Fail(http.StatusInternalServerError, "Failed to verify session")
+			}
+			if !active {
+				return response.NewError(http.StatusUnauthorized, response.CodeSessionRevoked, "Session has been revoked or expired")
+			}
+		}
 
-		// Simpan user ID ke context Echo
-		c.Set("userLogin", int(claims["id"].(float64)))
+		// Simpan user ID, role dan sesi ke context Echo
+		role := roleFromClaims(claims)
+		c.Set("userLogin", int(id))
+		c.Set("userRole", role)
+		c.Set("sessionID", sessionID)
+		mfaVerified, _ := claims["mfa"].(bool)
+		c.Set("mfaVerified", mfaVerified)
+		setAuditUser(c, int(id), role)
 
 		return next(c)
 	}
 }
+
+// roleFromClaims membaca role dari token, dengan fallback ke klaim
+// is_admin/isAdmin untuk token yang dibuat sebelum ada role
+func roleFromClaims(claims jwtToken.MapClaims) string {
+	if role, ok := claims["role"].(string); ok && models.IsValidRole(role) {
+		return role
+	}
+	for _, key := range []string{"is_admin", "isAdmin"} {
+		if isAdmin, ok := claims[key].(bool); ok && isAdmin {
+			return models.RoleSuperAdmin
+		}
+	}
+	return models.RoleDonor
+}
+
+// OptionalAuth untuk endpoint publik yang menampilkan data lebih lengkap bagi user login:
+// tanpa header Authorization request diteruskan sebagai tamu, dengan header divalidasi seperti Auth
+func OptionalAuth(next echo.HandlerFunc) echo.HandlerFunc {
+	withAuth := Auth(next)
+	return func(c echo.Context) error {
+		if c.Request().Header.Get("Authorization") == "" {
+			return next(c)
+		}
+		return withAuth(c)
+	}
+}
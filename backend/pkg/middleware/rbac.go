@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"zakat/models"
+	"zakat/pkg/response"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Permission adalah hak akses yang dibutuhkan sebuah route
+type Permission string
+
+const (
+	PermUserRead          Permission = "users:read"
+	PermUserManage        Permission = "users:manage"
+	PermUserManageRoles   Permission = "users:manage_roles"
+	PermCampaignCreate    Permission = "campaigns:create"
+	PermCampaignManage    Permission = "campaigns:manage"     // hanya campaign milik sendiri
+	PermCampaignManageAll Permission = "campaigns:manage_all" // campaign milik siapa pun
+	PermDonationCreate    Permission = "donations:create"
+	PermDonationReadAll   Permission = "donations:read_all"
+	PermDonationManage    Permission = "donations:manage"
+	PermDonationVerify    Permission = "donations:verify"
+	PermDonationOffline   Permission = "donations:record_offline"
+	PermDonationRefund    Permission = "donations:refund"
+	PermAuditRead         Permission = "audit:read"
+	PermFinanceReport     Permission = "finance:reports"
+	PermReferenceRates    Permission = "reference_rates:manage"
+	PermMustahikRead      Permission = "mustahik:read"
+	PermMustahikManage    Permission = "mustahik:manage"
+)
+
+// rolePermissions memetakan setiap role ke daftar permission yang dimiliki
+var rolePermissions = map[string][]Permission{
+	models.RoleSuperAdmin: {
+		PermUserRead,
+		PermUserManage,
+		PermUserManageRoles,
+		PermCampaignCreate,
+		PermCampaignManage,
+		PermCampaignManageAll,
+		PermDonationCreate,
+		PermDonationReadAll,
+		PermDonationManage,
+		PermDonationVerify,
+		PermDonationOffline,
+		PermDonationRefund,
+		PermAuditRead,
+		PermFinanceReport,
+		PermReferenceRates,
+		PermMustahikRead,
+		PermMustahikManage,
+	},
+	models.RoleAmil: {
+		PermUserRead,
+		PermDonationCreate,
+		PermDonationReadAll,
+		PermDonationManage,
+		PermDonationVerify,
+		PermDonationOffline,
+		PermDonationRefund,
+		PermFinanceReport,
+		PermReferenceRates,
+		PermMustahikRead,
+		PermMustahikManage,
+	},
+	models.RoleCampaignManager: {
+		PermCampaignCreate,
+		PermCampaignManage,
+		PermDonationCreate,
+	},
+	models.RoleDonor: {
+		PermDonationCreate,
+	},
+}
+
+// RoleHasPermission mengecek apakah role memiliki permission tertentu
+func RoleHasPermission(role string, perm Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// UserRole mengambil role user yang login dari context (di-set oleh Auth)
+func UserRole(c echo.Context) string {
+	role, _ := c.Get("userRole").(string)
+	if role == "" {
+		return models.RoleDonor
+	}
+	return role
+}
+
+// HasPermission mengecek permission user yang sedang login
+func HasPermission(c echo.Context, perm Permission) bool {
+	return RoleHasPermission(UserRole(c), perm)
+}
+
+// IsSelfOrHasPermission mengizinkan akses ke data milik sendiri,
+// atau ke data orang lain jika memiliki permission
+func IsSelfOrHasPermission(c echo.Context, ownerID int, perm Permission) bool {
+	userID, ok := c.Get("userLogin").(int)
+	if ok && userID == ownerID {
+		return true
+	}
+	return HasPermission(c, perm)
+}
+
+// RoleRequiresMFA mengecek apakah role wajib login dengan 2FA. Opt-in lewat TWO_FACTOR_REQUIRED_ROLES
+// (dipisah koma, mis. "super_admin,amil"); kosong berarti tidak ada role yang wajib.
+func RoleRequiresMFA(role string) bool {
+	for _, r := range strings.Split(os.Getenv("TWO_FACTOR_REQUIRED_ROLES"), ",") {
+		if strings.TrimSpace(r) == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorize memastikan user yang login memiliki permission. Harus dipasang setelah Auth.
+func Authorize(perm Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if _, ok := c.Get("userLogin").(int); !ok {
+				return response.Fail(http.StatusUnauthorized, "Unauthorized")
+			}
+
+			if !HasPermission(c, perm) {
+				return response.Fail(http.StatusForbidden, "Access denied. Missing permission: "+string(perm))
+			}
+
+			// Role tertentu hanya boleh memakai permission jika login dengan 2FA
+			if mfa, _ := c.Get("mfaVerified").(bool); !mfa && RoleRequiresMFA(UserRole(c)) {
+				return response.NewError(http.StatusForbidden, response.CodeMFARequired, "Two-factor authentication required for this role")
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// Protect menggabungkan Auth dan Authorize untuk satu route
+func Protect(perm Permission, next echo.HandlerFunc) echo.HandlerFunc {
+	return Auth(Authorize(perm)(next))
+}
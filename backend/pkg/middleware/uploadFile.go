@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"time"
+	"zakat/pkg/response"
 
 	"github.com/cloudinary/cloudinary-go/v2"
 	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
@@ -28,18 +29,12 @@ func UploadFile(formImage string) echo.MiddlewareFunc {
 
 			err := c.Request().ParseMultipartForm(MAX_UPLOAD_SIZE)
 			if err != nil {
-				return c.JSON(http.StatusBadRequest, Result{
-					Code:    http.StatusBadRequest,
-					Message: "File too large",
-				})
+				return response.NewError(http.StatusBadRequest, response.CodeUploadFailed, "File too large")
 			}
 
 			file, fileHeader, err := c.Request().FormFile(formImage)
 			if err != nil {
-				return c.JSON(http.StatusBadRequest, Result{
-					Code:    http.StatusBadRequest,
-					Message: "Error retrieving the file",
-				})
+				return response.NewError(http.StatusBadRequest, response.CodeUploadFailed, "Error retrieving the file")
 			}
 			defer file.Close()
 
@@ -50,19 +45,13 @@ func UploadFile(formImage string) echo.MiddlewareFunc {
 
 			// Validasi konfigurasi
 			if cloudName == "" || apiKey == "" || apiSecret == "" {
-				return c.JSON(http.StatusInternalServerError, Result{
-					Code:    http.StatusInternalServerError,
-					Message: "Cloudinary configuration missing",
-				})
+				return response.Fail(http.StatusInternalServerError, "Cloudinary configuration missing")
 			}
 
 			// Inisialisasi Cloudinary
 			cld, err := cloudinary.NewFromParams(cloudName, apiKey, apiSecret)
 			if err != nil {
-				return c.JSON(http.StatusInternalServerError, Result{
-					Code:    http.StatusInternalServerError,
-					Message: "Failed to initialize cloudinary",
-				})
+				return response.Fail(http.StatusInternalServerError, "Failed to initialize cloudinary")
 			}
 
 			// Buat public ID unik untuk file
@@ -73,10 +62,7 @@ func UploadFile(formImage string) echo.MiddlewareFunc {
 				PublicID: publicID,
 			})
 			if err != nil {
-				return c.JSON(http.StatusInternalServerError, Result{
-					Code:    http.StatusInternalServerError,
-					Message: "Failed to upload file to cloudinary",
-				})
+				return response.NewError(http.StatusInternalServerError, response.CodeUploadFailed, "Failed to upload file to cloudinary")
 			}
 
 			// Simpan URL hasil upload ke context agar handler bisa akses
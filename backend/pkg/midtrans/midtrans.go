@@ -1,6 +1,9 @@
 package midtrans
 
 import (
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
 	"fmt"
 	"os"
 
@@ -12,15 +15,17 @@ import (
 var (
 	SnapClient snap.Client
 	CoreClient coreapi.Client
+
+	serverKey string
 )
 
 func Init() {
 	// Ambil key dari environment variable
-	serverKey := os.Getenv("MIDTRANS_SERVER_KEY")
+	serverKey = os.Getenv("MIDTRANS_SERVER_KEY")
 	clientKey := os.Getenv("MIDTRANS_CLIENT_KEY")
 
-	fmt.Println("🔑 Midtrans Server Key:", serverKey[:10]+"...")
-	fmt.Println("🔑 Midtrans Client Key:", clientKey[:10]+"...")
+	fmt.Println("🔑 Midtrans Server Key:", maskKey(serverKey))
+	fmt.Println("🔑 Midtrans Client Key:", maskKey(clientKey))
 	fmt.Println("🌍 Midtrans Environment: Sandbox")
 
 	// Setup Midtrans config global
@@ -36,3 +41,30 @@ func Init() {
 	CoreClient = coreapi.Client{}
 	CoreClient.New(serverKey, midtrans.Environment)
 }
+
+// maskKey menampilkan awal key saja; key kosong/pendek tidak membuat Init panic
+func maskKey(key string) string {
+	if key == "" {
+		return "(not set)"
+	}
+	if len(key) <= 10 {
+		return "***"
+	}
+	return key[:10] + "..."
+}
+
+// Signature menghitung signature_key notifikasi Midtrans:
+// SHA512(order_id + status_code + gross_amount + server key)
+func Signature(orderID, statusCode, grossAmount string) string {
+	sum := sha512.Sum512([]byte(orderID + statusCode + grossAmount + serverKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifySignature mengecek signature_key pada notifikasi; selalu gagal jika server key belum diset
+func VerifySignature(orderID, statusCode, grossAmount, signatureKey string) bool {
+	if serverKey == "" || signatureKey == "" {
+		return false
+	}
+	expected := Signature(orderID, statusCode, grossAmount)
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(signatureKey)) == 1
+}
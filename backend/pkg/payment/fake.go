@@ -0,0 +1,263 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==================== Fake Gateway ====================
+
+// FakeSignatureHeader header berisi HMAC-SHA256 body webhook dari FakeGateway
+const FakeSignatureHeader = "X-Fake-Signature"
+
+// FakeGateway payment gateway palsu di dalam proses untuk development dan pengujian tanpa jaringan.
+// Sekaligus Provider dan http.Handler: halaman /pay/{order_id} mensimulasikan halaman pembayaran,
+// dan setiap perubahan status dikirim sebagai webhook bertanda tangan ke webhookURL.
+type FakeGateway struct {
+	baseURL    string
+	webhookURL string
+	finishURL  string
+	secret     []byte
+	client     *http.Client
+
+	mu           sync.Mutex
+	transactions map[string]*Transaction
+}
+
+// NewFakeGateway baseURL alamat tempat gateway dipasang (mis. http://localhost:8080/fake-gateway),
+// webhookURL endpoint notifikasi aplikasi, finishURL tujuan redirect setelah "membayar" (boleh kosong)
+func NewFakeGateway(baseURL, webhookURL, finishURL, secret string) *FakeGateway {
+	return &FakeGateway{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		webhookURL:   webhookURL,
+		finishURL:    finishURL,
+		secret:       []byte(secret),
+		client:       &http.Client{Timeout: 10 * time.Second},
+		transactions: map[string]*Transaction{},
+	}
+}
+
+func (f *FakeGateway) Name() string {
+	return "fake"
+}
+
+func (f *FakeGateway) CreateCharge(ctx context.Context, req ChargeRequest) (*Charge, error) {
+	token := make([]byte, 8)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+
+	txn := &Transaction{
+		OrderID:       req.OrderID,
+		TransactionID: "FAKE-" + hex.EncodeToString(token),
+		Status:        StatusPending,
+		RawStatus:     StatusPending,
+		GrossAmount:   req.Amount,
+	}
+	f.mu.Lock()
+	f.transactions[req.OrderID] = txn
+	f.mu.Unlock()
+
+	return &Charge{
+		OrderID:       req.OrderID,
+		TransactionID: txn.TransactionID,
+		Token:         hex.EncodeToString(token),
+		RedirectURL:   f.baseURL + "/pay/" + url.PathEscape(req.OrderID),
+	}, nil
+}
+
+func (f *FakeGateway) GetStatus(ctx context.Context, orderID string) (*Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	txn, ok := f.transactions[orderID]
+	if !ok {
+		return nil, ErrTransactionNotFound
+	}
+	snapshot := *txn
+	snapshot.Refunds = append([]Refund(nil), txn.Refunds...)
+	return &snapshot, nil
+}
+
+// Refund langsung berhasil; sebagian nominal membuat status partially_refunded
+func (f *FakeGateway) Refund(ctx context.Context, req RefundRequest) (*Refund, error) {
+	f.mu.Lock()
+	txn, ok := f.transactions[req.OrderID]
+	if !ok {
+		f.mu.Unlock()
+		return nil, fmt.Errorf("%w: %w", ErrRefundRejected, ErrTransactionNotFound)
+	}
+	for _, r := range txn.Refunds {
+		if r.RefundKey == req.RefundKey {
+			f.mu.Unlock()
+			return &r, nil
+		}
+	}
+	if txn.Status != StatusPaid && txn.Status != StatusPartiallyRefunded {
+		f.mu.Unlock()
+		return nil, fmt.Errorf("%w: fake gateway cannot refund %s transaction", ErrRefundRejected, txn.Status)
+	}
+
+	refund := Refund{RefundID: "FAKE-REFUND-" + req.RefundKey, RefundKey: req.RefundKey, Amount: req.Amount, Status: RefundStatusSucceeded}
+	txn.Refunds = append(txn.Refunds, refund)
+	var refunded float64
+	for _, r := range txn.Refunds {
+		refunded += r.Amount
+	}
+	txn.Status = StatusPartiallyRefunded
+	if AmountMatches(refunded, txn.GrossAmount) || refunded > txn.GrossAmount {
+		txn.Status = StatusRefunded
+	}
+	txn.RawStatus = txn.Status
+	f.mu.Unlock()
+
+	f.sendWebhook(req.OrderID)
+	return &refund, nil
+}
+
+type fakeWebhook struct {
+	OrderID       string  `json:"order_id"`
+	TransactionID string  `json:"transaction_id"`
+	Status        string  `json:"status"`
+	GrossAmount   float64 `json:"gross_amount"`
+	PaymentType   string  `json:"payment_type"`
+}
+
+func (f *FakeGateway) ParseWebhook(body []byte, header http.Header) (*Transaction, error) {
+	if !hmac.Equal([]byte(f.sign(body)), []byte(header.Get(FakeSignatureHeader))) {
+		return nil, ErrInvalidSignature
+	}
+	var n fakeWebhook
+	if err := json.Unmarshal(body, &n); err != nil {
+		return nil, fmt.Errorf("invalid notification payload: %w", err)
+	}
+	return &Transaction{
+		OrderID:       n.OrderID,
+		TransactionID: n.TransactionID,
+		Status:        n.Status,
+		RawStatus:     n.Status,
+		GrossAmount:   n.GrossAmount,
+		PaymentType:   n.PaymentType,
+	}, nil
+}
+
+func (f *FakeGateway) sign(body []byte) string {
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (f *FakeGateway) setStatus(orderID, status, paymentType string) (*Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	txn, ok := f.transactions[orderID]
+	if !ok {
+		return nil, ErrTransactionNotFound
+	}
+	txn.Status, txn.RawStatus = status, status
+	if paymentType != "" {
+		txn.PaymentType = paymentType
+	}
+	snapshot := *txn
+	return &snapshot, nil
+}
+
+// sendWebhook mengirim status terbaru ke aplikasi, seperti notifikasi HTTP gateway asli
+func (f *FakeGateway) sendWebhook(orderID string) {
+	txn, err := f.GetStatus(context.Background(), orderID)
+	if err != nil || f.webhookURL == "" {
+		return
+	}
+	body, _ := json.Marshal(fakeWebhook{
+		OrderID:       txn.OrderID,
+		TransactionID: txn.TransactionID,
+		Status:        txn.Status,
+		GrossAmount:   txn.GrossAmount,
+		PaymentType:   txn.PaymentType,
+	})
+
+	req, err := http.NewRequest(http.MethodPost, f.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("[FakeGateway] Failed to build webhook: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(FakeSignatureHeader, f.sign(body))
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		log.Printf("[FakeGateway] Webhook for order_id=%s failed: %v", orderID, err)
+		return
+	}
+	resp.Body.Close()
+	log.Printf("[FakeGateway] Webhook for order_id=%s status=%s -> %d", orderID, txn.Status, resp.StatusCode)
+}
+
+var fakePayPage = template.Must(template.New("pay").Parse(`<!DOCTYPE html>
+<html><head><title>Fake Payment Gateway</title></head>
+<body style="font-family:sans-serif;max-width:480px;margin:40px auto">
+<h2>Fake Payment Gateway</h2>
+<p>Order: <b>{{.OrderID}}</b><br>Amount: <b>{{printf "%.2f" .GrossAmount}}</b><br>Status: <b>{{.Status}}</b></p>
+<form method="post">
+<button name="action" value="pay">Pay</button>
+<button name="action" value="fail">Fail</button>
+<button name="action" value="expire">Expire</button>
+</form>
+</body></html>`))
+
+// ServeHTTP GET /pay/{order_id} menampilkan halaman bayar; POST mengubah status (action=pay|fail|expire),
+// mengirim webhook, lalu redirect ke finishURL
+func (f *FakeGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	orderID, ok := strings.CutPrefix(r.URL.Path, "/pay/")
+	if !ok || orderID == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		txn, err := f.GetStatus(r.Context(), orderID)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fakePayPage.Execute(w, txn)
+
+	case http.MethodPost:
+		statuses := map[string]string{"pay": StatusPaid, "fail": StatusFailed, "expire": StatusExpired}
+		status, ok := statuses[r.FormValue("action")]
+		if !ok {
+			http.Error(w, "invalid action", http.StatusBadRequest)
+			return
+		}
+		if _, err := f.setStatus(orderID, status, "fake_transfer"); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		f.sendWebhook(orderID)
+
+		target := f.finishURL
+		if target == "" {
+			target = f.baseURL + "/pay/" + url.PathEscape(orderID)
+		} else {
+			target += "?" + url.Values{"order_id": {orderID}, "status": {status}}.Encode()
+		}
+		http.Redirect(w, r, target, http.StatusSeeOther)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,183 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	zakatMidtrans "zakat/pkg/midtrans"
+
+	midtransSdk "github.com/midtrans/midtrans-go"
+	"github.com/midtrans/midtrans-go/coreapi"
+	"github.com/midtrans/midtrans-go/snap"
+)
+
+// ==================== Midtrans ====================
+
+// Midtrans adapter Snap + Core API; memakai client dari pkg/midtrans (lihat midtrans.Init)
+type Midtrans struct{}
+
+func NewMidtrans() *Midtrans {
+	return &Midtrans{}
+}
+
+func (m *Midtrans) Name() string {
+	return "midtrans"
+}
+
+func (m *Midtrans) CreateCharge(ctx context.Context, req ChargeRequest) (*Charge, error) {
+	snapReq := &snap.Request{
+		TransactionDetails: midtransSdk.TransactionDetails{
+			OrderID:  req.OrderID,
+			GrossAmt: int64(req.Amount),
+		},
+		CustomerDetail: &midtransSdk.CustomerDetails{
+			FName: req.CustomerName,
+			Email: req.CustomerEmail,
+			Phone: req.CustomerPhone,
+		},
+		Items: &[]midtransSdk.ItemDetails{
+			{
+				ID:    req.ItemID,
+				Price: int64(req.Amount),
+				Qty:   1,
+				Name:  req.ItemName,
+			},
+		},
+	}
+
+	resp, err := zakatMidtrans.SnapClient.CreateTransaction(snapReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snap transaction: %v", err)
+	}
+	return &Charge{OrderID: req.OrderID, Token: resp.Token, RedirectURL: resp.RedirectURL}, nil
+}
+
+func (m *Midtrans) GetStatus(ctx context.Context, orderID string) (*Transaction, error) {
+	status, err := zakatMidtrans.CoreClient.CheckTransaction(orderID)
+	if err != nil {
+		if err.StatusCode == http.StatusNotFound {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, fmt.Errorf("failed to check transaction status: %v", err)
+	}
+	if status.StatusCode == "404" {
+		return nil, ErrTransactionNotFound
+	}
+
+	return &Transaction{
+		OrderID:       status.OrderID,
+		TransactionID: status.TransactionID,
+		Status:        midtransStatus(status.TransactionStatus, status.FraudStatus),
+		RawStatus:     status.TransactionStatus,
+		FraudStatus:   status.FraudStatus,
+		GrossAmount:   parseAmount(status.GrossAmount),
+		PaymentType:   status.PaymentType,
+		Refunds:       midtransRefunds(status.Refunds),
+	}, nil
+}
+
+// midtransRefunds Midtrans hanya mencantumkan refund yang sudah berhasil di status transaksi
+func midtransRefunds(details []coreapi.RefundDetails) []Refund {
+	refunds := make([]Refund, 0, len(details))
+	for _, d := range details {
+		refunds = append(refunds, Refund{
+			RefundID:  d.RefundChargebackUUID,
+			RefundKey: d.RefundKey,
+			Amount:    parseAmount(d.RefundAmount),
+			Status:    RefundStatusSucceeded,
+		})
+	}
+	return refunds
+}
+
+func (m *Midtrans) Refund(ctx context.Context, req RefundRequest) (*Refund, error) {
+	resp, err := zakatMidtrans.CoreClient.RefundTransaction(req.OrderID, &coreapi.RefundReq{
+		RefundKey: req.RefundKey,
+		Amount:    int64(req.Amount),
+		Reason:    req.Reason,
+	})
+	if err != nil {
+		if rejectedStatus(err.StatusCode) {
+			return nil, fmt.Errorf("%w: %v", ErrRefundRejected, err)
+		}
+		return nil, fmt.Errorf("failed to refund transaction: %v", err)
+	}
+	if resp.StatusCode != "200" && resp.StatusCode != "201" {
+		if code, _ := strconv.Atoi(resp.StatusCode); rejectedStatus(code) {
+			return nil, fmt.Errorf("%w: %s %s", ErrRefundRejected, resp.StatusCode, resp.StatusMessage)
+		}
+		return nil, fmt.Errorf("refund not confirmed: %s %s", resp.StatusCode, resp.StatusMessage)
+	}
+	status := RefundStatusPending
+	if resp.TransactionStatus == "refund" || resp.TransactionStatus == "partial_refund" {
+		status = RefundStatusSucceeded
+	}
+	return &Refund{RefundID: resp.RefundChargebackUUID, RefundKey: req.RefundKey, Amount: req.Amount, Status: status}, nil
+}
+
+// midtransNotification field notifikasi HTTP Midtrans yang dibutuhkan
+type midtransNotification struct {
+	OrderID           string `json:"order_id"`
+	TransactionID     string `json:"transaction_id"`
+	StatusCode        string `json:"status_code"`
+	GrossAmount       string `json:"gross_amount"`
+	SignatureKey      string `json:"signature_key"`
+	TransactionStatus string `json:"transaction_status"`
+	FraudStatus       string `json:"fraud_status"`
+	PaymentType       string `json:"payment_type"`
+}
+
+// ParseWebhook memverifikasi signature_key: SHA512(order_id + status_code + gross_amount + server key)
+func (m *Midtrans) ParseWebhook(body []byte, header http.Header) (*Transaction, error) {
+	var n midtransNotification
+	if err := json.Unmarshal(body, &n); err != nil {
+		return nil, fmt.Errorf("invalid notification payload: %w", err)
+	}
+	if n.OrderID == "" {
+		return nil, fmt.Errorf("invalid notification payload: missing order_id")
+	}
+	if !zakatMidtrans.VerifySignature(n.OrderID, n.StatusCode, n.GrossAmount, n.SignatureKey) {
+		return nil, ErrInvalidSignature
+	}
+
+	return &Transaction{
+		OrderID:       n.OrderID,
+		TransactionID: n.TransactionID,
+		Status:        midtransStatus(n.TransactionStatus, n.FraudStatus),
+		RawStatus:     n.TransactionStatus,
+		FraudStatus:   n.FraudStatus,
+		GrossAmount:   parseAmount(n.GrossAmount),
+		PaymentType:   n.PaymentType,
+	}, nil
+}
+
+// midtransStatus memetakan transaction_status/fraud_status Midtrans; kosong jika tidak ditangani
+func midtransStatus(transactionStatus, fraudStatus string) string {
+	switch transactionStatus {
+	case "capture":
+		switch fraudStatus {
+		case "accept":
+			return StatusPaid
+		case "challenge":
+			return StatusPending
+		default:
+			return StatusFailed
+		}
+	case "settlement":
+		return StatusPaid
+	case "pending":
+		return StatusPending
+	case "deny", "cancel", "failure":
+		return StatusFailed
+	case "expire":
+		return StatusExpired
+	case "refund":
+		return StatusRefunded
+	case "partial_refund":
+		return StatusPartiallyRefunded
+	}
+	return ""
+}
@@ -0,0 +1,173 @@
+// Package payment abstraksi payment gateway yang tidak bergantung pada satu provider.
+// Handler dan service hanya memakai tipe di paket ini; detail Midtrans, Xendit, dan
+// gateway palsu untuk pengujian ada di adapter masing-masing.
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// Status transaksi yang sudah dinormalkan dari status masing-masing provider
+const (
+	StatusPending  = "pending"
+	StatusPaid     = "paid"
+	StatusFailed   = "failed"
+	StatusExpired  = "expired"
+	StatusRefunded = "refunded"
+	// StatusPartiallyRefunded sebagian nominal sudah dikembalikan; transaksi tetap dianggap dibayar
+	StatusPartiallyRefunded = "partially_refunded"
+)
+
+// Status pengembalian dana yang sudah dinormalkan
+const (
+	RefundStatusPending   = "pending"
+	RefundStatusSucceeded = "succeeded"
+	RefundStatusFailed    = "failed"
+)
+
+var (
+	// ErrTransactionNotFound provider tidak mengenal order ID (mis. halaman bayar tidak pernah dibuka)
+	ErrTransactionNotFound = errors.New("transaction not found at payment gateway")
+	// ErrInvalidSignature webhook tidak lolos verifikasi signature/token
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrNotSupported operasi tidak didukung provider
+	ErrNotSupported = errors.New("operation not supported by payment provider")
+	// ErrUnknownProvider provider tidak terdaftar
+	ErrUnknownProvider = errors.New("unknown payment provider")
+	// ErrRefundRejected provider menolak refund secara pasti (mis. transaksi tidak bisa di-refund);
+	// error lain dari Refund (timeout, 5xx) berarti hasilnya belum pasti dan boleh diulang dengan RefundKey yang sama
+	ErrRefundRejected = errors.New("refund rejected by payment provider")
+)
+
+// ChargeRequest data untuk membuat tagihan pembayaran
+type ChargeRequest struct {
+	OrderID       string
+	Amount        float64
+	CustomerName  string
+	CustomerEmail string
+	CustomerPhone string
+	ItemID        string
+	ItemName      string
+}
+
+// Charge tagihan yang dibuat provider; donatur diarahkan ke RedirectURL
+type Charge struct {
+	OrderID       string
+	TransactionID string
+	Token         string
+	RedirectURL   string
+}
+
+// Transaction status transaksi di provider
+type Transaction struct {
+	OrderID       string
+	TransactionID string
+	Status        string // salah satu Status*; kosong jika status provider tidak ditangani
+	RawStatus     string // status asli dari provider, untuk log dan audit
+	FraudStatus   string
+	GrossAmount   float64
+	PaymentType   string
+	// Refunds pengembalian dana yang tercatat di provider untuk transaksi ini
+	Refunds []Refund
+}
+
+// RefundRequest permintaan pengembalian dana; RefundKey dipakai sebagai idempotency key
+type RefundRequest struct {
+	OrderID       string
+	TransactionID string
+	RefundKey     string
+	Amount        float64
+	Reason        string
+}
+
+// Refund hasil pengembalian dana di provider
+type Refund struct {
+	RefundID  string
+	RefundKey string
+	Amount    float64
+	Status    string // salah satu RefundStatus*
+}
+
+// Provider adapter satu payment gateway
+type Provider interface {
+	Name() string
+	CreateCharge(ctx context.Context, req ChargeRequest) (*Charge, error)
+	GetStatus(ctx context.Context, orderID string) (*Transaction, error)
+	Refund(ctx context.Context, req RefundRequest) (*Refund, error)
+	// ParseWebhook memverifikasi dan membaca webhook. Hasilnya hanya pemicu;
+	// status yang dipakai tetap diambil ulang lewat GetStatus.
+	ParseWebhook(body []byte, header http.Header) (*Transaction, error)
+}
+
+// Registry daftar provider yang aktif beserta provider default untuk donasi baru
+type Registry struct {
+	providers map[string]Provider
+	def       string
+}
+
+func NewRegistry() *Registry {
+	return &Registry{providers: map[string]Provider{}}
+}
+
+// Register menambahkan provider; provider pertama menjadi default
+func (r *Registry) Register(p Provider) {
+	r.providers[p.Name()] = p
+	if r.def == "" {
+		r.def = p.Name()
+	}
+}
+
+// SetDefault memilih provider default; error jika belum terdaftar
+func (r *Registry) SetDefault(name string) error {
+	if _, ok := r.providers[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+	r.def = name
+	return nil
+}
+
+func (r *Registry) Default() (Provider, error) {
+	return r.Get(r.def)
+}
+
+func (r *Registry) Get(name string) (Provider, error) {
+	if p, ok := r.providers[name]; ok {
+		return p, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+}
+
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AmountMatches membandingkan nominal dari provider dengan nominal donasi
+func AmountMatches(a, b float64) bool {
+	return math.Abs(a-b) < 0.01
+}
+
+// parseAmount membaca nominal berformat string seperti "50000.00"
+func parseAmount(value string) float64 {
+	amount, _ := strconv.ParseFloat(value, 64)
+	return amount
+}
+
+// rejectedStatus status HTTP yang berarti permintaan pasti ditolak; 408, 409 dan 429 bisa berubah jika diulang
+func rejectedStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests:
+		return false
+	}
+	return code >= 400 && code < 500
+}
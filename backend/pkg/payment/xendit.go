@@ -0,0 +1,277 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ==================== Xendit ====================
+
+const xenditBaseURL = "https://api.xendit.co"
+
+// Xendit adapter Invoice API. Webhook diverifikasi dengan header x-callback-token.
+type Xendit struct {
+	secretKey     string
+	callbackToken string
+	successURL    string
+	failureURL    string
+	baseURL       string
+	client        *http.Client
+}
+
+// NewXendit membuat adapter; successURL/failureURL tujuan redirect setelah pembayaran (boleh kosong)
+func NewXendit(secretKey, callbackToken, successURL, failureURL string) *Xendit {
+	return &Xendit{
+		secretKey:     secretKey,
+		callbackToken: callbackToken,
+		successURL:    successURL,
+		failureURL:    failureURL,
+		baseURL:       xenditBaseURL,
+		client:        &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (x *Xendit) Name() string {
+	return "xendit"
+}
+
+type xenditInvoice struct {
+	ID            string  `json:"id"`
+	ExternalID    string  `json:"external_id"`
+	Status        string  `json:"status"`
+	Amount        float64 `json:"amount"`
+	InvoiceURL    string  `json:"invoice_url"`
+	PaymentMethod string  `json:"payment_method"`
+}
+
+// xenditRefund objek Refund API; metadata.order_id diisi saat refund dibuat agar callback bisa dicocokkan
+type xenditRefund struct {
+	ID          string            `json:"id"`
+	ReferenceID string            `json:"reference_id"`
+	InvoiceID   string            `json:"invoice_id"`
+	Amount      float64           `json:"amount"`
+	Status      string            `json:"status"`
+	Metadata    map[string]string `json:"metadata"`
+}
+
+func (x *Xendit) CreateCharge(ctx context.Context, req ChargeRequest) (*Charge, error) {
+	body := map[string]interface{}{
+		"external_id": req.OrderID,
+		"amount":      req.Amount,
+		"description": req.ItemName,
+		"customer": map[string]interface{}{
+			"given_names":   req.CustomerName,
+			"email":         req.CustomerEmail,
+			"mobile_number": req.CustomerPhone,
+		},
+		"items": []map[string]interface{}{
+			{"name": req.ItemName, "quantity": 1, "price": req.Amount, "reference_id": req.ItemID},
+		},
+	}
+	if req.CustomerEmail != "" {
+		body["payer_email"] = req.CustomerEmail
+	}
+	if x.successURL != "" {
+		body["success_redirect_url"] = x.successURL
+	}
+	if x.failureURL != "" {
+		body["failure_redirect_url"] = x.failureURL
+	}
+
+	var invoice xenditInvoice
+	if err := x.call(ctx, http.MethodPost, "/v2/invoices", body, nil, &invoice); err != nil {
+		return nil, fmt.Errorf("failed to create xendit invoice: %w", err)
+	}
+	return &Charge{OrderID: req.OrderID, TransactionID: invoice.ID, RedirectURL: invoice.InvoiceURL}, nil
+}
+
+func (x *Xendit) GetStatus(ctx context.Context, orderID string) (*Transaction, error) {
+	var invoices []xenditInvoice
+	path := "/v2/invoices?external_id=" + url.QueryEscape(orderID)
+	if err := x.call(ctx, http.MethodGet, path, nil, nil, &invoices); err != nil {
+		return nil, fmt.Errorf("failed to get xendit invoice: %w", err)
+	}
+	if len(invoices) == 0 {
+		return nil, ErrTransactionNotFound
+	}
+	// Invoice terbaru untuk external_id yang sama ada di urutan pertama
+	txn := xenditTransaction(invoices[0])
+	if txn.Status != StatusPaid {
+		return txn, nil
+	}
+
+	var refunds struct {
+		Data []xenditRefund `json:"data"`
+	}
+	path = "/refunds?invoice_id=" + url.QueryEscape(txn.TransactionID)
+	if err := x.call(ctx, http.MethodGet, path, nil, nil, &refunds); err != nil && !errors.Is(err, ErrTransactionNotFound) {
+		return nil, fmt.Errorf("failed to list xendit refunds: %w", err)
+	}
+
+	var refunded float64
+	for _, r := range refunds.Data {
+		refund := xenditRefundResult(r)
+		txn.Refunds = append(txn.Refunds, refund)
+		if refund.Status == RefundStatusSucceeded {
+			refunded += refund.Amount
+		}
+	}
+	switch {
+	case refunded > 0 && (refunded >= txn.GrossAmount || AmountMatches(refunded, txn.GrossAmount)):
+		txn.Status = StatusRefunded
+	case refunded > 0:
+		txn.Status = StatusPartiallyRefunded
+	}
+	return txn, nil
+}
+
+func (x *Xendit) Refund(ctx context.Context, req RefundRequest) (*Refund, error) {
+	body := map[string]interface{}{
+		"invoice_id":   req.TransactionID,
+		"amount":       req.Amount,
+		"reference_id": req.RefundKey,
+		"reason":       "REQUESTED_BY_CUSTOMER",
+		"metadata":     map[string]string{"order_id": req.OrderID, "note": req.Reason},
+	}
+	header := http.Header{"Idempotency-Key": []string{req.RefundKey}}
+
+	var resp xenditRefund
+	if err := x.call(ctx, http.MethodPost, "/refunds", body, header, &resp); err != nil {
+		var statusErr *xenditStatusError
+		if errors.Is(err, ErrTransactionNotFound) || (errors.As(err, &statusErr) && rejectedStatus(statusErr.code)) {
+			return nil, fmt.Errorf("%w: %w", ErrRefundRejected, err)
+		}
+		return nil, fmt.Errorf("failed to refund xendit invoice: %w", err)
+	}
+	refund := xenditRefundResult(resp)
+	return &refund, nil
+}
+
+func xenditRefundResult(r xenditRefund) Refund {
+	status := RefundStatusPending
+	switch strings.ToUpper(r.Status) {
+	case "SUCCEEDED":
+		status = RefundStatusSucceeded
+	case "FAILED", "CANCELLED":
+		status = RefundStatusFailed
+	}
+	return Refund{RefundID: r.ID, RefundKey: r.ReferenceID, Amount: r.Amount, Status: status}
+}
+
+func (x *Xendit) ParseWebhook(body []byte, header http.Header) (*Transaction, error) {
+	token := header.Get("X-Callback-Token")
+	if x.callbackToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(x.callbackToken)) != 1 {
+		return nil, ErrInvalidSignature
+	}
+
+	// Callback Refund API berbentuk {"event": "refund.succeeded", "data": {...}}; hanya berisi Refunds
+	var event struct {
+		Event string       `json:"event"`
+		Data  xenditRefund `json:"data"`
+	}
+	if err := json.Unmarshal(body, &event); err == nil && strings.HasPrefix(event.Event, "refund.") {
+		orderID := event.Data.Metadata["order_id"]
+		if orderID == "" {
+			return nil, fmt.Errorf("invalid notification payload: refund without order_id")
+		}
+		return &Transaction{
+			OrderID:       orderID,
+			TransactionID: event.Data.InvoiceID,
+			RawStatus:     event.Event,
+			Refunds:       []Refund{xenditRefundResult(event.Data)},
+		}, nil
+	}
+
+	var invoice xenditInvoice
+	if err := json.Unmarshal(body, &invoice); err != nil {
+		return nil, fmt.Errorf("invalid notification payload: %w", err)
+	}
+	if invoice.ExternalID == "" {
+		return nil, fmt.Errorf("invalid notification payload: missing external_id")
+	}
+	return xenditTransaction(invoice), nil
+}
+
+func xenditTransaction(invoice xenditInvoice) *Transaction {
+	return &Transaction{
+		OrderID:       invoice.ExternalID,
+		TransactionID: invoice.ID,
+		Status:        xenditStatus(invoice.Status),
+		RawStatus:     invoice.Status,
+		GrossAmount:   invoice.Amount,
+		PaymentType:   strings.ToLower(invoice.PaymentMethod),
+	}
+}
+
+func xenditStatus(status string) string {
+	switch strings.ToUpper(status) {
+	case "PENDING":
+		return StatusPending
+	case "PAID", "SETTLED":
+		return StatusPaid
+	case "EXPIRED":
+		return StatusExpired
+	}
+	return ""
+}
+
+// call mengirim request ke Xendit dengan basic auth (secret key sebagai username)
+func (x *Xendit) call(ctx context.Context, method, path string, body interface{}, header http.Header, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, x.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(x.secretKey, "")
+	req.Header.Set("Content-Type", "application/json")
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	resp, err := x.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrTransactionNotFound
+	}
+	if resp.StatusCode >= 300 {
+		return &xenditStatusError{code: resp.StatusCode, body: strings.TrimSpace(string(raw))}
+	}
+	return json.Unmarshal(raw, out)
+}
+
+// xenditStatusError respons non-2xx dari Xendit
+type xenditStatusError struct {
+	code int
+	body string
+}
+
+func (e *xenditStatusError) Error() string {
+	return fmt.Sprintf("xendit returned %d: %s", e.code, e.body)
+}
@@ -0,0 +1,43 @@
+package phone
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalid dikembalikan jika nomor tidak bisa diubah ke format E.164
+var ErrInvalid = errors.New("invalid phone number")
+
+// Normalize mengubah nomor HP ke format E.164. Nomor lokal Indonesia
+// (08xx, 628xx, 8xx) otomatis diberi awalan +62.
+func Normalize(raw string) (string, error) {
+	var digits strings.Builder
+	for i, r := range strings.TrimSpace(raw) {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0:
+		case r == ' ' || r == '-' || r == '(' || r == ')' || r == '.':
+		default:
+			return "", ErrInvalid
+		}
+	}
+
+	number := digits.String()
+	international := strings.HasPrefix(strings.TrimSpace(raw), "+")
+
+	switch {
+	case international:
+	case strings.HasPrefix(number, "0"):
+		number = "62" + number[1:]
+	case strings.HasPrefix(number, "62"):
+	case strings.HasPrefix(number, "8"):
+		number = "62" + number
+	}
+
+	// E.164: maksimal 15 digit, nomor Indonesia minimal 10 digit termasuk kode negara
+	if len(number) < 10 || len(number) > 15 || number[0] == '0' {
+		return "", ErrInvalid
+	}
+	return "+" + number, nil
+}
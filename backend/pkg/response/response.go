@@ -0,0 +1,275 @@
+package response
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"zakat/pkg/validator"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Code kode error yang stabil, dipakai client untuk menangani error tanpa membaca pesan
+type Code string
+
+const (
+	CodeBadRequest              Code = "BAD_REQUEST"
+	CodeValidationFailed        Code = "VALIDATION_FAILED"
+	CodeUnauthorized            Code = "UNAUTHORIZED"
+	CodeInvalidCredentials      Code = "INVALID_CREDENTIALS"
+	CodeInvalidToken            Code = "INVALID_TOKEN"
+	CodeSessionRevoked          Code = "SESSION_REVOKED"
+	CodeInvalidOTP              Code = "INVALID_OTP"
+	CodeInvalidMFACode          Code = "INVALID_MFA_CODE"
+	CodeForbidden               Code = "FORBIDDEN"
+	CodeMFARequired             Code = "MFA_REQUIRED"
+	CodeEmailNotVerified        Code = "EMAIL_NOT_VERIFIED"
+	CodePhoneNotVerified        Code = "PHONE_NOT_VERIFIED"
+	CodeNotFound                Code = "NOT_FOUND"
+	CodeUserNotFound            Code = "USER_NOT_FOUND"
+	CodeCampaignNotFound        Code = "CAMPAIGN_NOT_FOUND"
+	CodeDonationNotFound        Code = "DONATION_NOT_FOUND"
+	CodeInviteNotFound          Code = "INVITE_NOT_FOUND"
+	CodeConflict                Code = "CONFLICT"
+	CodeUsernameTaken           Code = "USERNAME_TAKEN"
+	CodeEmailTaken              Code = "EMAIL_TAKEN"
+	CodePhoneTaken              Code = "PHONE_TAKEN"
+	CodeTargetReached           Code = "TARGET_REACHED"
+	CodeInviteUnavailable       Code = "INVITE_UNAVAILABLE"
+	CodeRateLimited             Code = "RATE_LIMITED"
+	CodePaymentFailed           Code = "PAYMENT_FAILED"
+	CodeInvalidSignature        Code = "INVALID_SIGNATURE"
+	CodePaymentMismatch         Code = "PAYMENT_MISMATCH"
+	CodeInvalidStatusTransition Code = "INVALID_STATUS_TRANSITION"
+	CodeRefundNotAllowed        Code = "REFUND_NOT_ALLOWED"
+	CodeRateUnavailable         Code = "RATE_UNAVAILABLE"
+	CodeFundTypeMismatch        Code = "FUND_TYPE_MISMATCH"
+	CodeUploadFailed            Code = "UPLOAD_FAILED"
+	CodeInternal                Code = "INTERNAL_ERROR"
+)
+
+// codeForStatus kode default jika handler tidak memberi kode spesifik
+func codeForStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict, http.StatusGone:
+		return CodeConflict
+	case http.StatusUnprocessableEntity:
+		return CodeValidationFailed
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
+
+// Error adalah error yang dikembalikan handler; HTTPErrorHandler mengubahnya menjadi response.
+// Penyebab asli (cause) hanya dicatat di log, tidak pernah dikirim ke client.
+type Error struct {
+	Status  int
+	Code    Code
+	Message string
+	Details interface{}
+	cause   error
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.cause)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+func NewError(status int, code Code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// Fail membuat error dengan kode default sesuai status HTTP
+func Fail(status int, message string) *Error {
+	return NewError(status, codeForStatus(status), message)
+}
+
+// Wrap menyimpan error asli untuk log
+func (e *Error) Wrap(err error) *Error {
+	wrapped := *e
+	wrapped.cause = err
+	return &wrapped
+}
+
+func (e *Error) WithDetails(details interface{}) *Error {
+	detailed := *e
+	detailed.Details = details
+	return &detailed
+}
+
+// domainErrors memetakan sentinel error dari repository/service ke response
+var domainErrors []struct {
+	target error
+	err    *Error
+}
+
+// RegisterDomainError mendaftarkan sentinel error agar HTTPErrorHandler tahu status dan kodenya
+func RegisterDomainError(target error, status int, code Code, message string) {
+	domainErrors = append(domainErrors, struct {
+		target error
+		err    *Error
+	}{target, NewError(status, code, message)})
+}
+
+// From mengubah error apa pun menjadi *Error
+func From(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	var validationErrs validator.Errors
+	if errors.As(err, &validationErrs) {
+		return NewError(http.StatusUnprocessableEntity, CodeValidationFailed, "Validation failed").WithDetails(validationErrs)
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		message := http.StatusText(httpErr.Code)
+		if msg, ok := httpErr.Message.(string); ok && httpErr.Code < 500 {
+			message = msg
+		}
+		return Fail(httpErr.Code, message).Wrap(httpErr.Internal)
+	}
+
+	for _, d := range domainErrors {
+		if errors.Is(err, d.target) {
+			return d.err.Wrap(err)
+		}
+	}
+
+	return Fail(http.StatusInternalServerError, "Internal server error").Wrap(err)
+}
+
+// ==================== Response Body ====================
+
+const versionKey = "apiVersion"
+
+// Envelope dipasang di grup /api/v2 agar semua response memakai format envelope
+func Envelope(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Set(versionKey, 2)
+		return next(c)
+	}
+}
+
+func isEnvelope(c echo.Context) bool {
+	v, _ := c.Get(versionKey).(int)
+	return v >= 2
+}
+
+// RequestID dari middleware RequestID Echo
+func RequestID(c echo.Context) string {
+	if id := c.Response().Header().Get(echo.HeaderXRequestID); id != "" {
+		return id
+	}
+	return c.Request().Header.Get(echo.HeaderXRequestID)
+}
+
+// ErrorBody isi field "error" pada envelope v2
+type ErrorBody struct {
+	Code    Code        `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// EnvelopeBody format response /api/v2
+type EnvelopeBody struct {
+	Success   bool        `json:"success"`
+	Message   string      `json:"message,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+	Error     *ErrorBody  `json:"error,omitempty"`
+	RequestID string      `json:"request_id"`
+}
+
+// LegacyBody format response /api/v1; gabungan bentuk lama (code/data dan success/message)
+// agar client yang sudah ada tetap berjalan
+type LegacyBody struct {
+	Code      int         `json:"code"`
+	Success   bool        `json:"success"`
+	Message   string      `json:"message,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+	ErrorCode Code        `json:"error_code,omitempty"`
+	Errors    interface{} `json:"errors,omitempty"`
+	RequestID string      `json:"request_id"`
+}
+
+func Success(c echo.Context, status int, data interface{}) error {
+	return SuccessMessage(c, status, "", data)
+}
+
+func SuccessMessage(c echo.Context, status int, message string, data interface{}) error {
+	if isEnvelope(c) {
+		return c.JSON(status, EnvelopeBody{
+			Success:   true,
+			Message:   message,
+			Data:      data,
+			RequestID: RequestID(c),
+		})
+	}
+	return c.JSON(status, LegacyBody{
+		Code:      status,
+		Success:   true,
+		Message:   message,
+		Data:      data,
+		RequestID: RequestID(c),
+	})
+}
+
+// HTTPErrorHandler dipasang di Echo; semua error dari handler dan middleware lewat sini
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	apiErr := From(err)
+	requestID := RequestID(c)
+	if apiErr.Status >= 500 || apiErr.cause != nil && apiErr.Status != http.StatusNotFound {
+		log.Printf("❌ [%s] %s %s: %v", requestID, c.Request().Method, c.Request().URL.Path, apiErr)
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(apiErr.Status)
+	} else if isEnvelope(c) {
+		err = c.JSON(apiErr.Status, EnvelopeBody{
+			Success: false,
+			Error: &ErrorBody{
+				Code:    apiErr.Code,
+				Message: apiErr.Message,
+				Details: apiErr.Details,
+			},
+			RequestID: requestID,
+		})
+	} else {
+		err = c.JSON(apiErr.Status, LegacyBody{
+			Code:      apiErr.Status,
+			Success:   false,
+			Message:   apiErr.Message,
+			ErrorCode: apiErr.Code,
+			Errors:    apiErr.Details,
+			RequestID: requestID,
+		})
+	}
+	if err != nil {
+		log.Printf("❌ [%s] failed to write error response: %v", requestID, err)
+	}
+}
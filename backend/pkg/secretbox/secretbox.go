@@ -0,0 +1,141 @@
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// minKeyLength panjang minimal DATA_ENCRYPTION_KEY
+const minKeyLength = 32
+
+// ErrKeyMissing DATA_ENCRYPTION_KEY belum diatur atau terlalu pendek
+var ErrKeyMissing = fmt.Errorf("DATA_ENCRYPTION_KEY must be set to at least %d characters", minKeyLength)
+
+// CheckKey memastikan DATA_ENCRYPTION_KEY tersedia, dipanggil saat start agar server gagal lebih awal
+func CheckKey() error {
+	if len(os.Getenv("DATA_ENCRYPTION_KEY")) < minKeyLength {
+		return ErrKeyMissing
+	}
+	return nil
+}
+
+// dataKey DATA_ENCRYPTION_KEY, terpisah dari SECRET_KEY JWT: rotasi atau bocornya kunci JWT
+// tidak membuka data terenkripsi di database
+func dataKey() string {
+	if err := CheckKey(); err != nil {
+		panic(err.Error() + "! Please check .env")
+	}
+	return os.Getenv("DATA_ENCRYPTION_KEY")
+}
+
+func key() []byte {
+	sum := sha256.Sum256([]byte("secretbox:" + dataKey()))
+	return sum[:]
+}
+
+// legacyKey kunci lama yang diturunkan dari SECRET_KEY, hanya untuk membuka data lama
+// sampai dienkripsi ulang lewat Reseal
+func legacyKey() []byte {
+	secret := os.Getenv("SECRET_KEY")
+	if secret == "" {
+		return nil
+	}
+	sum := sha256.Sum256([]byte("secretbox:" + secret))
+	return sum[:]
+}
+
+// Seal mengenkripsi teks dengan AES-256-GCM dan mengembalikan base64
+func Seal(plaintext string) (string, error) {
+	gcm, err := newGCM(key())
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open membuka hasil Seal; data lama yang masih memakai kunci SECRET_KEY tetap bisa dibuka
+func Open(encoded string) (string, error) {
+	plaintext, _, err := open(encoded)
+	return plaintext, err
+}
+
+// Reseal membuka data dan mengenkripsinya ulang dengan DATA_ENCRYPTION_KEY jika masih memakai
+// kunci lama. changed false berarti data sudah memakai kunci baru dan tidak perlu disimpan ulang.
+func Reseal(encoded string) (resealed, plaintext string, changed bool, err error) {
+	plaintext, legacy, err := open(encoded)
+	if err != nil || !legacy {
+		return encoded, plaintext, false, err
+	}
+	resealed, err = Seal(plaintext)
+	if err != nil {
+		return encoded, plaintext, false, err
+	}
+	return resealed, plaintext, true, nil
+}
+
+func open(encoded string) (plaintext string, legacy bool, err error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", false, err
+	}
+
+	plaintext, err = openWith(key(), data)
+	if err == nil {
+		return plaintext, false, nil
+	}
+	if old := legacyKey(); old != nil {
+		if plaintext, legacyErr := openWith(old, data); legacyErr == nil {
+			return plaintext, true, nil
+		}
+	}
+	return "", false, err
+}
+
+func openWith(key, data []byte) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("secretbox: ciphertext too short")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Digest HMAC-SHA256 (hex) dari nilai, untuk mencari data terenkripsi tanpa membukanya
+// (mis. deduplikasi NIK). Hasilnya sama untuk nilai yang sama selama DATA_ENCRYPTION_KEY tidak berubah.
+func Digest(value string) string {
+	mac := hmac.New(sha256.New, []byte("digest:"+dataKey()))
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,212 @@
+package throttle
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Record menyimpan jumlah kegagalan untuk satu key (mis. akun atau IP)
+type Record struct {
+	Key         string
+	Failures    int
+	WindowStart time.Time
+	LockedUntil time.Time
+}
+
+// Store adalah penyimpanan Record. Tersedia MemoryStore, dan versi database
+// di repositories.NewThrottleRepository. Increment dan Lock harus atomik terhadap
+// pemanggilan bersamaan (juga dari instance lain) agar tidak ada kegagalan yang hilang.
+type Store interface {
+	Get(key string) (*Record, error) // nil jika belum ada
+	// Increment menambah satu kegagalan dan mengembalikan Record sesudahnya. Hitungan dimulai
+	// ulang dari 1 jika window sudah lewat (WindowStart sebelum now-window) dan key tidak sedang dikunci.
+	Increment(key string, now time.Time, window time.Duration) (*Record, error)
+	// Lock memperpanjang LockedUntil; kunci yang lebih lama tidak pernah diperpendek
+	Lock(key string, until time.Time) error
+	Delete(key string) error
+}
+
+// Policy mengatur kapan sebuah key mulai diperlambat dan dikunci
+type Policy struct {
+	// Window: kegagalan yang lebih lama dari ini dihitung ulang dari nol
+	Window time.Duration
+	// FreeAttempts: jumlah kegagalan sebelum jeda progresif mulai berlaku
+	FreeAttempts int
+	// BaseDelay: jeda setelah kegagalan pertama di atas FreeAttempts, lalu berlipat dua
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// MaxAttempts: jumlah kegagalan yang memicu lockout
+	MaxAttempts int
+	Lockout     time.Duration
+}
+
+// Limiter menerapkan Policy per scope ke Store
+type Limiter struct {
+	store    Store
+	policies map[string]Policy
+	now      func() time.Time
+}
+
+func NewLimiter(store Store, policies map[string]Policy) *Limiter {
+	return &Limiter{
+		store:    store,
+		policies: policies,
+		now:      time.Now,
+	}
+}
+
+func storeKey(scope, key string) string {
+	return scope + ":" + key
+}
+
+// Check mengembalikan sisa waktu tunggu jika key sedang diblokir, atau 0 jika boleh lanjut
+func (l *Limiter) Check(scope, key string) (time.Duration, error) {
+	record, err := l.store.Get(storeKey(scope, key))
+	if err != nil || record == nil {
+		return 0, err
+	}
+	if wait := record.LockedUntil.Sub(l.now()); wait > 0 {
+		return wait, nil
+	}
+	return 0, nil
+}
+
+// Fail mencatat satu kegagalan dan mengembalikan waktu tunggu yang berlaku setelahnya
+func (l *Limiter) Fail(scope, key string) (time.Duration, error) {
+	policy, ok := l.policies[scope]
+	if !ok {
+		return 0, fmt.Errorf("throttle: unknown scope %q", scope)
+	}
+
+	now := l.now()
+	k := storeKey(scope, key)
+
+	record, err := l.store.Increment(k, now, policy.Window)
+	if err != nil {
+		return 0, err
+	}
+
+	var lockedUntil time.Time
+	switch {
+	case policy.MaxAttempts > 0 && record.Failures >= policy.MaxAttempts:
+		lockedUntil = now.Add(policy.Lockout)
+	case record.Failures > policy.FreeAttempts:
+		lockedUntil = now.Add(progressiveDelay(policy, record.Failures-policy.FreeAttempts))
+	}
+	if lockedUntil.After(record.LockedUntil) {
+		if err := l.store.Lock(k, lockedUntil); err != nil {
+			return 0, err
+		}
+		record.LockedUntil = lockedUntil
+	}
+
+	if wait := record.LockedUntil.Sub(now); wait > 0 {
+		return wait, nil
+	}
+	return 0, nil
+}
+
+// Reset menghapus catatan kegagalan, dipanggil setelah percobaan berhasil
+func (l *Limiter) Reset(scope, key string) error {
+	return l.store.Delete(storeKey(scope, key))
+}
+
+// progressiveDelay: BaseDelay, 2x, 4x, ... dibatasi MaxDelay
+func progressiveDelay(policy Policy, step int) time.Duration {
+	delay := policy.BaseDelay
+	for i := 1; i < step && delay < policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+	return delay
+}
+
+// ==================== Memory Store ====================
+
+// MemoryStore menyimpan Record di memori proses. Cocok untuk satu instance server.
+// Record yang window dan lockout-nya sudah lewat dibuang berkala agar key buatan penyerang
+// (username/IP acak) tidak membuat map terus membesar.
+type MemoryStore struct {
+	mu        sync.Mutex
+	records   map[string]Record
+	retention time.Duration
+	lastPrune time.Time
+}
+
+// NewMemoryStore retention minimal sepanjang Window terpanjang dari semua Policy yang memakai store ini
+// (lihat LongestWindow)
+func NewMemoryStore(retention time.Duration) *MemoryStore {
+	return &MemoryStore{records: make(map[string]Record), retention: retention}
+}
+
+// LongestWindow Window terpanjang dari beberapa Policy
+func LongestWindow(policies map[string]Policy) time.Duration {
+	var longest time.Duration
+	for _, policy := range policies {
+		if policy.Window > longest {
+			longest = policy.Window
+		}
+	}
+	return longest
+}
+
+func (s *MemoryStore) Get(key string) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[key]
+	if !ok {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+func (s *MemoryStore) Increment(key string, now time.Time, window time.Duration) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(now)
+	record, ok := s.records[key]
+	if !ok || (record.WindowStart.Before(now.Add(-window)) && !record.LockedUntil.After(now)) {
+		record = Record{Key: key, WindowStart: now}
+	}
+	record.Failures++
+	s.records[key] = record
+	return &record, nil
+}
+
+func (s *MemoryStore) Lock(key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.records[key]; ok && until.After(record.LockedUntil) {
+		record.LockedUntil = until
+		s.records[key] = record
+	}
+	return nil
+}
+
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+// prune membuang Record yang window dan lockout-nya sudah lewat; paling sering sekali per menit
+func (s *MemoryStore) prune(now time.Time) {
+	if now.Sub(s.lastPrune) < time.Minute {
+		return
+	}
+	s.lastPrune = now
+	cutoff := now.Add(-s.retention)
+	for key, record := range s.records {
+		if record.WindowStart.Before(cutoff) && !record.LockedUntil.After(now) {
+			delete(s.records, key)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// Secret ASCII "12345678901234567890" dari lampiran B RFC 6238 (SHA1), dalam base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// Vektor uji RFC 6238 dipotong ke 6 digit terakhir, sesuai Digits
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestCodeRFC6238(t *testing.T) {
+	for _, v := range rfcVectors {
+		code, err := Code(rfcSecret, time.Unix(v.unix, 0))
+		if err != nil {
+			t.Fatalf("Code(%d): %v", v.unix, err)
+		}
+		if code != v.code {
+			t.Errorf("Code(%d) = %s, want %s", v.unix, code, v.code)
+		}
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	counter := uint64(now.Unix() / int64(Period.Seconds()))
+
+	for _, offset := range []int{-Skew, 0, Skew} {
+		at := now.Add(time.Duration(offset) * Period)
+		code, err := Code(rfcSecret, at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := Validate(rfcSecret, code, now)
+		if !ok {
+			t.Errorf("offset %d: code %s rejected", offset, code)
+		}
+		if want := counter + uint64(offset); got != want {
+			t.Errorf("offset %d: counter = %d, want %d", offset, got, want)
+		}
+	}
+
+	stale, _ := Code(rfcSecret, now.Add(-time.Duration(Skew+1)*Period))
+	if _, ok := Validate(rfcSecret, stale, now); ok {
+		t.Errorf("code outside skew accepted")
+	}
+}
+
+func TestValidateRejectsMalformed(t *testing.T) {
+	now := time.Unix(59, 0)
+	for _, code := range []string{"", "28708", "2870820", "94287082"} {
+		if _, ok := Validate(rfcSecret, code, now); ok {
+			t.Errorf("Validate(%q) accepted", code)
+		}
+	}
+	if _, ok := Validate("not base32!", "287082", now); ok {
+		t.Errorf("invalid secret accepted")
+	}
+}
+
+func TestGenerateSecretRoundTrip(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	code, err := Code(secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Validate(secret, code, now); !ok {
+		t.Errorf("generated secret does not validate its own code")
+	}
+}
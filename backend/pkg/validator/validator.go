@@ -0,0 +1,168 @@
+package validator
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"unicode"
+	"zakat/pkg/phone"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	idTranslations "github.com/go-playground/validator/v10/translations/id"
+)
+
+// FieldError satu kesalahan validasi, pesannya tersedia dalam bahasa Indonesia dan Inggris
+type FieldError struct {
+	Field     string `json:"field"`
+	Rule      string `json:"rule"`
+	Param     string `json:"param,omitempty"`
+	MessageID string `json:"message_id"`
+	MessageEN string `json:"message_en"`
+}
+
+// Errors dikembalikan Validate jika ada field yang tidak valid
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		messages[i] = fe.MessageEN
+	}
+	return strings.Join(messages, "; ")
+}
+
+// CustomValidator mengimplementasikan echo.Validator
+type CustomValidator struct {
+	validate *validator.Validate
+	id       ut.Translator
+	en       ut.Translator
+}
+
+// Rule tambahan di luar bawaan go-playground/validator
+var customRules = map[string]validator.Func{
+	"containsuppercase": func(fl validator.FieldLevel) bool {
+		return strings.IndexFunc(fl.Field().String(), unicode.IsUpper) >= 0
+	},
+	"containsnumber": func(fl validator.FieldLevel) bool {
+		return strings.IndexFunc(fl.Field().String(), unicode.IsDigit) >= 0
+	},
+	// phone menerima format lokal (08xx) maupun E.164, sesuai pkg/phone
+	"phone": func(fl validator.FieldLevel) bool {
+		_, err := phone.Normalize(fl.Field().String())
+		return err == nil
+	},
+	// rupiah nominal tanpa pecahan; payment gateway hanya menagih rupiah bulat
+	"rupiah": func(fl validator.FieldLevel) bool {
+		v := fl.Field().Float()
+		return v == math.Trunc(v)
+	},
+}
+
+// Terjemahan untuk rule yang belum ada di paket translations; {0} nama field, {1} parameter
+var extraTranslations = map[string]struct{ id, en string }{
+	"containsuppercase": {"{0} harus mengandung minimal satu huruf besar", "{0} must contain at least one uppercase letter"},
+	"containsnumber":    {"{0} harus mengandung minimal satu angka", "{0} must contain at least one number"},
+	"phone":             {"{0} harus berupa nomor HP yang valid", "{0} must be a valid phone number"},
+	"rupiah":            {"{0} harus berupa nominal rupiah bulat", "{0} must be a whole rupiah amount"},
+	"e164":              {"{0} harus berupa nomor HP format E.164", "{0} must be a valid E.164 formatted phone number"},
+	"required_without":  {"{0} wajib diisi jika {1} kosong", "{0} is required when {1} is empty"},
+}
+
+func New() *CustomValidator {
+	v := validator.New()
+
+	// Nama field di pesan error mengikuti tag json
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	for tag, fn := range customRules {
+		if err := v.RegisterValidation(tag, fn); err != nil {
+			panic(fmt.Sprintf("validator: register %s: %v", tag, err))
+		}
+	}
+
+	uni := ut.New(en.New(), en.New(), id.New())
+	idTrans, _ := uni.GetTranslator("id")
+	enTrans, _ := uni.GetTranslator("en")
+	if err := idTranslations.RegisterDefaultTranslations(v, idTrans); err != nil {
+		panic(fmt.Sprintf("validator: id translations: %v", err))
+	}
+	if err := enTranslations.RegisterDefaultTranslations(v, enTrans); err != nil {
+		panic(fmt.Sprintf("validator: en translations: %v", err))
+	}
+
+	for tag, msg := range extraTranslations {
+		registerTranslation(v, idTrans, tag, msg.id)
+		registerTranslation(v, enTrans, tag, msg.en)
+	}
+
+	return &CustomValidator{validate: v, id: idTrans, en: enTrans}
+}
+
+func registerTranslation(v *validator.Validate, trans ut.Translator, tag, text string) {
+	err := v.RegisterTranslation(tag, trans,
+		func(ut ut.Translator) error {
+			return ut.Add(tag, text, true)
+		},
+		func(ut ut.Translator, fe validator.FieldError) string {
+			msg, err := ut.T(tag, fe.Field(), fe.Param())
+			if err != nil {
+				return fe.Error()
+			}
+			return msg
+		},
+	)
+	if err != nil {
+		panic(fmt.Sprintf("validator: register translation %s: %v", tag, err))
+	}
+}
+
+// Validate menjalankan tag `validate` pada struct dan mengembalikan Errors jika ada yang gagal
+func (cv *CustomValidator) Validate(i interface{}) error {
+	err := cv.validate.Struct(i)
+	if err == nil {
+		return nil
+	}
+
+	validationErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return err
+	}
+
+	result := make(Errors, len(validationErrors))
+	for i, fe := range validationErrors {
+		result[i] = FieldError{
+			Field:     fe.Field(),
+			Rule:      fe.Tag(),
+			Param:     fe.Param(),
+			MessageID: translate(fe, cv.id),
+			MessageEN: translate(fe, cv.en),
+		}
+	}
+	return result
+}
+
+// translate jatuh ke pesan umum jika rule belum punya terjemahan
+func translate(fe validator.FieldError, trans ut.Translator) string {
+	msg := fe.Translate(trans)
+	if msg != fe.Error() {
+		return msg
+	}
+	if trans.Locale() == "id" {
+		return fmt.Sprintf("%s tidak valid", fe.Field())
+	}
+	return fmt.Sprintf("%s is invalid", fe.Field())
+}
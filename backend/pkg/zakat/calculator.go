@@ -0,0 +1,283 @@
+package zakat
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Calculator menghitung zakat dengan ambang nisab dan madzhab default dari Config
+type Calculator struct {
+	config Config
+}
+
+func NewCalculator(config Config) *Calculator {
+	if config.NisabGoldGrams <= 0 {
+		config.NisabGoldGrams = DefaultNisabGoldGrams
+	}
+	if config.NisabSilverGrams <= 0 {
+		config.NisabSilverGrams = DefaultNisabSilverGrams
+	}
+	if config.FitrahRiceKg <= 0 {
+		config.FitrahRiceKg = DefaultFitrahRiceKg
+	}
+	if config.DefaultMadhhab == "" {
+		config.DefaultMadhhab = MadhhabStandard
+	}
+	return &Calculator{config: config}
+}
+
+// Config pengaturan yang dipakai setelah default diterapkan
+func (c *Calculator) Config() Config {
+	return c.config
+}
+
+// Nisab nilai nisab setahun dalam rupiah untuk basis emas/perak
+func (c *Calculator) Nisab(basis string, rates Rates) (float64, error) {
+	switch basis {
+	case NisabBasisGold:
+		if rates.GoldPerGram <= 0 {
+			return 0, fmt.Errorf("%w: gold price", ErrMissingRate)
+		}
+		return c.config.NisabGoldGrams * rates.GoldPerGram, nil
+	case NisabBasisSilver:
+		if rates.SilverPerGram <= 0 {
+			return 0, fmt.Errorf("%w: silver price", ErrMissingRate)
+		}
+		return c.config.NisabSilverGrams * rates.SilverPerGram, nil
+	}
+	return 0, fmt.Errorf("zakat: unknown nisab basis %q", basis)
+}
+
+// FitrahPerPerson nominal fitrah per jiwa: tarif resmi jika ada, selain itu harga beras × takaran
+func (c *Calculator) FitrahPerPerson(rates Rates) (float64, error) {
+	if rates.FitrahPerPerson > 0 {
+		return rates.FitrahPerPerson, nil
+	}
+	if rates.RicePerKg <= 0 {
+		return 0, fmt.Errorf("%w: rice price", ErrMissingRate)
+	}
+	return roundUp(rates.RicePerKg * c.config.FitrahRiceKg), nil
+}
+
+// Calculate menghitung zakat sesuai req.Type
+func (c *Calculator) Calculate(req Request, rates Rates) (*Result, error) {
+	madhhab := req.Madhhab
+	if madhhab == "" {
+		madhhab = c.config.DefaultMadhhab
+	}
+	r, ok := madhhabRules[madhhab]
+	if !ok {
+		return nil, ErrUnknownMadhhab
+	}
+
+	on := req.On
+	if on.IsZero() {
+		on = time.Now()
+	}
+	result := &Result{Type: req.Type, Madhhab: madhhab, On: on}
+
+	var err error
+	switch {
+	case req.Type == TypeMaal && req.Maal != nil:
+		err = c.maal(result, r, req, rates)
+	case req.Type == TypeIncome && req.Income != nil:
+		err = c.income(result, r, req, rates)
+	case req.Type == TypeTrade && req.Trade != nil:
+		err = c.trade(result, r, req, rates)
+	case req.Type == TypeFitrah && req.Fitrah != nil:
+		err = c.fitrah(result, r, req, rates)
+	default:
+		return nil, ErrUnknownType
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Calculator) maal(result *Result, r rules, req Request, rates Rates) error {
+	in := req.Maal
+	if err := c.setNisab(result, r, req.NisabBasis, rates); err != nil {
+		return err
+	}
+
+	result.add("Tabungan, deposito dan uang tunai", in.Savings)
+	if in.GoldGrams > 0 {
+		if rates.GoldPerGram <= 0 {
+			return fmt.Errorf("%w: gold price", ErrMissingRate)
+		}
+		result.add(fmt.Sprintf("Emas simpanan (%g g)", in.GoldGrams), in.GoldGrams*rates.GoldPerGram)
+	}
+	if in.GoldJewelryGrams > 0 {
+		if r.jewelryZakatable {
+			if rates.GoldPerGram <= 0 {
+				return fmt.Errorf("%w: gold price", ErrMissingRate)
+			}
+			result.add(fmt.Sprintf("Perhiasan emas dipakai (%g g)", in.GoldJewelryGrams), in.GoldJewelryGrams*rates.GoldPerGram)
+		} else {
+			result.note("Perhiasan emas yang dipakai wajar tidak dizakati menurut madzhab ini")
+		}
+	}
+	if in.SilverGrams > 0 {
+		if rates.SilverPerGram <= 0 {
+			return fmt.Errorf("%w: silver price", ErrMissingRate)
+		}
+		result.add(fmt.Sprintf("Perak (%g g)", in.SilverGrams), in.SilverGrams*rates.SilverPerGram)
+	}
+	result.add("Saham, reksa dana dan surat berharga", in.Stocks)
+	result.add("Piutang yang diharapkan kembali", in.Receivables)
+	c.deductDebts(result, r, "Utang jatuh tempo", in.Debts)
+
+	result.Haul = haul(req.HaulStart, result.On)
+	result.finish(Rate)
+	return nil
+}
+
+func (c *Calculator) income(result *Result, r rules, req Request, rates Rates) error {
+	in := req.Income
+	if err := c.setNisab(result, r, req.NisabBasis, rates); err != nil {
+		return err
+	}
+	switch in.Period {
+	case PeriodMonthly, "":
+		// Nisab setahun dibagi rata per bulan
+		result.Nisab /= 12
+		result.note("Zakat penghasilan bulanan: nisab setahun dibagi 12, dibayar saat penghasilan diterima")
+	case PeriodAnnual:
+	default:
+		return fmt.Errorf("zakat: unknown income period %q", in.Period)
+	}
+
+	result.add("Penghasilan", in.Income)
+	result.add("Penghasilan lain", in.OtherIncome)
+	if in.Net {
+		result.add("Kebutuhan pokok", -in.BasicNeeds)
+		result.add("Cicilan utang", -in.Debts)
+	} else {
+		result.note("Dihitung dari penghasilan bruto")
+	}
+
+	result.finish(Rate)
+	return nil
+}
+
+func (c *Calculator) trade(result *Result, r rules, req Request, rates Rates) error {
+	in := req.Trade
+	if err := c.setNisab(result, r, req.NisabBasis, rates); err != nil {
+		return err
+	}
+
+	result.add("Barang dagangan (harga pasar)", in.Inventory)
+	result.add("Kas dan saldo usaha", in.Cash)
+	result.add("Piutang dagang lancar", in.Receivables)
+	c.deductDebts(result, r, "Utang dagang jatuh tempo", in.Debts)
+
+	result.Haul = haul(req.HaulStart, result.On)
+	result.finish(Rate)
+	return nil
+}
+
+func (c *Calculator) fitrah(result *Result, r rules, req Request, rates Rates) error {
+	people := req.Fitrah.People
+	if people < 1 {
+		return fmt.Errorf("zakat: fitrah requires at least one person")
+	}
+	perPerson, err := c.FitrahPerPerson(rates)
+	if err != nil {
+		return err
+	}
+
+	result.Fitrah = &Fitrah{
+		People:        people,
+		RiceKg:        c.config.FitrahRiceKg * float64(people),
+		CashPerPerson: perPerson,
+		PayInKind:     !r.fitrahCash,
+	}
+	result.add(fmt.Sprintf("Fitrah %d jiwa × %.0f", people, perPerson), perPerson*float64(people))
+	if !r.fitrahCash {
+		result.note("Madzhab ini mensyaratkan fitrah berupa makanan pokok; amil membelikan beras senilai nominal ini")
+	}
+
+	result.Wealth = result.sum()
+	result.AboveNisab = true
+	result.Estimated = roundUp(result.Wealth)
+	result.Zakat = result.Estimated
+	return nil
+}
+
+func (c *Calculator) setNisab(result *Result, r rules, basis string, rates Rates) error {
+	if basis == "" {
+		basis = r.nisabBasis
+	}
+	nisab, err := c.Nisab(basis, rates)
+	if err != nil {
+		return err
+	}
+	result.NisabBasis, result.Nisab = basis, nisab
+	return nil
+}
+
+func (c *Calculator) deductDebts(result *Result, r rules, label string, debts float64) {
+	if debts <= 0 {
+		return
+	}
+	if r.deductDebts {
+		result.add(label, -debts)
+		return
+	}
+	result.note("Utang tidak mengurangi harta wajib zakat menurut madzhab ini")
+}
+
+// haul menentukan apakah harta sudah dimiliki satu tahun hijriah pada tanggal on
+func haul(start *time.Time, on time.Time) *Haul {
+	if start == nil {
+		return &Haul{Completed: true, Assumed: true}
+	}
+	due := start.AddDate(0, 0, HaulDays)
+	h := &Haul{StartedAt: start, DueAt: &due}
+	if !on.Before(due) {
+		h.Completed = true
+	} else {
+		h.DaysRemaining = int(math.Ceil(due.Sub(on).Hours() / 24))
+	}
+	return h
+}
+
+func (r *Result) add(label string, amount float64) {
+	if amount == 0 {
+		return
+	}
+	r.Lines = append(r.Lines, Line{Label: label, Amount: amount})
+}
+
+func (r *Result) note(note string) {
+	r.Notes = append(r.Notes, note)
+}
+
+func (r *Result) sum() float64 {
+	var total float64
+	for _, line := range r.Lines {
+		total += line.Amount
+	}
+	return total
+}
+
+// finish menjumlahkan rincian lalu menerapkan nisab dan haul
+func (r *Result) finish(rate float64) {
+	r.Wealth = r.sum()
+	r.Rate = rate
+	r.AboveNisab = r.Nisab > 0 && r.Wealth >= r.Nisab
+	if !r.AboveNisab {
+		return
+	}
+	r.Estimated = roundUp(r.Wealth * rate)
+	if r.Haul == nil || r.Haul.Completed {
+		r.Zakat = r.Estimated
+	}
+}
+
+// roundUp membulatkan ke atas ke rupiah penuh, setelah membuang galat floating point
+func roundUp(amount float64) float64 {
+	return math.Ceil(math.Round(amount*100) / 100)
+}
@@ -0,0 +1,171 @@
+package zakat
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// Harga uji: nisab emas 85 g × 1.000.000 = 85.000.000, nisab perak 595 g × 10.000 = 5.950.000
+var testRates = Rates{GoldPerGram: 1000000, SilverPerGram: 10000, RicePerKg: 15000}
+
+var testOn = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func TestCalculateMaal(t *testing.T) {
+	haulStart := testOn.AddDate(0, 0, -100)
+
+	cases := []struct {
+		name       string
+		madhhab    string
+		haulStart  *time.Time
+		maal       MaalInput
+		nisab      float64
+		wealth     float64
+		aboveNisab bool
+		estimated  float64
+		zakat      float64
+	}{
+		{"di atas nisab", MadhhabStandard, nil, MaalInput{Savings: 100000000},
+			85000000, 100000000, true, 2500000, 2500000},
+		{"di bawah nisab", MadhhabStandard, nil, MaalInput{Savings: 80000000},
+			85000000, 80000000, false, 0, 0},
+		{"tepat nisab", MadhhabStandard, nil, MaalInput{Savings: 85000000},
+			85000000, 85000000, true, 2125000, 2125000},
+		{"dibulatkan ke atas", MadhhabStandard, nil, MaalInput{Savings: 85000001},
+			85000000, 85000001, true, 2125001, 2125001},
+		{"utang mengurangi harta", MadhhabStandard, nil, MaalInput{Savings: 100000000, Debts: 20000000},
+			85000000, 80000000, false, 0, 0},
+		{"syafii utang tidak dikurangkan", MadhhabShafii, nil, MaalInput{Savings: 100000000, Debts: 20000000},
+			85000000, 100000000, true, 2500000, 2500000},
+		{"haul belum lewat", MadhhabStandard, &haulStart, MaalInput{Savings: 100000000},
+			85000000, 100000000, true, 2500000, 0},
+		{"emas simpanan", MadhhabStandard, nil, MaalInput{Savings: 10000000, GoldGrams: 80},
+			85000000, 90000000, true, 2250000, 2250000},
+		{"perhiasan tidak dizakati", MadhhabStandard, nil, MaalInput{Savings: 3000000, GoldJewelryGrams: 5},
+			85000000, 3000000, false, 0, 0},
+		{"hanafi perhiasan dan nisab perak", MadhhabHanafi, nil, MaalInput{Savings: 3000000, GoldJewelryGrams: 5},
+			5950000, 8000000, true, 200000, 200000},
+		{"hanafi di bawah nisab perak", MadhhabHanafi, nil, MaalInput{Savings: 5000000},
+			5950000, 5000000, false, 0, 0},
+	}
+
+	calc := NewCalculator(Config{})
+	for _, tc := range cases {
+		maal := tc.maal
+		result, err := calc.Calculate(Request{Type: TypeMaal, Madhhab: tc.madhhab, HaulStart: tc.haulStart, On: testOn, Maal: &maal}, testRates)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if result.Nisab != tc.nisab || result.Wealth != tc.wealth || result.AboveNisab != tc.aboveNisab {
+			t.Errorf("%s: nisab %.2f wealth %.2f above %v, want %.2f %.2f %v",
+				tc.name, result.Nisab, result.Wealth, result.AboveNisab, tc.nisab, tc.wealth, tc.aboveNisab)
+		}
+		if result.Estimated != tc.estimated || result.Zakat != tc.zakat {
+			t.Errorf("%s: estimated %.2f zakat %.2f, want %.2f %.2f", tc.name, result.Estimated, result.Zakat, tc.estimated, tc.zakat)
+		}
+	}
+}
+
+func TestCalculateMaalHaul(t *testing.T) {
+	calc := NewCalculator(Config{})
+
+	start := testOn.AddDate(0, 0, -100)
+	result, err := calc.Calculate(Request{Type: TypeMaal, HaulStart: &start, On: testOn, Maal: &MaalInput{Savings: 100000000}}, testRates)
+	if err != nil {
+		t.Fatalf("Calculate: %v", err)
+	}
+	if result.Haul.Completed || result.Haul.DaysRemaining != HaulDays-100 {
+		t.Errorf("haul completed %v remaining %d, want false %d", result.Haul.Completed, result.Haul.DaysRemaining, HaulDays-100)
+	}
+
+	start = testOn.AddDate(0, 0, -HaulDays)
+	result, err = calc.Calculate(Request{Type: TypeMaal, HaulStart: &start, On: testOn, Maal: &MaalInput{Savings: 100000000}}, testRates)
+	if err != nil {
+		t.Fatalf("Calculate: %v", err)
+	}
+	if !result.Haul.Completed || result.Zakat != 2500000 {
+		t.Errorf("haul completed %v zakat %.2f, want true 2500000", result.Haul.Completed, result.Zakat)
+	}
+}
+
+func TestCalculateIncome(t *testing.T) {
+	cases := []struct {
+		name       string
+		income     IncomeInput
+		nisab      float64
+		aboveNisab bool
+		zakat      float64
+	}{
+		// Nisab bulanan 85.000.000 / 12
+		{"bulanan di atas nisab", IncomeInput{Period: PeriodMonthly, Income: 10000000}, 85000000.0 / 12, true, 250000},
+		{"bulanan di bawah nisab", IncomeInput{Period: PeriodMonthly, Income: 7000000}, 85000000.0 / 12, false, 0},
+		{"bulanan neto", IncomeInput{Income: 10000000, Net: true, BasicNeeds: 2000000, Debts: 1000000}, 85000000.0 / 12, false, 0},
+		{"tahunan", IncomeInput{Period: PeriodAnnual, Income: 120000000}, 85000000, true, 3000000},
+	}
+
+	calc := NewCalculator(Config{})
+	for _, tc := range cases {
+		income := tc.income
+		result, err := calc.Calculate(Request{Type: TypeIncome, On: testOn, Income: &income}, testRates)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if result.Nisab != tc.nisab || result.AboveNisab != tc.aboveNisab || result.Zakat != tc.zakat {
+			t.Errorf("%s: nisab %.2f above %v zakat %.2f, want %.2f %v %.2f",
+				tc.name, result.Nisab, result.AboveNisab, result.Zakat, tc.nisab, tc.aboveNisab, tc.zakat)
+		}
+	}
+}
+
+func TestCalculateFitrah(t *testing.T) {
+	cases := []struct {
+		name      string
+		madhhab   string
+		rates     Rates
+		perPerson float64
+		zakat     float64
+		payInKind bool
+	}{
+		{"tunai dari harga beras", MadhhabStandard, testRates, 37500, 150000, false},
+		{"tarif resmi per jiwa", MadhhabStandard, Rates{FitrahPerPerson: 45000}, 45000, 180000, false},
+		{"syafii dibayar beras", MadhhabShafii, testRates, 37500, 150000, true},
+		{"harga beras pecahan dibulatkan", MadhhabStandard, Rates{RicePerKg: 14999.5}, 37499, 149996, false},
+	}
+
+	calc := NewCalculator(Config{})
+	for _, tc := range cases {
+		result, err := calc.Calculate(Request{Type: TypeFitrah, Madhhab: tc.madhhab, On: testOn, Fitrah: &FitrahInput{People: 4}}, tc.rates)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if result.Fitrah.CashPerPerson != tc.perPerson || result.Zakat != tc.zakat || result.Fitrah.PayInKind != tc.payInKind {
+			t.Errorf("%s: per person %.2f zakat %.2f in kind %v, want %.2f %.2f %v",
+				tc.name, result.Fitrah.CashPerPerson, result.Zakat, result.Fitrah.PayInKind, tc.perPerson, tc.zakat, tc.payInKind)
+		}
+		if result.Fitrah.RiceKg != 10 {
+			t.Errorf("%s: rice %.2f kg, want 10", tc.name, result.Fitrah.RiceKg)
+		}
+	}
+}
+
+func TestCalculateMissingRate(t *testing.T) {
+	cases := []struct {
+		name  string
+		req   Request
+		rates Rates
+	}{
+		{"nisab emas", Request{Type: TypeMaal, Maal: &MaalInput{Savings: 1}}, Rates{SilverPerGram: 10000}},
+		{"nisab perak hanafi", Request{Type: TypeMaal, Madhhab: MadhhabHanafi, Maal: &MaalInput{Savings: 1}}, Rates{GoldPerGram: 1000000}},
+		{"perak simpanan", Request{Type: TypeMaal, Maal: &MaalInput{SilverGrams: 100}}, Rates{GoldPerGram: 1000000}},
+		{"penghasilan", Request{Type: TypeIncome, Income: &IncomeInput{Income: 1}}, Rates{}},
+		{"fitrah", Request{Type: TypeFitrah, Fitrah: &FitrahInput{People: 1}}, Rates{}},
+	}
+
+	calc := NewCalculator(Config{})
+	for _, tc := range cases {
+		tc.req.On = testOn
+		if _, err := calc.Calculate(tc.req, tc.rates); !errors.Is(err, ErrMissingRate) {
+			t.Errorf("%s: err %v, want ErrMissingRate", tc.name, err)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// Package zakat menghitung kewajiban zakat maal, penghasilan, perdagangan dan fitrah.
+// Harga emas, perak dan beras tidak di-hardcode: pemanggil menyediakan Rates yang berlaku
+// pada tanggal perhitungan, sedangkan ambang nisab dan aturan madzhab diatur lewat Config.
+package zakat
+
+import (
+	"errors"
+	"time"
+)
+
+// Jenis zakat yang bisa dihitung
+const (
+	TypeMaal         = "maal"
+	TypeIncome       = "penghasilan"
+	TypeTrade        = "perdagangan"
+	TypeFitrah       = "fitrah"
+	PeriodMonthly    = "monthly"
+	PeriodAnnual     = "annual"
+	NisabBasisGold   = "gold"
+	NisabBasisSilver = "silver"
+)
+
+// Madzhab yang didukung; MadhhabStandard mengikuti praktik BAZNAS (nisab emas, utang jatuh tempo
+// dikurangkan, fitrah boleh dibayar tunai)
+const (
+	MadhhabStandard = "standard"
+	MadhhabShafii   = "shafii"
+	MadhhabHanafi   = "hanafi"
+	MadhhabMaliki   = "maliki"
+	MadhhabHanbali  = "hanbali"
+)
+
+const (
+	// Rate kadar zakat maal, penghasilan dan perdagangan (2,5%)
+	Rate = 0.025
+	// HaulDays satu tahun hijriah
+	HaulDays = 354
+
+	DefaultNisabGoldGrams   = 85.0
+	DefaultNisabSilverGrams = 595.0
+	DefaultFitrahRiceKg     = 2.5
+)
+
+var (
+	// ErrUnknownType jenis zakat tidak dikenal atau inputnya tidak diisi
+	ErrUnknownType = errors.New("zakat: unknown zakat type")
+	// ErrUnknownMadhhab madzhab tidak dikenal
+	ErrUnknownMadhhab = errors.New("zakat: unknown madhhab")
+	// ErrMissingRate harga referensi yang dibutuhkan perhitungan belum tersedia
+	ErrMissingRate = errors.New("zakat: reference rate is not available")
+)
+
+// Rates harga referensi yang berlaku saat perhitungan
+type Rates struct {
+	GoldPerGram   float64 `json:"gold_per_gram"`
+	SilverPerGram float64 `json:"silver_per_gram"`
+	RicePerKg     float64 `json:"rice_per_kg"`
+	// FitrahPerPerson nominal fitrah resmi per jiwa; jika 0 dihitung dari RicePerKg × FitrahRiceKg
+	FitrahPerPerson float64 `json:"fitrah_per_person"`
+}
+
+// Config ambang nisab dan madzhab default; nilai nol memakai standar di atas
+type Config struct {
+	NisabGoldGrams   float64
+	NisabSilverGrams float64
+	FitrahRiceKg     float64
+	DefaultMadhhab   string
+}
+
+// rules perbedaan pendapat yang memengaruhi hasil hitung
+type rules struct {
+	nisabBasis       string
+	deductDebts      bool // utang jatuh tempo mengurangi harta wajib zakat
+	jewelryZakatable bool // perhiasan emas yang dipakai ikut dihitung
+	fitrahCash       bool // fitrah boleh dibayar dengan uang (qimah)
+}
+
+var madhhabRules = map[string]rules{
+	MadhhabStandard: {nisabBasis: NisabBasisGold, deductDebts: true, fitrahCash: true},
+	MadhhabShafii:   {nisabBasis: NisabBasisGold},
+	// Hanafi: nisab perak (lebih rendah, lebih menguntungkan mustahik) dan perhiasan tetap dizakati
+	MadhhabHanafi:  {nisabBasis: NisabBasisSilver, deductDebts: true, jewelryZakatable: true, fitrahCash: true},
+	MadhhabMaliki:  {nisabBasis: NisabBasisGold, deductDebts: true},
+	MadhhabHanbali: {nisabBasis: NisabBasisGold, deductDebts: true},
+}
+
+// IsValidMadhhab mengecek nama madzhab; string kosong berarti default Config
+func IsValidMadhhab(madhhab string) bool {
+	if madhhab == "" {
+		return true
+	}
+	_, ok := madhhabRules[madhhab]
+	return ok
+}
+
+// Request data satu perhitungan; hanya input sesuai Type yang dipakai
+type Request struct {
+	Type    string
+	Madhhab string
+	// NisabBasis "gold"/"silver"; kosong mengikuti madzhab
+	NisabBasis string
+	// HaulStart tanggal harta pertama kali mencapai nisab; nil berarti donatur menyatakan haul sudah lewat
+	HaulStart *time.Time
+	// On tanggal perhitungan; nol berarti sekarang
+	On time.Time
+
+	Maal   *MaalInput
+	Income *IncomeInput
+	Trade  *TradeInput
+	Fitrah *FitrahInput
+}
+
+// MaalInput harta simpanan; nilai dalam rupiah kecuali yang bersatuan gram
+type MaalInput struct {
+	Savings          float64 // tabungan, deposito, uang tunai
+	GoldGrams        float64 // emas simpanan/investasi
+	GoldJewelryGrams float64 // perhiasan emas yang dipakai
+	SilverGrams      float64
+	Stocks           float64 // nilai pasar saham, reksa dana, sukuk
+	Receivables      float64 // piutang yang diharapkan kembali
+	Debts            float64 // utang jatuh tempo
+}
+
+// IncomeInput penghasilan per bulan atau per tahun; dizakati saat diterima tanpa haul
+type IncomeInput struct {
+	Period      string
+	Income      float64 // gaji, honor, upah
+	OtherIncome float64
+	// Net: dihitung setelah kebutuhan pokok dan cicilan; default dari penghasilan bruto
+	Net        bool
+	BasicNeeds float64
+	Debts      float64
+}
+
+// TradeInput aset usaha pada akhir haul
+type TradeInput struct {
+	Inventory   float64 // stok barang dagangan dengan harga pasar saat ini
+	Cash        float64 // kas dan saldo rekening usaha
+	Receivables float64 // piutang dagang yang lancar
+	Debts       float64 // utang dagang jatuh tempo
+}
+
+// FitrahInput jumlah jiwa yang ditanggung
+type FitrahInput struct {
+	People int
+}
+
+// Line satu baris rincian; deduction bernilai negatif
+type Line struct {
+	Label  string  `json:"label"`
+	Amount float64 `json:"amount"`
+}
+
+// Haul status kepemilikan satu tahun hijriah
+type Haul struct {
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	DueAt         *time.Time `json:"due_at,omitempty"`
+	Completed     bool       `json:"completed"`
+	DaysRemaining int        `json:"days_remaining"`
+	// Assumed true jika tanggal mulai tidak diisi dan haul dianggap sudah lewat
+	Assumed bool `json:"assumed"`
+}
+
+// Fitrah rincian zakat fitrah
+type Fitrah struct {
+	People        int     `json:"people"`
+	RiceKg        float64 `json:"rice_kg"`         // total beras untuk seluruh jiwa
+	CashPerPerson float64 `json:"cash_per_person"` // padanan uang per jiwa
+	PayInKind     bool    `json:"pay_in_kind"`     // madzhab mensyaratkan bahan makanan pokok
+}
+
+// Result rincian perhitungan; Zakat adalah nominal yang wajib dibayar sekarang
+type Result struct {
+	Type       string    `json:"type"`
+	Madhhab    string    `json:"madhhab"`
+	On         time.Time `json:"on"`
+	Lines      []Line    `json:"lines"`
+	Wealth     float64   `json:"wealth"`
+	NisabBasis string    `json:"nisab_basis,omitempty"`
+	Nisab      float64   `json:"nisab"`
+	AboveNisab bool      `json:"above_nisab"`
+	Rate       float64   `json:"rate"`
+	Haul       *Haul     `json:"haul,omitempty"`
+	// Estimated zakat jika nisab tercapai, meskipun haul belum lewat
+	Estimated float64  `json:"estimated"`
+	Zakat     float64  `json:"zakat"`
+	Fitrah    *Fitrah  `json:"fitrah,omitempty"`
+	Notes     []string `json:"notes,omitempty"`
+}
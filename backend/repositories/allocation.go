@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"zakat/models"
+
+	"gorm.io/gorm"
+)
+
+// ==================== Donation Allocation Repository ====================
+
+// AllocationTotal nominal satu porsi (program, amil, biaya gateway) untuk satu jenis dana
+type AllocationTotal struct {
+	FundType string  `json:"fund_type"`
+	Portion  string  `json:"portion"`
+	Amount   float64 `json:"amount"`
+}
+
+type AllocationRepository interface {
+	WithContext(ctx context.Context) AllocationRepository
+	// Create menyimpan baris alokasi; baris tidak pernah diubah, refund dicatat sebagai baris pembalik
+	Create(lines []models.DonationAllocation) error
+	ListByDonation(donationID int) ([]models.DonationAllocation, error)
+	// SumByFund saldo setiap porsi per jenis dana, dari from (inklusif) sampai to (eksklusif)
+	// menurut waktu pencatatan baris; nil berarti tidak dibatasi
+	SumByFund(from, to *time.Time) ([]AllocationTotal, error)
+}
+
+type allocationRepository struct {
+	db *gorm.DB
+}
+
+func NewAllocationRepository(db *gorm.DB) AllocationRepository {
+	return &allocationRepository{db: db}
+}
+
+func (r *allocationRepository) WithContext(ctx context.Context) AllocationRepository {
+	return &allocationRepository{db: r.db.WithContext(ctx)}
+}
+
+func (r *allocationRepository) Create(lines []models.DonationAllocation) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	return r.db.Create(&lines).Error
+}
+
+func (r *allocationRepository) ListByDonation(donationID int) ([]models.DonationAllocation, error) {
+	var lines []models.DonationAllocation
+	err := r.db.Where("donation_id = ?", donationID).Order("id").Find(&lines).Error
+	return lines, err
+}
+
+func (r *allocationRepository) SumByFund(from, to *time.Time) ([]AllocationTotal, error) {
+	query := r.db.Table("donation_allocations a").
+		Select("a.fund_type, a.portion, COALESCE(SUM(a.amount), 0) AS amount").
+		Joins("JOIN donations d ON d.id = a.donation_id AND d.deleted_at IS NULL")
+	if from != nil {
+		query = query.Where("a.created_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("a.created_at < ?", *to)
+	}
+
+	var totals []AllocationTotal
+	err := query.Group("a.fund_type, a.portion").Order("a.fund_type, a.portion").Scan(&totals).Error
+	return totals, err
+}
@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+	"zakat/models"
+	"zakat/pkg/audit"
+
+	"gorm.io/gorm"
+)
+
+// ==================== Audit Repository ====================
+
+// AuditFilter kriteria pencarian audit log; field kosong diabaikan
+type AuditFilter struct {
+	EntityType string
+	EntityID   string
+	ActorID    *int
+	Action     string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+type AuditRepository interface {
+	Search(filter AuditFilter) ([]models.AuditEvent, int64, error)
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) Search(filter AuditFilter) ([]models.AuditEvent, int64, error) {
+	query := r.db.Model(&models.AuditEvent{})
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []models.AuditEvent
+	err := query.Order("created_at DESC, id DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&events).Error
+	return events, total, err
+}
+
+// recordAudit mencatat perubahan entity di transaksi yang sama dengan perubahannya.
+// before nil berarti create, after nil berarti delete. Tidak mencatat apa pun jika tidak ada field yang berubah.
+func recordAudit(tx *gorm.DB, action, entityType string, entityID int, before, after interface{}) error {
+	changes := audit.Diff(audit.Snapshot(before), audit.Snapshot(after))
+	if len(changes) == 0 {
+		return nil
+	}
+	raw, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	actor := audit.ActorFrom(tx.Statement.Context)
+	event := &models.AuditEvent{
+		ActorID:    actor.UserID,
+		ActorRole:  actor.Role,
+		ActorName:  actor.Name,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   strconv.Itoa(entityID),
+		Changes:    models.JSONText(raw),
+		IPAddress:  actor.IPAddress,
+		UserAgent:  actor.UserAgent,
+		RequestID:  actor.RequestID,
+	}
+	if event.ActorID == nil && event.ActorName == "" {
+		event.ActorName = "system"
+	}
+	return tx.Create(event).Error
+}
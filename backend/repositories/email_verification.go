@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+	"zakat/models"
+
+	"gorm.io/gorm"
+)
+
+// ==================== Email Verification Repository ====================
+
+type EmailVerificationRepository interface {
+	Create(verification *models.EmailVerification) error
+	GetByToken(token string) (*models.EmailVerification, error)
+	GetLatestByUser(userID int) (*models.EmailVerification, error)
+	MarkAsUsed(token string) error
+	InvalidateForUser(userID int) error
+	DeleteExpired() error
+}
+
+type emailVerificationRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailVerificationRepository(db *gorm.DB) EmailVerificationRepository {
+	return &emailVerificationRepository{db: db}
+}
+
+func (r *emailVerificationRepository) Create(verification *models.EmailVerification) error {
+	return r.db.Create(verification).Error
+}
+
+func (r *emailVerificationRepository) GetByToken(token string) (*models.EmailVerification, error) {
+	var verification models.EmailVerification
+	err := r.db.Where("token = ? AND used = ? AND expires_at > ?", token, false, time.Now()).First(&verification).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &verification, nil
+}
+
+// GetLatestByUser dipakai untuk cooldown kirim ulang
+func (r *emailVerificationRepository) GetLatestByUser(userID int) (*models.EmailVerification, error) {
+	var verification models.EmailVerification
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").First(&verification).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &verification, nil
+}
+
+func (r *emailVerificationRepository) MarkAsUsed(token string) error {
+	return r.db.Model(&models.EmailVerification{}).Where("token = ?", token).Update("used", true).Error
+}
+
+// InvalidateForUser menonaktifkan token lama saat token baru dikirim
+func (r *emailVerificationRepository) InvalidateForUser(userID int) error {
+	return r.db.Model(&models.EmailVerification{}).
+		Where("user_id = ? AND used = ?", userID, false).
+		Update("used", true).Error
+}
+
+func (r *emailVerificationRepository) DeleteExpired() error {
+	return r.db.Where("expires_at < ? OR used = ?", time.Now(), true).Delete(&models.EmailVerification{}).Error
+}
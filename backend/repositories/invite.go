@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+	"zakat/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ==================== Admin Invite Repository ====================
+
+// ErrInviteUnavailable dikembalikan jika undangan sudah dipakai, dicabut, atau kadaluarsa
+var ErrInviteUnavailable = errors.New("invite is no longer available")
+
+// ErrInviteDowngrade role undangan lebih rendah dari role user saat ini
+var ErrInviteDowngrade = errors.New("invite role is lower than the current role")
+
+type AdminInviteRepository interface {
+	Create(invite *models.AdminInvite) error
+	GetByID(id uint) (*models.AdminInvite, error)
+	GetByTokenHash(tokenHash string) (*models.AdminInvite, error)
+	List(status string) ([]models.AdminInvite, error)
+	// Accept menandai undangan dipakai dan memberikan role-nya ke user dalam satu transaksi,
+	// lalu mengembalikan user dengan role baru
+	Accept(id uint, userID int) (*models.User, error)
+	Revoke(id uint, revokedByID int) error
+	// WithContext membawa actor (pkg/audit) dari request agar perubahan role tercatat di audit log
+	WithContext(ctx context.Context) AdminInviteRepository
+}
+
+type adminInviteRepository struct {
+	db *gorm.DB
+}
+
+func NewAdminInviteRepository(db *gorm.DB) AdminInviteRepository {
+	return &adminInviteRepository{db: db}
+}
+
+func (r *adminInviteRepository) WithContext(ctx context.Context) AdminInviteRepository {
+	return &adminInviteRepository{db: r.db.WithContext(ctx)}
+}
+
+func (r *adminInviteRepository) Create(invite *models.AdminInvite) error {
+	return r.db.Create(invite).Error
+}
+
+func (r *adminInviteRepository) GetByID(id uint) (*models.AdminInvite, error) {
+	var invite models.AdminInvite
+	err := r.db.Preload("InvitedBy").First(&invite, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &invite, nil
+}
+
+func (r *adminInviteRepository) GetByTokenHash(tokenHash string) (*models.AdminInvite, error) {
+	var invite models.AdminInvite
+	err := r.db.Preload("InvitedBy").Where("token_hash = ?", tokenHash).First(&invite).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// List mengembalikan undangan terbaru dulu, bisa difilter per status
+func (r *adminInviteRepository) List(status string) ([]models.AdminInvite, error) {
+	var invites []models.AdminInvite
+	query := r.db.Preload("InvitedBy").Order("created_at DESC")
+
+	now := time.Now()
+	switch status {
+	case models.InviteStatusPending:
+		query = query.Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", now)
+	case models.InviteStatusAccepted:
+		query = query.Where("accepted_at IS NOT NULL")
+	case models.InviteStatusRevoked:
+		query = query.Where("accepted_at IS NULL AND revoked_at IS NOT NULL")
+	case models.InviteStatusExpired:
+		query = query.Where("accepted_at IS NULL AND revoked_at IS NULL AND expires_at <= ?", now)
+	}
+
+	err := query.Find(&invites).Error
+	return invites, err
+}
+
+// Accept hanya berhasil jika undangan masih pending, sehingga satu undangan tidak bisa dipakai dua kali.
+// Undangan dan user dikunci agar role tidak berubah di antara pengecekan dan penyimpanan.
+func (r *adminInviteRepository) Accept(id uint, userID int) (*models.User, error) {
+	var user models.User
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		var invite models.AdminInvite
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", id, now).
+			First(&invite).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInviteUnavailable
+			}
+			return err
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+			return err
+		}
+		if models.IsRoleDowngrade(user.EffectiveRole(), invite.Role) {
+			return ErrInviteDowngrade
+		}
+
+		err = tx.Model(&invite).Updates(map[string]interface{}{
+			"accepted_at":    now,
+			"accepted_by_id": userID,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		before := user
+		user.SetRole(invite.Role)
+		user.UpdatedAt = now
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, models.AuditActionUpdate, models.AuditEntityUser, user.ID, &before, &user)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *adminInviteRepository) Revoke(id uint, revokedByID int) error {
+	now := time.Now()
+	result := r.db.Model(&models.AdminInvite{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"revoked_at":    now,
+			"revoked_by_id": revokedByID,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInviteUnavailable
+	}
+	return nil
+}
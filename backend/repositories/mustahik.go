@@ -0,0 +1,267 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+	"zakat/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ==================== Mustahik Repository ====================
+
+// ErrDuplicateMustahik NIK sudah terdaftar sebagai mustahik lain
+var ErrDuplicateMustahik = errors.New("mustahik with this NIK is already registered")
+
+// MustahikFilter filter daftar mustahik; field kosong tidak membatasi
+type MustahikFilter struct {
+	Asnaf  string
+	Status string
+	City   string
+	Search string // nama (sebagian)
+}
+
+type MustahikRepository interface {
+	// WithContext membawa actor (pkg/audit) agar perubahan data mustahik tercatat di audit log
+	WithContext(ctx context.Context) MustahikRepository
+	// Create menyimpan mustahik beserta anggota rumah tangganya; ErrDuplicateMustahik jika NIK sudah ada
+	Create(mustahik *models.Mustahik) error
+	// GetByID beserta anggota rumah tangga, asesmen (terbaru dulu) dan dokumen
+	GetByID(id uint) (*models.Mustahik, error)
+	GetByNIKDigest(digest string) (*models.Mustahik, error)
+	// Update menimpa profil dan mengganti seluruh anggota rumah tangga
+	Update(mustahik *models.Mustahik) error
+	// Delete menghapus permanen mustahik beserta anggota, asesmen dan dokumennya
+	Delete(id uint) error
+	List(filter MustahikFilter, limit, offset int) ([]models.Mustahik, int64, error)
+	// AddAssessment menyimpan asesmen lalu memperbarui status dan asnaf mustahik
+	AddAssessment(assessment *models.MustahikAssessment) error
+	AddDocument(document *models.MustahikDocument) error
+	GetDocument(mustahikID, documentID uint) (*models.MustahikDocument, error)
+	DeleteDocument(id uint) error
+}
+
+type mustahikRepository struct {
+	db *gorm.DB
+}
+
+func NewMustahikRepository(db *gorm.DB) MustahikRepository {
+	return &mustahikRepository{db: db}
+}
+
+func (r *mustahikRepository) WithContext(ctx context.Context) MustahikRepository {
+	return &mustahikRepository{db: r.db.WithContext(ctx)}
+}
+
+func (r *mustahikRepository) Create(mustahik *models.Mustahik) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := r.checkDuplicate(tx, mustahik.NIKDigest, 0); err != nil {
+			return err
+		}
+		if err := tx.Create(mustahik).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, models.AuditActionCreate, models.AuditEntityMustahik, int(mustahik.ID), nil, mustahik)
+	})
+}
+
+// checkDuplicate NIK yang sama di mustahik lain; unique index pada nik_digest tetap menjadi pengaman terakhir
+func (r *mustahikRepository) checkDuplicate(tx *gorm.DB, digest string, exceptID uint) error {
+	var count int64
+	err := tx.Model(&models.Mustahik{}).
+		Where("nik_digest = ? AND id <> ?", digest, exceptID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDuplicateMustahik
+	}
+	return nil
+}
+
+func (r *mustahikRepository) GetByID(id uint) (*models.Mustahik, error) {
+	var mustahik models.Mustahik
+	err := r.db.
+		Preload("Members", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Assessments", func(db *gorm.DB) *gorm.DB { return db.Order("assessed_at DESC, id DESC") }).
+		Preload("Documents", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		First(&mustahik, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &mustahik, nil
+}
+
+func (r *mustahikRepository) GetByNIKDigest(digest string) (*models.Mustahik, error) {
+	var mustahik models.Mustahik
+	if err := r.db.Where("nik_digest = ?", digest).First(&mustahik).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &mustahik, nil
+}
+
+func (r *mustahikRepository) Update(mustahik *models.Mustahik) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var before models.Mustahik
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&before, mustahik.ID).Error; err != nil {
+			return err
+		}
+		if mustahik.NIKDigest != before.NIKDigest {
+			if err := r.checkDuplicate(tx, mustahik.NIKDigest, mustahik.ID); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("mustahik_id = ?", mustahik.ID).Delete(&models.MustahikMember{}).Error; err != nil {
+			return err
+		}
+		for i := range mustahik.Members {
+			mustahik.Members[i].ID = 0
+			mustahik.Members[i].MustahikID = mustahik.ID
+		}
+		if len(mustahik.Members) > 0 {
+			if err := tx.Create(&mustahik.Members).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Omit(clause.Associations).Save(mustahik).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, models.AuditActionUpdate, models.AuditEntityMustahik, int(mustahik.ID), &before, mustahik)
+	})
+}
+
+func (r *mustahikRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var before models.Mustahik
+		if err := tx.First(&before, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		for _, child := range []interface{}{&models.MustahikMember{}, &models.MustahikAssessment{}, &models.MustahikDocument{}} {
+			if err := tx.Where("mustahik_id = ?", id).Delete(child).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Delete(&models.Mustahik{}, id).Error; err != nil {
+			return err
+		}
+		// Data pribadi dihapus permanen, jadi jejak audit hanya menyimpan field non-pribadi
+		return recordAudit(tx, models.AuditActionDelete, models.AuditEntityMustahik, int(before.ID), map[string]interface{}{
+			"id":             before.ID,
+			"asnaf":          before.Asnaf,
+			"status":         before.Status,
+			"household_size": before.HouseholdSize,
+			"created_at":     before.CreatedAt,
+		}, nil)
+	})
+}
+
+func (r *mustahikRepository) List(filter MustahikFilter, limit, offset int) ([]models.Mustahik, int64, error) {
+	query := r.db.Model(&models.Mustahik{})
+	if filter.Asnaf != "" {
+		query = query.Where("asnaf = ?", filter.Asnaf)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.City != "" {
+		query = query.Where("city ILIKE ?", "%"+filter.City+"%")
+	}
+	if filter.Search != "" {
+		query = query.Where("full_name ILIKE ?", "%"+filter.Search+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var mustahik []models.Mustahik
+	err := query.Order("full_name, id").Limit(limit).Offset(offset).Find(&mustahik).Error
+	return mustahik, total, err
+}
+
+func (r *mustahikRepository) AddAssessment(assessment *models.MustahikAssessment) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var before models.Mustahik
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&before, assessment.MustahikID).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(assessment).Error; err != nil {
+			return err
+		}
+
+		status := models.MustahikStatusIneligible
+		if assessment.Eligible {
+			status = models.MustahikStatusEligible
+		}
+		after := before
+		after.Status = status
+		after.Asnaf = assessment.Asnaf
+		after.MonthlyIncome = assessment.MonthlyIncome
+		after.HouseholdSize = assessment.HouseholdSize
+		after.AssessedAt = &assessment.AssessedAt
+		after.UpdatedAt = time.Now()
+		err := tx.Model(&models.Mustahik{}).Where("id = ?", before.ID).Updates(map[string]interface{}{
+			"status":         after.Status,
+			"asnaf":          after.Asnaf,
+			"monthly_income": after.MonthlyIncome,
+			"household_size": after.HouseholdSize,
+			"assessed_at":    after.AssessedAt,
+			"updated_at":     after.UpdatedAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, models.AuditActionUpdate, models.AuditEntityMustahik, int(before.ID), &before, &after)
+	})
+}
+
+func (r *mustahikRepository) AddDocument(document *models.MustahikDocument) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(document).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, models.AuditActionCreate, models.AuditEntityMustahikDocument, int(document.ID), nil, document)
+	})
+}
+
+func (r *mustahikRepository) GetDocument(mustahikID, documentID uint) (*models.MustahikDocument, error) {
+	var document models.MustahikDocument
+	err := r.db.Where("mustahik_id = ?", mustahikID).First(&document, documentID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &document, nil
+}
+
+func (r *mustahikRepository) DeleteDocument(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var before models.MustahikDocument
+		if err := tx.First(&before, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		if err := tx.Delete(&models.MustahikDocument{}, id).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, models.AuditActionDelete, models.AuditEntityMustahikDocument, int(before.ID), &before, nil)
+	})
+}
@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"errors"
+	"zakat/models"
+
+	"gorm.io/gorm"
+)
+
+// ==================== Offline Donation Repository ====================
+
+// ErrDuplicateReceipt dikembalikan jika nomor kwitansi sudah pernah dicatat
+var ErrDuplicateReceipt = errors.New("receipt number has already been recorded")
+
+type OfflineDonationRepository interface {
+	CreateSession(session *models.CollectionSession) error
+	GetSession(id uint) (*models.CollectionSession, error)
+	// ListSessions campaignID 0 berarti semua campaign
+	ListSessions(campaignID uint, limit, offset int) ([]models.CollectionSession, int64, error)
+	// SessionDonations donasi yang dicatat dalam satu sesi pengumpulan
+	SessionDonations(sessionID uint) ([]models.Donation, error)
+	ReceiptExists(receiptNumber string) (bool, error)
+}
+
+type offlineDonationRepository struct {
+	db *gorm.DB
+}
+
+func NewOfflineDonationRepository(db *gorm.DB) OfflineDonationRepository {
+	return &offlineDonationRepository{db: db}
+}
+
+func (r *offlineDonationRepository) CreateSession(session *models.CollectionSession) error {
+	return r.db.Create(session).Error
+}
+
+func (r *offlineDonationRepository) GetSession(id uint) (*models.CollectionSession, error) {
+	var session models.CollectionSession
+	err := r.db.Preload("Campaign").First(&session, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *offlineDonationRepository) ListSessions(campaignID uint, limit, offset int) ([]models.CollectionSession, int64, error) {
+	query := r.db.Model(&models.CollectionSession{})
+	if campaignID != 0 {
+		query = query.Where("campaign_id = ?", campaignID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var sessions []models.CollectionSession
+	err := query.Preload("Campaign").Order("collected_at DESC").Limit(limit).Offset(offset).Find(&sessions).Error
+	return sessions, total, err
+}
+
+func (r *offlineDonationRepository) SessionDonations(sessionID uint) ([]models.Donation, error) {
+	var donations []models.Donation
+	err := r.db.
+		Joins("JOIN offline_donations od ON od.donation_id = donations.id").
+		Where("od.collection_session_id = ?", sessionID).
+		Preload("User").
+		Preload("Offline").
+		Order("donations.created_at").
+		Find(&donations).Error
+	return donations, err
+}
+
+func (r *offlineDonationRepository) ReceiptExists(receiptNumber string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.OfflineDonation{}).Where("receipt_number = ?", receiptNumber).Count(&count).Error
+	return count > 0, err
+}
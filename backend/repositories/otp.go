@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+	"zakat/models"
+
+	"gorm.io/gorm"
+)
+
+// ==================== Phone OTP Repository ====================
+
+type PhoneOTPRepository interface {
+	Create(otp *models.PhoneOTP) error
+	GetLatest(phone, purpose string) (*models.PhoneOTP, error)
+	IncrementAttempts(id uint) error
+	MarkConsumed(id uint) error
+	InvalidateActive(phone, purpose string) error
+	DeleteExpired() error
+}
+
+type phoneOTPRepository struct {
+	db *gorm.DB
+}
+
+func NewPhoneOTPRepository(db *gorm.DB) PhoneOTPRepository {
+	return &phoneOTPRepository{db: db}
+}
+
+func (r *phoneOTPRepository) Create(otp *models.PhoneOTP) error {
+	return r.db.Create(otp).Error
+}
+
+// GetLatest mengambil OTP terakhir untuk nomor dan tujuan tertentu
+func (r *phoneOTPRepository) GetLatest(phone, purpose string) (*models.PhoneOTP, error) {
+	var otp models.PhoneOTP
+	err := r.db.Where("phone = ? AND purpose = ?", phone, purpose).Order("created_at DESC").First(&otp).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &otp, nil
+}
+
+func (r *phoneOTPRepository) IncrementAttempts(id uint) error {
+	return r.db.Model(&models.PhoneOTP{}).Where("id = ?", id).
+		Update("attempts", gorm.Expr("attempts + 1")).Error
+}
+
+// MarkConsumed hanya berhasil sekali, sehingga satu kode tidak bisa dipakai dua kali bersamaan
+func (r *phoneOTPRepository) MarkConsumed(id uint) error {
+	result := r.db.Model(&models.PhoneOTP{}).
+		Where("id = ? AND consumed_at IS NULL", id).
+		Update("consumed_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("otp already consumed")
+	}
+	return nil
+}
+
+func (r *phoneOTPRepository) InvalidateActive(phone, purpose string) error {
+	return r.db.Model(&models.PhoneOTP{}).
+		Where("phone = ? AND purpose = ? AND consumed_at IS NULL", phone, purpose).
+		Update("consumed_at", time.Now()).Error
+}
+
+func (r *phoneOTPRepository) DeleteExpired() error {
+	return r.db.Where("expires_at < ?", time.Now()).Delete(&models.PhoneOTP{}).Error
+}
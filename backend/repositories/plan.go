@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+	"zakat/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ==================== Donation Plan Repository ====================
+
+type DonationPlanRepository interface {
+	// WithContext membawa actor (pkg/audit) agar donasi yang dibuat scheduler tercatat di audit log
+	WithContext(ctx context.Context) DonationPlanRepository
+	Create(plan *models.DonationPlan) error
+	GetByID(id uint) (*models.DonationPlan, error)
+	Update(plan *models.DonationPlan) error
+	ListByUser(userID int) ([]models.DonationPlan, error)
+	List(status string, limit, offset int) ([]models.DonationPlan, int64, error)
+	Charges(planID uint) ([]models.DonationPlanCharge, error)
+	// Due rencana aktif yang jadwal tagihannya sudah lewat
+	Due(now time.Time, limit int) ([]models.DonationPlan, error)
+	// CreateCharge membuat donasi periode plan.NextChargeAt dan memajukan jadwal dalam satu transaksi.
+	// created=false jika rencana sudah tidak aktif atau periode itu sudah diproses instance lain.
+	CreateCharge(planID uint, periodAt time.Time, donation *models.Donation) (created bool, err error)
+	// PendingCharges tagihan yang donasinya masih pending dan dibuat sebelum cutoff, belum diingatkan
+	PendingCharges(before time.Time, limit int) ([]models.DonationPlanCharge, error)
+	MarkReminded(chargeID uint) error
+}
+
+type donationPlanRepository struct {
+	db *gorm.DB
+}
+
+func NewDonationPlanRepository(db *gorm.DB) DonationPlanRepository {
+	return &donationPlanRepository{db: db}
+}
+
+func (r *donationPlanRepository) WithContext(ctx context.Context) DonationPlanRepository {
+	return &donationPlanRepository{db: r.db.WithContext(ctx)}
+}
+
+func (r *donationPlanRepository) Create(plan *models.DonationPlan) error {
+	return r.db.Create(plan).Error
+}
+
+func (r *donationPlanRepository) GetByID(id uint) (*models.DonationPlan, error) {
+	var plan models.DonationPlan
+	err := r.db.Preload("Campaign").First(&plan, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func (r *donationPlanRepository) Update(plan *models.DonationPlan) error {
+	return r.db.Omit("User", "Campaign").Save(plan).Error
+}
+
+func (r *donationPlanRepository) ListByUser(userID int) ([]models.DonationPlan, error) {
+	var plans []models.DonationPlan
+	err := r.db.Where("user_id = ?", userID).Preload("Campaign").Order("created_at DESC").Find(&plans).Error
+	return plans, err
+}
+
+func (r *donationPlanRepository) List(status string, limit, offset int) ([]models.DonationPlan, int64, error) {
+	query := r.db.Model(&models.DonationPlan{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var plans []models.DonationPlan
+	err := query.Preload("User").Preload("Campaign").Order("created_at DESC").Limit(limit).Offset(offset).Find(&plans).Error
+	return plans, total, err
+}
+
+func (r *donationPlanRepository) Charges(planID uint) ([]models.DonationPlanCharge, error) {
+	var charges []models.DonationPlanCharge
+	err := r.db.Where("plan_id = ?", planID).Preload("Donation").Order("period_at DESC").Find(&charges).Error
+	return charges, err
+}
+
+func (r *donationPlanRepository) Due(now time.Time, limit int) ([]models.DonationPlan, error) {
+	var plans []models.DonationPlan
+	err := r.db.
+		Where("status = ? AND next_charge_at <= ?", models.DonationPlanActive, now).
+		Order("next_charge_at").
+		Limit(limit).
+		Find(&plans).Error
+	return plans, err
+}
+
+func (r *donationPlanRepository) CreateCharge(planID uint, periodAt time.Time, donation *models.Donation) (bool, error) {
+	created := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var plan models.DonationPlan
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&plan, planID).Error; err != nil {
+			return err
+		}
+		if plan.Status != models.DonationPlanActive || !plan.NextChargeAt.Equal(periodAt) {
+			return nil
+		}
+
+		if err := stampFundType(tx, donation); err != nil {
+			return err
+		}
+		if err := tx.Create(donation).Error; err != nil {
+			return err
+		}
+		if err := recordAudit(tx, models.AuditActionCreate, models.AuditEntityDonation, donation.ID, nil, donation); err != nil {
+			return err
+		}
+		charge := models.DonationPlanCharge{PlanID: plan.ID, PeriodAt: periodAt, DonationID: donation.ID}
+		if err := tx.Create(&charge).Error; err != nil {
+			return err
+		}
+
+		// Periode yang terlewat (mis. server mati) tidak ditagih mundur
+		next := plan.NextAfter(periodAt)
+		for !next.After(time.Now()) {
+			next = plan.NextAfter(next)
+		}
+		if err := tx.Model(&plan).Updates(map[string]interface{}{"next_charge_at": next, "updated_at": time.Now()}).Error; err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	return created, err
+}
+
+func (r *donationPlanRepository) PendingCharges(before time.Time, limit int) ([]models.DonationPlanCharge, error) {
+	var charges []models.DonationPlanCharge
+	err := r.db.
+		Joins("JOIN donations d ON d.id = donation_plan_charges.donation_id").
+		Where("d.status = ? AND d.deleted_at IS NULL", models.DonationStatusPending).
+		Where("donation_plan_charges.reminder_sent_at IS NULL AND donation_plan_charges.created_at <= ?", before).
+		Preload("Donation.User").
+		Preload("Donation.Campaign").
+		Order("donation_plan_charges.created_at").
+		Limit(limit).
+		Find(&charges).Error
+	return charges, err
+}
+
+func (r *donationPlanRepository) MarkReminded(chargeID uint) error {
+	return r.db.Model(&models.DonationPlanCharge{}).Where("id = ?", chargeID).Update("reminder_sent_at", time.Now()).Error
+}
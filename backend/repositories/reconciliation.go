@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+	"zakat/models"
+
+	"gorm.io/gorm"
+)
+
+// ==================== Reconciliation Repository ====================
+
+type ReconciliationRepository interface {
+	CreateRun(run *models.ReconciliationRun) error
+	FinishRun(run *models.ReconciliationRun) error
+	AddItem(item *models.ReconciliationItem) error
+	ListRuns(limit, offset int) ([]models.ReconciliationRun, int64, error)
+	GetRun(id uint) (*models.ReconciliationRun, error)
+	// PendingDonations donasi yang masih menunggu pembayaran dan dibuat sebelum cutoff;
+	// yang belum pernah atau paling lama tidak dicek didahulukan
+	PendingDonations(before time.Time, limit int) ([]models.Donation, error)
+	// MarkReconciled mencatat waktu donasi terakhir dicek agar batch berikutnya bergiliran
+	MarkReconciled(donationID int, at time.Time) error
+	// StaleManualTransfers transfer manual yang masih pending sejak sebelum cutoff dan tidak punya
+	// bukti transfer yang menunggu verifikasi
+	StaleManualTransfers(before time.Time, limit int) ([]models.Donation, error)
+}
+
+type reconciliationRepository struct {
+	db *gorm.DB
+}
+
+func NewReconciliationRepository(db *gorm.DB) ReconciliationRepository {
+	return &reconciliationRepository{db: db}
+}
+
+func (r *reconciliationRepository) CreateRun(run *models.ReconciliationRun) error {
+	return r.db.Create(run).Error
+}
+
+func (r *reconciliationRepository) FinishRun(run *models.ReconciliationRun) error {
+	return r.db.Omit("Items").Save(run).Error
+}
+
+func (r *reconciliationRepository) AddItem(item *models.ReconciliationItem) error {
+	return r.db.Create(item).Error
+}
+
+func (r *reconciliationRepository) ListRuns(limit, offset int) ([]models.ReconciliationRun, int64, error) {
+	var runs []models.ReconciliationRun
+	var total int64
+	if err := r.db.Model(&models.ReconciliationRun{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := r.db.Order("started_at DESC").Limit(limit).Offset(offset).Find(&runs).Error
+	return runs, total, err
+}
+
+func (r *reconciliationRepository) GetRun(id uint) (*models.ReconciliationRun, error) {
+	var run models.ReconciliationRun
+	err := r.db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).First(&run, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *reconciliationRepository) PendingDonations(before time.Time, limit int) ([]models.Donation, error) {
+	var donations []models.Donation
+	err := r.db.
+		Where("(status IN ? OR status IS NULL) AND order_id <> '' AND created_at < ?",
+			[]string{models.DonationStatusPending, "", "unknown"}, before).
+		// Transfer manual tidak ada di payment gateway; statusnya ditentukan lewat verifikasi admin
+		Where("payment_provider IS NULL OR payment_provider <> ?", models.PaymentProviderManual).
+		Order("reconciled_at NULLS FIRST, created_at").
+		Limit(limit).
+		Find(&donations).Error
+	return donations, err
+}
+
+func (r *reconciliationRepository) MarkReconciled(donationID int, at time.Time) error {
+	return r.db.Model(&models.Donation{}).Where("id = ?", donationID).UpdateColumn("reconciled_at", at).Error
+}
+
+func (r *reconciliationRepository) StaleManualTransfers(before time.Time, limit int) ([]models.Donation, error) {
+	var donations []models.Donation
+	err := r.db.
+		Where("payment_provider = ? AND status = ? AND created_at < ?",
+			models.PaymentProviderManual, models.DonationStatusPending, before).
+		Where("NOT EXISTS (SELECT 1 FROM transfer_proofs p WHERE p.donation_id = donations.id AND p.status = ?)",
+			models.TransferProofSubmitted).
+		Order("created_at").
+		Limit(limit).
+		Find(&donations).Error
+	return donations, err
+}
@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+	"zakat/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ==================== Reference Rate Repository ====================
+
+// ReferenceRateFilter filter riwayat harga referensi; field kosong tidak membatasi
+type ReferenceRateFilter struct {
+	Kind   string
+	Region string
+	Source string
+	From   *time.Time
+	To     *time.Time
+}
+
+type ReferenceRateRepository interface {
+	// WithContext membawa actor (pkg/audit) agar perubahan tarif resmi tercatat di audit log
+	WithContext(ctx context.Context) ReferenceRateRepository
+	// Effective harga yang berlaku pada tanggal on, nil jika belum ada. Urutan prioritas:
+	//  1. Dalam satu cakupan (wilayah atau nasional), harga dengan effective_from terbaru yang berlaku;
+	//     pada tanggal yang sama tarif resmi menang atas hasil impor.
+	//  2. Harga wilayah didahulukan dari nasional selama umurnya (on - effective_from) tidak lebih
+	//     dari maxRegionalAge atau tidak lebih tua dari harga nasional; 0 berarti tidak dibatasi.
+	Effective(kind, region string, on time.Time, maxRegionalAge time.Duration) (*models.ReferenceRate, error)
+	// SetOfficial menyimpan tarif resmi; tarif resmi dengan jenis, wilayah dan tanggal yang sama ditimpa
+	SetOfficial(rate *models.ReferenceRate) error
+	// Import menyimpan hasil impor feed; baris dengan kunci yang sama diperbarui
+	Import(rates []models.ReferenceRate) error
+	GetByID(id uint) (*models.ReferenceRate, error)
+	Delete(id uint) error
+	List(filter ReferenceRateFilter, limit, offset int) ([]models.ReferenceRate, int64, error)
+}
+
+type referenceRateRepository struct {
+	db *gorm.DB
+}
+
+func NewReferenceRateRepository(db *gorm.DB) ReferenceRateRepository {
+	return &referenceRateRepository{db: db}
+}
+
+func (r *referenceRateRepository) WithContext(ctx context.Context) ReferenceRateRepository {
+	return &referenceRateRepository{db: r.db.WithContext(ctx)}
+}
+
+func (r *referenceRateRepository) Effective(kind, region string, on time.Time, maxRegionalAge time.Duration) (*models.ReferenceRate, error) {
+	national, err := r.effectiveIn(kind, "", on)
+	if err != nil || region == "" {
+		return national, err
+	}
+	regional, err := r.effectiveIn(kind, region, on)
+	if err != nil || regional == nil {
+		return national, err
+	}
+
+	fresh := maxRegionalAge <= 0 || on.Sub(regional.EffectiveFrom) <= maxRegionalAge
+	if national == nil || fresh || !regional.EffectiveFrom.Before(national.EffectiveFrom) {
+		return regional, nil
+	}
+	return national, nil
+}
+
+// effectiveIn harga terbaru dalam satu cakupan wilayah. Tarif resmi menang atas impor bertanggal sama
+// atau lebih lama; impor yang lebih baru tetap dipakai agar harga tidak tertahan tarif resmi lama.
+func (r *referenceRateRepository) effectiveIn(kind, region string, on time.Time) (*models.ReferenceRate, error) {
+	var rate models.ReferenceRate
+	err := r.db.
+		Where("kind = ? AND region = ? AND effective_from <= ?", kind, region, on).
+		Order("effective_from DESC, source = 'official' DESC").
+		First(&rate).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func (r *referenceRateRepository) SetOfficial(rate *models.ReferenceRate) error {
+	rate.Source = models.RateSourceOfficial
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.ReferenceRate
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("kind = ? AND region = ? AND effective_from = ? AND source = ?", rate.Kind, rate.Region, rate.EffectiveFrom, rate.Source).
+			First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := tx.Create(rate).Error; err != nil {
+				return err
+			}
+			return recordAudit(tx, models.AuditActionCreate, models.AuditEntityReferenceRate, int(rate.ID), nil, rate)
+		}
+		if err != nil {
+			return err
+		}
+
+		rate.ID, rate.CreatedAt = existing.ID, existing.CreatedAt
+		if err := tx.Save(rate).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, models.AuditActionUpdate, models.AuditEntityReferenceRate, int(rate.ID), &existing, rate)
+	})
+}
+
+func (r *referenceRateRepository) Import(rates []models.ReferenceRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "kind"}, {Name: "region"}, {Name: "effective_from"}, {Name: "source"}},
+		DoUpdates: clause.AssignmentColumns([]string{"price", "authority", "note", "updated_at"}),
+	}).CreateInBatches(rates, 200).Error
+}
+
+func (r *referenceRateRepository) GetByID(id uint) (*models.ReferenceRate, error) {
+	var rate models.ReferenceRate
+	if err := r.db.First(&rate, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func (r *referenceRateRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var before models.ReferenceRate
+		if err := tx.First(&before, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		if err := tx.Delete(&models.ReferenceRate{}, id).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, models.AuditActionDelete, models.AuditEntityReferenceRate, int(before.ID), &before, nil)
+	})
+}
+
+func (r *referenceRateRepository) List(filter ReferenceRateFilter, limit, offset int) ([]models.ReferenceRate, int64, error) {
+	query := r.db.Model(&models.ReferenceRate{})
+	if filter.Kind != "" {
+		query = query.Where("kind = ?", filter.Kind)
+	}
+	if filter.Region != "" {
+		query = query.Where("region = ?", filter.Region)
+	}
+	if filter.Source != "" {
+		query = query.Where("source = ?", filter.Source)
+	}
+	if filter.From != nil {
+		query = query.Where("effective_from >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("effective_from <= ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rates []models.ReferenceRate
+	err := query.Order("effective_from DESC, kind, region, source").Limit(limit).Offset(offset).Find(&rates).Error
+	return rates, total, err
+}
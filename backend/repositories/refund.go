@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+	"zakat/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ==================== Refund Repository ====================
+
+type RefundRepository interface {
+	Create(refund *models.Refund) error
+	GetByID(id uint) (*models.Refund, error)
+	// GetForUpdate mengunci baris refund sampai transaksi selesai
+	GetForUpdate(id uint) (*models.Refund, error)
+	// FindForDonation mencari refund milik donasi berdasarkan refund key atau ID refund di provider
+	FindForDonation(donationID int, refundKey, providerRefundID string) (*models.Refund, error)
+	Update(refund *models.Refund) error
+	ListByDonation(donationID int) ([]models.Refund, error)
+	List(status string, limit, offset int) ([]models.Refund, int64, error)
+	// PendingAmount total refund yang sudah diajukan ke gateway tapi belum selesai
+	PendingAmount(donationID int) (float64, error)
+	// PendingBefore refund ke gateway yang masih pending dan diajukan sebelum cutoff, terlama dulu
+	PendingBefore(before time.Time, limit int) ([]models.Refund, error)
+}
+
+type refundRepository struct {
+	db *gorm.DB
+}
+
+func NewRefundRepository(db *gorm.DB) RefundRepository {
+	return &refundRepository{db: db}
+}
+
+func (r *refundRepository) Create(refund *models.Refund) error {
+	return r.db.Create(refund).Error
+}
+
+func (r *refundRepository) GetByID(id uint) (*models.Refund, error) {
+	var refund models.Refund
+	err := r.db.Preload("Donation.User").Preload("Donation.Campaign").First(&refund, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (r *refundRepository) GetForUpdate(id uint) (*models.Refund, error) {
+	var refund models.Refund
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&refund, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (r *refundRepository) FindForDonation(donationID int, refundKey, providerRefundID string) (*models.Refund, error) {
+	query := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("donation_id = ?", donationID)
+	switch {
+	case refundKey != "" && providerRefundID != "":
+		query = query.Where("refund_key = ? OR provider_refund_id = ?", refundKey, providerRefundID)
+	case refundKey != "":
+		query = query.Where("refund_key = ?", refundKey)
+	case providerRefundID != "":
+		query = query.Where("provider_refund_id = ?", providerRefundID)
+	default:
+		return nil, nil
+	}
+
+	var refund models.Refund
+	if err := query.First(&refund).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (r *refundRepository) Update(refund *models.Refund) error {
+	return r.db.Omit("Donation").Save(refund).Error
+}
+
+func (r *refundRepository) ListByDonation(donationID int) ([]models.Refund, error) {
+	var refunds []models.Refund
+	err := r.db.Where("donation_id = ?", donationID).Order("created_at").Find(&refunds).Error
+	return refunds, err
+}
+
+func (r *refundRepository) List(status string, limit, offset int) ([]models.Refund, int64, error) {
+	query := r.db.Model(&models.Refund{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var refunds []models.Refund
+	err := query.Preload("Donation.User").Preload("Donation.Campaign").
+		Order("created_at DESC").Limit(limit).Offset(offset).Find(&refunds).Error
+	return refunds, total, err
+}
+
+func (r *refundRepository) PendingAmount(donationID int) (float64, error) {
+	var total float64
+	err := r.db.Model(&models.Refund{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("donation_id = ? AND status = ?", donationID, models.RefundStatusPending).
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *refundRepository) PendingBefore(before time.Time, limit int) ([]models.Refund, error) {
+	var refunds []models.Refund
+	err := r.db.
+		Where("status = ? AND provider <> '' AND created_at < ?", models.RefundStatusPending, before).
+		Order("created_at").
+		Limit(limit).
+		Find(&refunds).Error
+	return refunds, err
+}
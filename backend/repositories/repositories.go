@@ -1,11 +1,13 @@
 package repositories
 
 import (
+	"context"
 	"errors"
 	"time"
 	"zakat/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // =========forgoten password =============
@@ -59,6 +61,8 @@ func (r *passwordResetRepository) DeleteExpired() error {
 // ==================== User Repository ====================
 
 type UserRepository interface {
+	// WithContext membawa actor (pkg/audit) dari request agar perubahan tercatat di audit log
+	WithContext(ctx context.Context) UserRepository
 	FindAdmin() (*models.User, error)
 	CountAdmins() (int64, error)
 	Create(user *models.User) error
@@ -69,6 +73,7 @@ type UserRepository interface {
 	GetByEmail(email string) (*models.User, error)
 	GetByUsername(username string) (*models.User, error)
 	GetByPhone(phone string) (*models.User, error)
+	GetByVerifiedPhone(phone string) (*models.User, error)
 }
 
 type userRepository struct {
@@ -79,6 +84,10 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
+func (r *userRepository) WithContext(ctx context.Context) UserRepository {
+	return &userRepository{db: r.db.WithContext(ctx)}
+}
+
 func (r *userRepository) CountAdmins() (int64, error) {
 	var count int64
 	err := r.db.Model(&models.User{}).Where("is_admin = ?", true).Count(&count).Error
@@ -98,7 +107,12 @@ func (r *userRepository) FindAdmin() (*models.User, error) {
 }
 
 func (r *userRepository) Create(user *models.User) error {
-	return r.db.Create(user).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, models.AuditActionCreate, models.AuditEntityUser, user.ID, nil, user)
+	})
 }
 
 func (r *userRepository) GetAll() ([]models.User, error) {
@@ -140,6 +154,19 @@ func (r *userRepository) GetByPhone(phone string) (*models.User, error) {
 	return &user, nil
 }
 
+// GetByVerifiedPhone hanya mencari nomor yang sudah diverifikasi lewat OTP (format E.164)
+func (r *userRepository) GetByVerifiedPhone(phone string) (*models.User, error) {
+	var user models.User
+	err := r.db.Where("phone = ? AND phone_verified_at IS NOT NULL", phone).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *userRepository) GetByUsername(username string) (*models.User, error) {
 	var user models.User
 	err := r.db.Where("username = ?", username).First(&user).Error
@@ -152,12 +179,39 @@ func (r *userRepository) GetByUsername(username string) (*models.User, error) {
 	return &user, nil
 }
 
+// Update menyimpan perubahan dan mencatat field yang berubah di audit log
 func (r *userRepository) Update(user *models.User) error {
-	return r.db.Save(user).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var before models.User
+		err := tx.First(&before, user.ID).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err := tx.Save(user).Error; err != nil {
+			return err
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return recordAudit(tx, models.AuditActionCreate, models.AuditEntityUser, user.ID, nil, user)
+		}
+		return recordAudit(tx, models.AuditActionUpdate, models.AuditEntityUser, user.ID, &before, user)
+	})
 }
 
+// Delete menghapus data dan menyimpan salinan terakhirnya di audit log
 func (r *userRepository) Delete(id uint) error {
-	return r.db.Delete(&models.User{}, id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var before models.User
+		if err := tx.First(&before, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		if err := tx.Delete(&models.User{}, id).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, models.AuditActionDelete, models.AuditEntityUser, before.ID, &before, nil)
+	})
 }
 
 func (r *userRepository) GetAllWithPagination(limit, offset int) ([]models.User, int64, error) {
@@ -173,13 +227,20 @@ func (r *userRepository) GetAllWithPagination(limit, offset int) ([]models.User,
 // ==================== Campaign Repository ====================
 
 type CampaignRepository interface {
+	// WithContext membawa actor (pkg/audit) dari request agar perubahan tercatat di audit log
+	WithContext(ctx context.Context) CampaignRepository
 	Create(campaign *models.Campaign) error
 	GetAll() ([]models.Campaign, error)
 	GetByID(id uint) (*models.Campaign, error)
 	Update(campaign *models.Campaign) error
 	Delete(id uint) error
 	GetDonations(campaignID uint) ([]models.Donation, error)
-	GetByFilters(category, location string) ([]models.Campaign, error)
+	GetByFilters(category, location, fundType string) ([]models.Campaign, error)
+	// Total dan jumlah donatur hanya diubah secara atomik, tidak lewat Update
+	GetForUpdate(id uint) (*models.Campaign, error)
+	AdjustTotals(id uint, gross, net float64, donors int) error
+	SetTotals(id uint, gross, net float64, donors int) error
+	ComputeTotals() ([]CampaignTotals, error)
 }
 
 type campaignRepository struct {
@@ -190,31 +251,27 @@ func NewCampaignRepository(db *gorm.DB) CampaignRepository {
 	return &campaignRepository{db: db}
 }
 
+func (r *campaignRepository) WithContext(ctx context.Context) CampaignRepository {
+	return &campaignRepository{db: r.db.WithContext(ctx)}
+}
+
 func (r *campaignRepository) Create(campaign *models.Campaign) error {
-	return r.db.Create(campaign).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(campaign).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, models.AuditActionCreate, models.AuditEntityCampaign, campaign.ID, nil, campaign)
+	})
 }
 
 func (r *campaignRepository) GetAll() ([]models.Campaign, error) {
 	var campaigns []models.Campaign
 	err := r.db.Preload("User").Preload("Donations").Find(&campaigns).Error
-	if err != nil {
-		return nil, err
-	}
-
-	// Hitung donor count untuk setiap campaign
-	for i := range campaigns {
-		var donorCount int64
-		r.db.Model(&models.Donation{}).
-			Where("campaign_id = ? AND status = ?", campaigns[i].ID, "success").
-			Distinct("user_id").
-			Count(&donorCount)
-		campaigns[i].DonorCount = int(donorCount)
-	}
 	return campaigns, err
 }
 
 // Add this method for filtering
-func (r *campaignRepository) GetByFilters(category, location string) ([]models.Campaign, error) {
+func (r *campaignRepository) GetByFilters(category, location, fundType string) ([]models.Campaign, error) {
 	var campaigns []models.Campaign
 	query := r.db.Preload("User").Preload("Donations")
 
@@ -226,6 +283,10 @@ func (r *campaignRepository) GetByFilters(category, location string) ([]models.C
 		query = query.Where("location LIKE ?", "%"+location+"%")
 	}
 
+	if fundType != "" {
+		query = query.Where("fund_type = ?", fundType)
+	}
+
 	err := query.Find(&campaigns).Error
 	return campaigns, err
 }
@@ -239,23 +300,57 @@ func (r *campaignRepository) GetByID(id uint) (*models.Campaign, error) {
 	return &campaign, err
 }
 
+// Update menyimpan perubahan dan mencatat field yang berubah di audit log.
+// total_collected, net_collected dan donor_count tidak ikut ditulis (lihat AdjustTotals) agar nilai lama
+// yang terbaca sebelumnya tidak menimpa kredit yang terjadi bersamaan.
 func (r *campaignRepository) Update(campaign *models.Campaign) error {
-	return r.db.Save(campaign).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var before models.Campaign
+		err := tx.First(&before, campaign.ID).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil {
+			campaign.TotalCollected, campaign.NetCollected, campaign.DonorCount = before.TotalCollected, before.NetCollected, before.DonorCount
+		}
+		if err := tx.Omit("TotalCollected", "NetCollected", "DonorCount").Save(campaign).Error; err != nil {
+			return err
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return recordAudit(tx, models.AuditActionCreate, models.AuditEntityCampaign, campaign.ID, nil, campaign)
+		}
+		return recordAudit(tx, models.AuditActionUpdate, models.AuditEntityCampaign, campaign.ID, &before, campaign)
+	})
 }
 
+// Delete menghapus data dan menyimpan salinan terakhirnya di audit log
 func (r *campaignRepository) Delete(id uint) error {
-	return r.db.Delete(&models.Campaign{}, id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var before models.Campaign
+		if err := tx.First(&before, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		if err := tx.Delete(&models.Campaign{}, id).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, models.AuditActionDelete, models.AuditEntityCampaign, before.ID, &before, nil)
+	})
 }
 
 func (r *campaignRepository) GetDonations(campaignID uint) ([]models.Donation, error) {
 	var donations []models.Donation
-	err := r.db.Where("campaign_id = ?", campaignID).Preload("User").Find(&donations).Error
+	err := r.db.Where("campaign_id = ?", campaignID).Preload("User").Preload("Offline").Find(&donations).Error
 	return donations, err
 }
 
 // ==================== Donation Repository ====================
 
 type DonationRepository interface {
+	// WithContext membawa actor (pkg/audit) dari request agar perubahan tercatat di audit log
+	WithContext(ctx context.Context) DonationRepository
 	Create(donation *models.Donation) error
 	GetAll() ([]models.Donation, error)
 	GetByID(id uint) (*models.Donation, error)
@@ -264,11 +359,57 @@ type DonationRepository interface {
 	GetByCampaign(campaignID uint) ([]models.Donation, error)
 	CountAll() (int64, error)
 	CountPaid() (int64, error)
+	// SumPaidAmount total donasi sukses, sudah dikurangi refund parsial
 	SumPaidAmount() (float64, error)
+	// SumPaidByChannel rekap donasi sukses per channel (online, manual_transfer, offline)
+	SumPaidByChannel() ([]ChannelTotal, error)
+	// SumPaidByFund rekap donasi sukses per jenis dana dan channel; dari tanggal from (inklusif)
+	// sampai to (eksklusif) menurut credited_at, nil berarti tidak dibatasi
+	SumPaidByFund(from, to *time.Time) ([]FundTotal, error)
 	CountByCampaign(campaignID uint) (int64, error)
 	GetByOrderID(orderID string) (*models.Donation, error)
 	GetAllWithDetails() ([]models.Donation, error)
 	GetByUser(userID uint) ([]models.Donation, error)
+	// Perubahan status hanya lewat DonationService (state machine dalam satu transaksi)
+	GetForUpdate(id uint) (*models.Donation, error)
+	GetByOrderIDForUpdate(orderID string) (*models.Donation, error)
+	UpdateStatus(donation *models.Donation) error
+	HasOtherSuccessful(campaignID int, userID *int, excludeID int) (bool, error)
+	RecordNotification(notification *models.PaymentNotification) (bool, error)
+	// ManualAmountInUse mengecek apakah nominal (termasuk kode unik) sudah dipakai transfer manual lain
+	// yang masih pending ke rekening yang sama
+	ManualAmountInUse(account string, amount float64) (bool, error)
+	// CreateManualTransfer seperti Create, tetapi mengembalikan false tanpa error jika nominal sudah
+	// dipakai transfer pending lain ke rekening yang sama (dijaga unique index parsial)
+	CreateManualTransfer(donation *models.Donation) (bool, error)
+	// GuestByEmailForUpdate mengunci donasi tamu (tanpa akun) dengan email donatur tersebut
+	GuestByEmailForUpdate(email string) ([]models.Donation, error)
+	// LinkUser menautkan donasi tamu ke akun; kontak tamu tetap disimpan sebagai riwayat
+	LinkUser(donation *models.Donation, userID int) error
+}
+
+// ErrFundTypeMismatch jenis dana donasi berbeda dengan jenis dana campaign
+var ErrFundTypeMismatch = errors.New("donation fund type does not match the campaign")
+
+// ErrDonationCredited nominal, campaign, donatur, jenis dana dan order donasi yang sudah dikredit
+// tidak boleh diubah, dan donasinya tidak boleh dihapus sebelum di-refund
+var ErrDonationCredited = errors.New("donation has been credited to the campaign")
+
+// stampFundType mengisi jenis dana donasi dari campaign agar dana tidak pernah tercampur
+func stampFundType(tx *gorm.DB, donation *models.Donation) error {
+	var fundType string
+	err := tx.Model(&models.Campaign{}).Where("id = ?", donation.CampaignID).Pluck("fund_type", &fundType).Error
+	if err != nil {
+		return err
+	}
+	if fundType == "" {
+		fundType = models.DefaultFundType
+	}
+	if donation.FundType != "" && donation.FundType != fundType {
+		return ErrFundTypeMismatch
+	}
+	donation.FundType = fundType
+	return nil
 }
 
 type donationRepository struct {
@@ -279,37 +420,108 @@ func NewDonationRepository(db *gorm.DB) DonationRepository {
 	return &donationRepository{db: db}
 }
 
+func (r *donationRepository) WithContext(ctx context.Context) DonationRepository {
+	return &donationRepository{db: r.db.WithContext(ctx)}
+}
+
 func (r *donationRepository) Create(donation *models.Donation) error {
-	return r.db.Create(donation).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := stampFundType(tx, donation); err != nil {
+			return err
+		}
+		if err := tx.Create(donation).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, models.AuditActionCreate, models.AuditEntityDonation, donation.ID, nil, donation)
+	})
 }
 
 func (r *donationRepository) GetAll() ([]models.Donation, error) {
 	var donations []models.Donation
-	err := r.db.Preload("User").Preload("Campaign").Find(&donations).Error
+	err := r.db.Preload("User").Preload("Offline").Preload("Campaign").Find(&donations).Error
 	return donations, err
 }
 
 func (r *donationRepository) GetByID(id uint) (*models.Donation, error) {
 	var donation models.Donation
-	err := r.db.Preload("User").Preload("Campaign").First(&donation, id).Error
+	err := r.db.Preload("User").Preload("Offline").Preload("Campaign").First(&donation, id).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, nil
 	}
 	return &donation, err
 }
 
+// Update menyimpan perubahan dan mencatat field yang berubah di audit log.
+// Status, credited_at, dan refunded_amount hanya diubah lewat UpdateStatus (DonationService);
+// setelah donasi dikredit, perubahan nominal/campaign/donatur/jenis dana/order ditolak (ErrDonationCredited).
 func (r *donationRepository) Update(donation *models.Donation) error {
-	return r.db.Save(donation).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var before models.Donation
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&before, donation.ID).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil {
+			if before.CreditedAt != nil && creditedFieldsChanged(&before, donation) {
+				return ErrDonationCredited
+			}
+			donation.Status, donation.CreditedAt, donation.RefundedAmount = before.Status, before.CreditedAt, before.RefundedAmount
+		}
+		if err := stampFundType(tx, donation); err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations, "Status", "CreditedAt", "RefundedAmount").Save(donation).Error; err != nil {
+			return err
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return recordAudit(tx, models.AuditActionCreate, models.AuditEntityDonation, donation.ID, nil, donation)
+		}
+		return recordAudit(tx, models.AuditActionUpdate, models.AuditEntityDonation, donation.ID, &before, donation)
+	})
+}
+
+// creditedFieldsChanged field yang menentukan total campaign dan alokasi dana
+func creditedFieldsChanged(before, after *models.Donation) bool {
+	return before.Amount != after.Amount ||
+		before.CampaignID != after.CampaignID ||
+		before.FundType != after.FundType ||
+		before.OrderID != after.OrderID ||
+		!sameUserID(before.UserID, after.UserID)
+}
+
+func sameUserID(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
 }
 
+// Delete menghapus data dan menyimpan salinan terakhirnya di audit log. Donasi yang masih
+// terhitung di total campaign harus di-refund dulu (ErrDonationCredited).
 func (r *donationRepository) Delete(id uint) error {
-	return r.db.Delete(&models.Donation{}, id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var before models.Donation
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&before, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		if before.Status == models.DonationStatusSuccess && before.CreditedAt != nil {
+			return ErrDonationCredited
+		}
+		if err := tx.Delete(&models.Donation{}, id).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, models.AuditActionDelete, models.AuditEntityDonation, before.ID, &before, nil)
+	})
 }
 
 func (r *donationRepository) GetAllWithDetails() ([]models.Donation, error) {
 	var donations []models.Donation
 	err := r.db.
 		Preload("User").
+		Preload("Offline").
 		Preload("Campaign").
 		Order("created_at DESC").
 		Find(&donations).Error
@@ -321,6 +533,7 @@ func (r *donationRepository) GetByUser(userID uint) ([]models.Donation, error) {
 	err := r.db.
 		Where("user_id = ?", userID).
 		Preload("User").
+		Preload("Offline").
 		Preload("Campaign").
 		Order("created_at DESC").
 		Find(&donations).Error
@@ -332,6 +545,7 @@ func (r *donationRepository) GetByCampaign(campaignID uint) ([]models.Donation,
 	err := r.db.
 		Where("campaign_id = ?", campaignID).
 		Preload("User").
+		Preload("Offline").
 		Preload("Campaign").
 		Order("created_at DESC").
 		Find(&donations).Error
@@ -348,7 +562,7 @@ func (r *donationRepository) CountPaid() (int64, error) {
 	var count int64
 	err := r.db.
 		Model(&models.Donation{}).
-		Where("status = ?", "paid").
+		Where("status = ?", models.DonationStatusSuccess).
 		Count(&count).Error
 	return count, err
 }
@@ -357,12 +571,56 @@ func (r *donationRepository) SumPaidAmount() (float64, error) {
 	var total float64
 	err := r.db.
 		Model(&models.Donation{}).
-		Select("SUM(amount)").
-		Where("status = ?", "paid").
+		Select("COALESCE(SUM(amount - refunded_amount), 0)").
+		Where("status = ?", models.DonationStatusSuccess).
 		Scan(&total).Error
 	return total, err
 }
 
+// ChannelTotal jumlah transaksi dan nominal donasi sukses untuk satu channel
+type ChannelTotal struct {
+	Channel string  `json:"channel"`
+	Count   int64   `json:"count"`
+	Amount  float64 `json:"amount"`
+}
+
+func (r *donationRepository) SumPaidByChannel() ([]ChannelTotal, error) {
+	var totals []ChannelTotal
+	err := r.db.
+		Model(&models.Donation{}).
+		Select("channel, COUNT(*) AS count, COALESCE(SUM(amount - refunded_amount), 0) AS amount").
+		Where("status = ?", models.DonationStatusSuccess).
+		Group("channel").
+		Order("channel").
+		Scan(&totals).Error
+	return totals, err
+}
+
+// FundTotal jumlah transaksi dan nominal donasi sukses untuk satu jenis dana dan channel
+type FundTotal struct {
+	FundType string  `json:"fund_type"`
+	Channel  string  `json:"channel"`
+	Count    int64   `json:"count"`
+	Amount   float64 `json:"amount"`
+}
+
+func (r *donationRepository) SumPaidByFund(from, to *time.Time) ([]FundTotal, error) {
+	query := r.db.
+		Model(&models.Donation{}).
+		Select("fund_type, channel, COUNT(*) AS count, COALESCE(SUM(amount - refunded_amount), 0) AS amount").
+		Where("status = ?", models.DonationStatusSuccess)
+	if from != nil {
+		query = query.Where("credited_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("credited_at < ?", *to)
+	}
+
+	var totals []FundTotal
+	err := query.Group("fund_type, channel").Order("fund_type, channel").Scan(&totals).Error
+	return totals, err
+}
+
 func (r *donationRepository) CountByCampaign(campaignID uint) (int64, error) {
 	var count int64
 	err := r.db.Model(&models.Donation{}).
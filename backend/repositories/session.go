@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+	"zakat/models"
+
+	"gorm.io/gorm"
+)
+
+// ==================== Session Repository ====================
+
+type SessionRepository interface {
+	Create(session *models.Session) error
+	GetByID(id string) (*models.Session, error)
+	GetByRefreshTokenHash(hash string) (*models.Session, error)
+	GetByPreviousTokenHash(hash string) (*models.Session, error)
+	Rotate(session *models.Session, newHash string, expiresAt time.Time) error
+	Revoke(id, reason string) error
+	RevokeAllForUser(userID int, reason string) error
+	RevokeAllForUserExcept(userID int, keepID, reason string) error
+	IsSessionActive(id string) (bool, error)
+	MarkMFAVerified(id string) error
+	DeleteExpired() error
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(session *models.Session) error {
+	return r.db.Create(session).Error
+}
+
+func (r *sessionRepository) GetByID(id string) (*models.Session, error) {
+	var session models.Session
+	err := r.db.Where("id = ?", id).First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) GetByRefreshTokenHash(hash string) (*models.Session, error) {
+	var session models.Session
+	err := r.db.Where("refresh_token_hash = ?", hash).First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) GetByPreviousTokenHash(hash string) (*models.Session, error) {
+	var session models.Session
+	err := r.db.Where("previous_token_hash = ?", hash).First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Rotate mengganti refresh token sesi. Update hanya berhasil jika hash lama
+// masih sama, sehingga dua request refresh bersamaan tidak bisa sama-sama lolos.
+func (r *sessionRepository) Rotate(session *models.Session, newHash string, expiresAt time.Time) error {
+	now := time.Now()
+
+	result := r.db.Model(&models.Session{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", session.ID, session.RefreshTokenHash).
+		Updates(map[string]interface{}{
+			"previous_token_hash": session.RefreshTokenHash,
+			"refresh_token_hash":  newHash,
+			"last_used_at":        now,
+			"expires_at":          expiresAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("session already rotated or revoked")
+	}
+
+	session.PreviousTokenHash = session.RefreshTokenHash
+	session.RefreshTokenHash = newHash
+	session.LastUsedAt = now
+	session.ExpiresAt = expiresAt
+	return nil
+}
+
+func (r *sessionRepository) Revoke(id, reason string) error {
+	return r.db.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_reason": reason}).Error
+}
+
+func (r *sessionRepository) RevokeAllForUser(userID int, reason string) error {
+	return r.db.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_reason": reason}).Error
+}
+
+func (r *sessionRepository) RevokeAllForUserExcept(userID int, keepID, reason string) error {
+	return r.db.Model(&models.Session{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, keepID).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_reason": reason}).Error
+}
+
+// IsSessionActive dipakai middleware.Auth untuk menolak token dari sesi yang sudah dicabut
+func (r *sessionRepository) IsSessionActive(id string) (bool, error) {
+	session, err := r.GetByID(id)
+	if err != nil {
+		return false, err
+	}
+	return session != nil && session.IsActive(), nil
+}
+
+func (r *sessionRepository) MarkMFAVerified(id string) error {
+	return r.db.Model(&models.Session{}).Where("id = ?", id).Update("mfa_verified", true).Error
+}
+
+func (r *sessionRepository) DeleteExpired() error {
+	return r.db.Where("expires_at < ?", time.Now()).Delete(&models.Session{}).Error
+}
@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+	"zakat/models"
+	"zakat/pkg/throttle"
+
+	"gorm.io/gorm"
+)
+
+// ==================== Throttle Repository ====================
+
+// ThrottleRepository adalah throttle.Store berbasis database, sehingga
+// hitungan percobaan gagal tetap berlaku di semua instance server
+type ThrottleRepository interface {
+	throttle.Store
+	// DeleteExpired menghapus record yang window-nya dimulai sebelum batas dan tidak sedang dikunci
+	DeleteExpired(windowStartBefore time.Time) error
+}
+
+type throttleRepository struct {
+	db *gorm.DB
+}
+
+func NewThrottleRepository(db *gorm.DB) ThrottleRepository {
+	return &throttleRepository{db: db}
+}
+
+func (r *throttleRepository) Get(key string) (*throttle.Record, error) {
+	var record models.ThrottleRecord
+	err := r.db.Where("key = ?", key).First(&record).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &throttle.Record{
+		Key:         record.Key,
+		Failures:    record.Failures,
+		WindowStart: record.WindowStart,
+		LockedUntil: record.LockedUntil,
+	}, nil
+}
+
+// Increment satu pernyataan INSERT ... ON CONFLICT sehingga kegagalan dari beberapa instance
+// sekaligus tidak saling menimpa
+func (r *throttleRepository) Increment(key string, now time.Time, window time.Duration) (*throttle.Record, error) {
+	var record models.ThrottleRecord
+	err := r.db.Raw(`
+		INSERT INTO throttle_records (key, failures, window_start, locked_until, updated_at)
+		VALUES (@key, 1, @now, @zero, @now)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN throttle_records.window_start < @reset AND throttle_records.locked_until <= @now
+				THEN 1 ELSE throttle_records.failures + 1 END,
+			window_start = CASE WHEN throttle_records.window_start < @reset AND throttle_records.locked_until <= @now
+				THEN @now ELSE throttle_records.window_start END,
+			updated_at = @now
+		RETURNING key, failures, window_start, locked_until, updated_at`,
+		map[string]interface{}{
+			"key":   key,
+			"now":   now,
+			"zero":  time.Time{},
+			"reset": now.Add(-window),
+		}).Scan(&record).Error
+	if err != nil {
+		return nil, err
+	}
+	return &throttle.Record{
+		Key:         record.Key,
+		Failures:    record.Failures,
+		WindowStart: record.WindowStart,
+		LockedUntil: record.LockedUntil,
+	}, nil
+}
+
+func (r *throttleRepository) Lock(key string, until time.Time) error {
+	return r.db.Model(&models.ThrottleRecord{}).
+		Where("key = ? AND locked_until < ?", key, until).
+		Updates(map[string]interface{}{"locked_until": until, "updated_at": time.Now()}).Error
+}
+
+func (r *throttleRepository) Delete(key string) error {
+	return r.db.Where("key = ?", key).Delete(&models.ThrottleRecord{}).Error
+}
+
+func (r *throttleRepository) DeleteExpired(windowStartBefore time.Time) error {
+	return r.db.Where("window_start < ? AND locked_until < ?", windowStartBefore, time.Now()).
+		Delete(&models.ThrottleRecord{}).Error
+}
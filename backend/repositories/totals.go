@@ -0,0 +1,207 @@
+package repositories
+
+import (
+	"math"
+	"time"
+	"zakat/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ==================== Campaign Totals ====================
+
+// CampaignTotals total yang tersimpan di campaign dibandingkan dengan hasil hitung ulang dari donasi sukses
+type CampaignTotals struct {
+	CampaignID     int     `json:"campaign_id"`
+	Title          string  `json:"title"`
+	TotalCollected float64 `json:"total_collected"`
+	NetCollected   float64 `json:"net_collected"`
+	DonorCount     int     `json:"donor_count"`
+	ExpectedTotal  float64 `json:"expected_total"`
+	ExpectedNet    float64 `json:"expected_net"`
+	ExpectedDonors int     `json:"expected_donors"`
+}
+
+// Drift true jika total, net atau jumlah donatur tersimpan berbeda dengan hasil hitung ulang
+func (t CampaignTotals) Drift() bool {
+	return math.Abs(t.TotalCollected-t.ExpectedTotal) >= 0.01 ||
+		math.Abs(t.NetCollected-t.ExpectedNet) >= 0.01 ||
+		t.DonorCount != t.ExpectedDonors
+}
+
+// GetForUpdate mengunci baris campaign sampai transaksi selesai
+func (r *campaignRepository) GetForUpdate(id uint) (*models.Campaign, error) {
+	var campaign models.Campaign
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&campaign, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+// AdjustTotals menambah (atau mengurangi, jika negatif) total_collected (bruto), net_collected
+// (porsi program) dan donor_count langsung di database, sehingga kredit yang berjalan bersamaan
+// tidak saling menimpa
+func (r *campaignRepository) AdjustTotals(id uint, gross, net float64, donors int) error {
+	return r.updateTotals(id, map[string]interface{}{
+		"total_collected": gorm.Expr("total_collected + ?", gross),
+		"net_collected":   gorm.Expr("net_collected + ?", net),
+		"donor_count":     gorm.Expr("donor_count + ?", donors),
+	})
+}
+
+// SetTotals menimpa total dengan nilai hasil rekonsiliasi
+func (r *campaignRepository) SetTotals(id uint, gross, net float64, donors int) error {
+	return r.updateTotals(id, map[string]interface{}{
+		"total_collected": gross,
+		"net_collected":   net,
+		"donor_count":     donors,
+	})
+}
+
+func (r *campaignRepository) updateTotals(id uint, values map[string]interface{}) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var before, after models.Campaign
+		if err := tx.First(&before, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Campaign{}).Where("id = ?", id).Updates(values).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&after, id).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, models.AuditActionUpdate, models.AuditEntityCampaign, after.ID, &before, &after)
+	})
+}
+
+// ComputeTotals menghitung ulang total (dikurangi refund parsial) dan jumlah donatur unik setiap campaign
+// dari donasi sukses; net dihitung dari baris alokasi porsi program
+func (r *campaignRepository) ComputeTotals() ([]CampaignTotals, error) {
+	var totals []CampaignTotals
+	err := r.db.Table("campaigns c").
+		Select(`c.id AS campaign_id, c.title, c.total_collected, c.net_collected, c.donor_count,
+			COALESCE(SUM(d.amount - d.refunded_amount), 0) AS expected_total,
+			COALESCE((SELECT SUM(a.amount) FROM donation_allocations a
+				JOIN donations ad ON ad.id = a.donation_id AND ad.deleted_at IS NULL
+				WHERE a.campaign_id = c.id AND a.portion = ?), 0) AS expected_net,
+			COUNT(DISTINCT d.user_id) + COUNT(d.id) FILTER (WHERE d.user_id IS NULL) AS expected_donors`, models.AllocationProgram).
+		Joins("LEFT JOIN donations d ON d.campaign_id = c.id AND d.status = ? AND d.deleted_at IS NULL", models.DonationStatusSuccess).
+		Where("c.deleted_at IS NULL").
+		Group("c.id").
+		Order("c.id").
+		Scan(&totals).Error
+	return totals, err
+}
+
+// ==================== Donation Status ====================
+
+// GetForUpdate mengunci baris donasi sampai transaksi selesai
+func (r *donationRepository) GetForUpdate(id uint) (*models.Donation, error) {
+	return r.lock("id = ?", id)
+}
+
+func (r *donationRepository) GetByOrderIDForUpdate(orderID string) (*models.Donation, error) {
+	return r.lock("order_id = ?", orderID)
+}
+
+func (r *donationRepository) lock(query string, args ...interface{}) (*models.Donation, error) {
+	var donation models.Donation
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where(query, args...).First(&donation).Error
+	if err != nil {
+		return nil, err
+	}
+	return &donation, nil
+}
+
+// UpdateStatus hanya menyimpan kolom status pembayaran, bukan seluruh baris
+func (r *donationRepository) UpdateStatus(donation *models.Donation) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var before models.Donation
+		if err := tx.First(&before, donation.ID).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&models.Donation{}).Where("id = ?", donation.ID).Updates(map[string]interface{}{
+			"status":          donation.Status,
+			"payment_method":  donation.PaymentMethod,
+			"credited_at":     donation.CreditedAt,
+			"refunded_amount": donation.RefundedAmount,
+			"updated_at":      donation.UpdatedAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, models.AuditActionUpdate, models.AuditEntityDonation, donation.ID, &before, donation)
+	})
+}
+
+// HasOtherSuccessful mengecek apakah user sudah punya donasi sukses lain di campaign yang sama,
+// dipakai untuk menentukan apakah donor_count perlu bertambah/berkurang.
+// Donasi tanpa akun (offline) selalu dihitung sebagai donatur tersendiri.
+func (r *donationRepository) HasOtherSuccessful(campaignID int, userID *int, excludeID int) (bool, error) {
+	if userID == nil {
+		return false, nil
+	}
+	var count int64
+	err := r.db.Model(&models.Donation{}).
+		Where("campaign_id = ? AND user_id = ? AND id <> ? AND status = ?", campaignID, userID, excludeID, models.DonationStatusSuccess).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// RecordNotification menyimpan notifikasi yang sudah diproses; false jika sudah pernah dicatat
+func (r *donationRepository) RecordNotification(notification *models.PaymentNotification) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(notification)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *donationRepository) ManualAmountInUse(account string, amount float64) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Donation{}).
+		Where("transfer_account = ? AND payment_provider = ? AND status = ? AND amount = ?",
+			account, models.PaymentProviderManual, models.DonationStatusPending, amount).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *donationRepository) CreateManualTransfer(donation *models.Donation) (bool, error) {
+	created := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := stampFundType(tx, donation); err != nil {
+			return err
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(donation)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		created = true
+		return recordAudit(tx, models.AuditActionCreate, models.AuditEntityDonation, donation.ID, nil, donation)
+	})
+	return created, err
+}
+
+func (r *donationRepository) GuestByEmailForUpdate(email string) ([]models.Donation, error) {
+	var donations []models.Donation
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id IS NULL AND LOWER(donor_email) = LOWER(?)", email).
+		Order("id").
+		Find(&donations).Error
+	return donations, err
+}
+
+func (r *donationRepository) LinkUser(donation *models.Donation, userID int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		before := *donation
+		donation.UserID = &userID
+		donation.UpdatedAt = time.Now()
+		err := tx.Model(&models.Donation{}).Where("id = ?", donation.ID).Updates(map[string]interface{}{
+			"user_id":    userID,
+			"updated_at": donation.UpdatedAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, models.AuditActionUpdate, models.AuditEntityDonation, donation.ID, &before, donation)
+	})
+}
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+	"zakat/models"
+
+	"gorm.io/gorm"
+)
+
+// ==================== Transfer Proof Repository ====================
+
+// ErrProofAlreadyReviewed dikembalikan jika bukti transfer sudah disetujui/ditolak sebelumnya
+var ErrProofAlreadyReviewed = errors.New("transfer proof has already been reviewed")
+
+type TransferProofRepository interface {
+	Create(proof *models.TransferProof) error
+	GetByID(id uint) (*models.TransferProof, error)
+	List(status string, limit, offset int) ([]models.TransferProof, int64, error)
+	// Review mengubah status bukti yang masih submitted; ErrProofAlreadyReviewed jika sudah diproses
+	Review(id uint, status string, reviewerID int, reason string) error
+}
+
+type transferProofRepository struct {
+	db *gorm.DB
+}
+
+func NewTransferProofRepository(db *gorm.DB) TransferProofRepository {
+	return &transferProofRepository{db: db}
+}
+
+func (r *transferProofRepository) Create(proof *models.TransferProof) error {
+	return r.db.Create(proof).Error
+}
+
+func (r *transferProofRepository) GetByID(id uint) (*models.TransferProof, error) {
+	var proof models.TransferProof
+	err := r.db.Preload("Donation.User").Preload("Donation.Campaign").First(&proof, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &proof, nil
+}
+
+func (r *transferProofRepository) List(status string, limit, offset int) ([]models.TransferProof, int64, error) {
+	query := r.db.Model(&models.TransferProof{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Antrian verifikasi: yang paling lama menunggu di atas
+	var proofs []models.TransferProof
+	err := query.Preload("Donation.User").Preload("Donation.Campaign").
+		Order("created_at").Limit(limit).Offset(offset).Find(&proofs).Error
+	return proofs, total, err
+}
+
+func (r *transferProofRepository) Review(id uint, status string, reviewerID int, reason string) error {
+	now := time.Now()
+	result := r.db.Model(&models.TransferProof{}).
+		Where("id = ? AND status = ?", id, models.TransferProofSubmitted).
+		Updates(map[string]interface{}{
+			"status":         status,
+			"reviewed_by_id": reviewerID,
+			"reviewed_at":    now,
+			"reject_reason":  reason,
+			"updated_at":     now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrProofAlreadyReviewed
+	}
+	return nil
+}
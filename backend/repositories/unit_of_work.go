@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// ==================== Unit of Work ====================
+
+// Repositories kumpulan repository yang berbagi satu transaksi database
+type Repositories struct {
+	Users          UserRepository
+	Campaigns      CampaignRepository
+	Donations      DonationRepository
+	TransferProofs TransferProofRepository
+	Offline        OfflineDonationRepository
+	Refunds        RefundRepository
+	Allocations    AllocationRepository
+}
+
+// UnitOfWork menjalankan beberapa operasi repository dalam satu transaksi.
+// Jika fn mengembalikan error, semua perubahan dibatalkan.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(repos Repositories) error) error
+}
+
+type unitOfWork struct {
+	db *gorm.DB
+}
+
+func NewUnitOfWork(db *gorm.DB) UnitOfWork {
+	return &unitOfWork{db: db}
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(repos Repositories) error) error {
+	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(Repositories{
+			Users:          NewUserRepository(tx),
+			Campaigns:      NewCampaignRepository(tx),
+			Donations:      NewDonationRepository(tx),
+			TransferProofs: NewTransferProofRepository(tx),
+			Offline:        NewOfflineDonationRepository(tx),
+			Refunds:        NewRefundRepository(tx),
+			Allocations:    NewAllocationRepository(tx),
+		})
+	})
+}
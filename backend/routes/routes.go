@@ -1,12 +1,22 @@
 package routes
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
 	"net/http"
+	"os"
+	"time"
 
 	"zakat/handlers"
-	"zakat/pkg/bcrypt"
+	"zakat/models"
+	"zakat/pkg/docstore"
 	"zakat/pkg/middleware"
 	"zakat/pkg/midtrans"
+	"zakat/pkg/payment"
+	"zakat/pkg/response"
+	"zakat/pkg/throttle"
 	"zakat/repositories"
 	"zakat/services"
 
@@ -29,88 +39,337 @@ func InitRouter(e *echo.Echo, db *gorm.DB) {
 	donationRepo := repositories.NewDonationRepository(db)
 
 	passwordRepo := repositories.NewPasswordResetRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
+	emailVerificationRepo := repositories.NewEmailVerificationRepository(db)
+	otpRepo := repositories.NewPhoneOTPRepository(db)
+	recoveryCodeRepo := repositories.NewRecoveryCodeRepository(db)
+	adminInviteRepo := repositories.NewAdminInviteRepository(db)
+	auditRepo := repositories.NewAuditRepository(db)
+	reconciliationRepo := repositories.NewReconciliationRepository(db)
+	transferProofRepo := repositories.NewTransferProofRepository(db)
+	offlineDonationRepo := repositories.NewOfflineDonationRepository(db)
+	refundRepo := repositories.NewRefundRepository(db)
+	donationPlanRepo := repositories.NewDonationPlanRepository(db)
+
+	// Throttling login & reset password; pakai database jika server berjalan lebih dari satu instance
+	throttleWindow := throttle.LongestWindow(handlers.ThrottlePolicies)
+	var throttleStore throttle.Store = throttle.NewMemoryStore(throttleWindow)
+	var throttleRepo repositories.ThrottleRepository
+	if os.Getenv("THROTTLE_STORE") == "database" {
+		throttleRepo = repositories.NewThrottleRepository(db)
+		throttleStore = throttleRepo
+	}
+	limiter := throttle.NewLimiter(throttleStore, handlers.ThrottlePolicies)
+
+	// Sesi, OTP, token verifikasi/reset dan record throttle yang kedaluwarsa dihapus berkala
+	cleaner := services.NewCleaner(services.CleanupConfigFromEnv(throttleWindow), sessionRepo, otpRepo,
+		emailVerificationRepo, passwordRepo, throttleRepo)
+	cleaner.Start(context.Background())
+
+	// Auth menolak access token dari sesi yang sudah dicabut
+	middleware.SetSessionVerifier(sessionRepo)
+
 	// Services
-	paymentService := services.NewPaymentService()
+	paymentService := services.NewPaymentService(paymentProviders(e))
+
+	// Status donasi dan total campaign diubah dalam satu transaksi; setiap kredit dibagi
+	// menjadi porsi program, amil dan biaya gateway sesuai jenis dana
+	allocationRepo := repositories.NewAllocationRepository(db)
+	donationService := services.NewDonationService(repositories.NewUnitOfWork(db), services.NewAllocator(services.AllocationConfigFromEnv()))
 
 	emailService := services.NewEmailService()
 
 	whatsappService := services.NewWhatsAppService()
 
+	smsService := services.NewSMSService()
+
+	// Refund lewat gateway; donatur diberi tahu lewat email
+	refundService := services.NewRefundService(donationService, paymentService, donationRepo, refundRepo, emailService)
+
+	// Cek berkala donasi pending ke Midtrans untuk notifikasi yang hilang, dan refund yang hasilnya belum pasti
+	reconciler := services.NewReconciler(services.ReconcilerConfigFromEnv(), reconciliationRepo, paymentService, donationService, refundService)
+	reconciler.Start(context.Background())
+
+	// Donasi rutin: tagihan tiap periode dan pengingat link yang belum dibayar
+	planScheduler := services.NewPlanScheduler(services.PlanSchedulerConfigFromEnv(), donationPlanRepo, donationRepo,
+		campaignRepo, userRepo, paymentService, emailService)
+	planScheduler.Start(context.Background())
+
+	// Kalkulator zakat; harga emas/perak/beras dari riwayat harga referensi, env sebagai cadangan
+	referenceRateRepo := repositories.NewReferenceRateRepository(db)
+	referenceRates := services.NewReferenceRates(referenceRateRepo, services.ZakatRatesFromEnv(), services.RegionalRateMaxAgeFromEnv())
+	zakatService := services.NewZakatService(services.ZakatConfigFromEnv(), referenceRates)
+	rateImporter := services.NewRateImporter(services.RateImportConfigFromEnv(), referenceRateRepo)
+	rateImporter.Start(context.Background())
+
+	// Dokumen mustahik (KTP/KK/SKTM) disimpan privat; URL unduhan hanya berlaku beberapa menit
+	documentStore, err := docstore.NewFromEnv("mustahik", 5*time.Minute)
+	if err != nil {
+		log.Printf("[Docstore] Mustahik document upload disabled: %v", err)
+	}
+
 	// Handlers
 	handler := handlers.NewHandler(userRepo, campaignRepo, donationRepo, paymentService, passwordRepo,
 		emailService,
-		whatsappService)
+		whatsappService,
+		sessionRepo,
+		limiter,
+		emailVerificationRepo,
+		otpRepo,
+		smsService,
+		recoveryCodeRepo,
+		adminInviteRepo,
+		auditRepo,
+		donationService,
+		reconciliationRepo,
+		reconciler,
+		transferProofRepo,
+		offlineDonationRepo,
+		refundRepo,
+		refundService,
+		donationPlanRepo,
+		planScheduler,
+		zakatService,
+		referenceRateRepo,
+		referenceRates,
+		rateImporter,
+		allocationRepo,
+		repositories.NewMustahikRepository(db),
+		documentStore)
+
+	// API v1: format response lama (code/data), tetap dipakai client yang sudah ada
+	api := e.Group("/api/v1", middleware.AuditContext)
+	registerRoutes(api, handler)
+
+	// API v2: semua response (sukses maupun error) memakai envelope pkg/response
+	registerRoutes(e.Group("/api/v2", response.Envelope, middleware.AuditContext), handler)
+}
+
+// paymentProviders mendaftarkan payment provider sesuai environment. Midtrans selalu aktif,
+// Xendit jika XENDIT_SECRET_KEY diisi, dan gateway palsu jika PAYMENT_FAKE_GATEWAY=true
+// (untuk development/pengujian tanpa jaringan). PAYMENT_PROVIDER memilih provider donasi baru.
+func paymentProviders(e *echo.Echo) *payment.Registry {
+	registry := payment.NewRegistry()
+	registry.Register(payment.NewMidtrans())
 
-	// API Routes
-	api := e.Group("/api/v1")
+	frontendURL := os.Getenv("FRONTEND_URL")
+
+	if secretKey := os.Getenv("XENDIT_SECRET_KEY"); secretKey != "" {
+		registry.Register(payment.NewXendit(secretKey, os.Getenv("XENDIT_CALLBACK_TOKEN"), frontendURL, frontendURL))
+	}
+
+	if os.Getenv("PAYMENT_FAKE_GATEWAY") == "true" {
+		port := os.Getenv("PORT")
+		if port == "" {
+			port = "8080"
+		}
+		baseURL := os.Getenv("PAYMENT_FAKE_BASE_URL")
+		if baseURL == "" {
+			baseURL = "http://localhost:" + port + "/fake-gateway"
+		}
+		webhookURL := os.Getenv("PAYMENT_FAKE_WEBHOOK_URL")
+		if webhookURL == "" {
+			webhookURL = "http://localhost:" + port + "/api/v1/donations/notifications/" + models.PaymentProviderFake
+		}
+
+		gateway := payment.NewFakeGateway(baseURL, webhookURL, frontendURL, fakeWebhookSecret())
+		registry.Register(gateway)
+		e.Any("/fake-gateway/*", echo.WrapHandler(http.StripPrefix("/fake-gateway", gateway)))
+		log.Println("⚠️  Fake payment gateway enabled at", baseURL)
+	}
+
+	if name := os.Getenv("PAYMENT_PROVIDER"); name != "" {
+		if err := registry.SetDefault(name); err != nil {
+			log.Fatal("Invalid PAYMENT_PROVIDER: ", err)
+		}
+	}
+	return registry
+}
+
+// fakeWebhookSecret PAYMENT_FAKE_WEBHOOK_SECRET, atau secret acak per proses jika kosong.
+// Sengaja tidak memakai SECRET_KEY JWT agar tanda tangan webhook palsu tidak ikut membocorkannya.
+func fakeWebhookSecret() string {
+	if secret := os.Getenv("PAYMENT_FAKE_WEBHOOK_SECRET"); secret != "" {
+		return secret
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		log.Fatal("Failed to generate fake gateway webhook secret: ", err)
+	}
+	return hex.EncodeToString(buf)
+}
 
+// registerRoutes mendaftarkan semua endpoint ke grup versi API
+func registerRoutes(api *echo.Group, handler *handlers.Handler) {
 	// Passord
 	api.POST("/forgot-password", handler.ForgotPassword)
 	api.POST("/reset-password", handler.ResetPassword)
 	api.GET("/verify-reset-token", handler.VerifyResetToken)
 
+	// Verifikasi email
+	api.GET("/verify-email", handler.VerifyEmail)
+	api.POST("/verify-email", handler.VerifyEmail)
+	api.POST("/verify-email/resend", middleware.Auth(handler.ResendEmailVerification))
+
+	// Login OTP WhatsApp/SMS dan verifikasi nomor HP
+	api.POST("/otp/request", handler.RequestLoginOTP)
+	api.POST("/otp/verify", handler.VerifyLoginOTP)
+	api.POST("/phone/verification", middleware.Auth(handler.RequestPhoneVerification))
+	api.POST("/phone/verification/confirm", middleware.Auth(handler.ConfirmPhoneVerification))
+
+	// Two-factor authentication (TOTP)
+	api.POST("/2fa/verify", handler.VerifyTwoFactor)
+	api.POST("/2fa/setup", middleware.Auth(handler.SetupTwoFactor))
+	api.POST("/2fa/enable", middleware.Auth(handler.EnableTwoFactor))
+	api.POST("/2fa/disable", middleware.Auth(handler.DisableTwoFactor))
+	api.POST("/2fa/recovery-codes", middleware.Auth(handler.RegenerateRecoveryCodes))
+
 	api.GET("/check-auth", middleware.Auth(handler.CheckAuth))
 
 	// PATCH image
 	api.PATCH("/change-image", middleware.Auth(middleware.UploadFile("photo")(handler.ChangeProfileImage)))
 
-	api.POST("/verify-password", func(c echo.Context) error {
-		var req struct {
-			Password string `json:"password"`
-			Hash     string `json:"hash"`
-		}
-		if err := c.Bind(&req); err != nil {
-			return err
-		}
-
-		match := bcrypt.CheckPasswordHash(req.Password, req.Hash)
-		return c.JSON(http.StatusOK, map[string]interface{}{
-			"match":    match,
-			"password": req.Password,
-			"hash":     req.Hash,
-		})
-	})
-
 	api.POST("/signup", handler.CreateUser)
 	api.POST("/signin", handler.SignIn)
-	api.GET("/admin-count", handler.GetAdminCount)
+	api.POST("/refresh", handler.RefreshToken)
+	api.POST("/logout", middleware.Auth(handler.Logout))
+	api.POST("/logout-all", middleware.Auth(handler.LogoutAll))
 
 	// User routes
 	userRoutes := api.Group("/users")
 	{
 		userRoutes.POST("", handler.CreateUser)
-		userRoutes.GET("", handler.GetAllUsers)
-		userRoutes.GET("/:id", handler.GetUser)
-		userRoutes.PUT("/:id", handler.UpdateUser)
-		userRoutes.DELETE("/:id", handler.DeleteUser)
+		userRoutes.GET("", middleware.Protect(middleware.PermUserRead, handler.GetAllUsers))
 		userRoutes.PUT("/change-password", middleware.Auth(handler.ChangePassword))
+		// GET/PUT /:id: pemilik akun, atau user dengan users:read / users:manage (dicek di handler)
+		userRoutes.GET("/:id", middleware.Auth(handler.GetUser))
+		userRoutes.PUT("/:id", middleware.Auth(handler.UpdateUser))
+		userRoutes.PUT("/:id/role", middleware.Protect(middleware.PermUserManageRoles, handler.UpdateUserRole))
+		userRoutes.DELETE("/:id", middleware.Protect(middleware.PermUserManage, handler.DeleteUser))
+	}
+
+	// Undangan admin: hanya super admin yang bisa mengundang, siapa pun yang diundang menerima setelah login
+	inviteRoutes := api.Group("/admin/invites")
+	{
+		inviteRoutes.POST("", middleware.Protect(middleware.PermUserManageRoles, handler.CreateAdminInvite))
+		inviteRoutes.GET("", middleware.Protect(middleware.PermUserManageRoles, handler.GetAdminInvites))
+		inviteRoutes.DELETE("/:id", middleware.Protect(middleware.PermUserManageRoles, handler.RevokeAdminInvite))
+	}
+	api.POST("/invites/accept", middleware.Auth(handler.AcceptAdminInvite))
+
+	// Audit log perubahan data user, campaign dan donasi
+	auditRoutes := api.Group("/admin/audit-events")
+	{
+		auditRoutes.GET("", middleware.Protect(middleware.PermAuditRead, handler.GetAuditEvents))
+		auditRoutes.GET("/export", middleware.Protect(middleware.PermAuditRead, handler.ExportAuditEvents))
+	}
+
+	// Laporan rekonsiliasi pembayaran untuk tim keuangan
+	reconciliationRoutes := api.Group("/admin/reconciliations")
+	{
+		reconciliationRoutes.GET("", middleware.Protect(middleware.PermFinanceReport, handler.GetReconciliationRuns))
+		reconciliationRoutes.POST("", middleware.Protect(middleware.PermFinanceReport, handler.TriggerReconciliation))
+		reconciliationRoutes.GET("/:id", middleware.Protect(middleware.PermFinanceReport, handler.GetReconciliationRun))
 	}
 
 	// Campaign routes
 	campaignRoutes := api.Group("/campaigns")
 	{
-		campaignRoutes.POST("/add", middleware.Auth(middleware.UploadFile("photo")(handler.CreateCampaign)))
-		campaignRoutes.GET("", handler.GetAllCampaigns)
-		campaignRoutes.GET("/filter", handler.GetCampaignsByFilters)
-		campaignRoutes.GET("/:id", handler.GetCampaignByID)
-		campaignRoutes.PUT("/edit/:id", handler.UpdateCampaign)
-		campaignRoutes.DELETE("/:id", handler.DeleteCampaign)
-		campaignRoutes.GET("/:id/donations", handler.GetDonationsByCampaign)
-		campaignRoutes.POST("/:id/upload-photo", middleware.Auth(middleware.UploadFile("photo")(handler.UploadCampaignPhoto)))
+		campaignRoutes.POST("/add", middleware.Protect(middleware.PermCampaignCreate, middleware.UploadFile("photo")(handler.CreateCampaign)))
+		campaignRoutes.GET("", middleware.OptionalAuth(handler.GetAllCampaigns))
+		campaignRoutes.GET("/filter", middleware.OptionalAuth(handler.GetCampaignsByFilters))
+		campaignRoutes.GET("/:id", middleware.OptionalAuth(handler.GetCampaignByID))
+		campaignRoutes.PUT("/edit/:id", middleware.Protect(middleware.PermCampaignManage, handler.UpdateCampaign))
+		campaignRoutes.DELETE("/:id", middleware.Protect(middleware.PermCampaignManage, handler.DeleteCampaign))
+		campaignRoutes.GET("/:id/donations", middleware.OptionalAuth(handler.GetDonationsByCampaign))
+		campaignRoutes.POST("/:id/upload-photo", middleware.Protect(middleware.PermCampaignManage, middleware.UploadFile("photo")(handler.UploadCampaignPhoto)))
+	}
+
+	// Kalkulator zakat publik; hasilnya bisa langsung dipakai untuk POST /donations
+	zakatRoutes := api.Group("/zakat")
+	{
+		zakatRoutes.POST("/calculate", handler.CalculateZakat)
+		zakatRoutes.GET("/nisab", handler.GetZakatNisab)
+		zakatRoutes.GET("/rates", handler.GetReferenceRates)
+		zakatRoutes.GET("/rates/history", handler.GetReferenceRateHistory)
+	}
+
+	// Jenis dana (zakat, infaq, sedekah, ...) dan laporan penerimaan yang dipisah per jenis dana
+	api.GET("/funds", handler.GetFunds)
+	api.GET("/admin/reports/funds", middleware.Protect(middleware.PermFinanceReport, handler.GetFundReport))
+
+	// Harga referensi resmi (BAZNAS pusat/daerah) dan impor feed
+	rateRoutes := api.Group("/admin/reference-rates")
+	{
+		rateRoutes.POST("", middleware.Protect(middleware.PermReferenceRates, handler.SetReferenceRate))
+		rateRoutes.POST("/import", middleware.Protect(middleware.PermReferenceRates, handler.ImportReferenceRates))
+		rateRoutes.DELETE("/:id", middleware.Protect(middleware.PermReferenceRates, handler.DeleteReferenceRate))
+	}
+
+	// Registri mustahik (penerima manfaat); data pribadi, hanya untuk role amil
+	mustahikRoutes := api.Group("/mustahik")
+	{
+		mustahikRoutes.GET("", middleware.Protect(middleware.PermMustahikRead, handler.GetMustahikList))
+		mustahikRoutes.POST("", middleware.Protect(middleware.PermMustahikManage, handler.CreateMustahik))
+		mustahikRoutes.POST("/check-nik", middleware.Protect(middleware.PermMustahikManage, handler.CheckMustahikNIK))
+		mustahikRoutes.GET("/:id", middleware.Protect(middleware.PermMustahikRead, handler.GetMustahikByID))
+		mustahikRoutes.PUT("/:id", middleware.Protect(middleware.PermMustahikManage, handler.UpdateMustahik))
+		mustahikRoutes.DELETE("/:id", middleware.Protect(middleware.PermMustahikManage, handler.DeleteMustahik))
+		mustahikRoutes.POST("/:id/assessments", middleware.Protect(middleware.PermMustahikManage, handler.CreateMustahikAssessment))
+		mustahikRoutes.POST("/:id/documents", middleware.Protect(middleware.PermMustahikManage, handler.UploadMustahikDocument))
+		mustahikRoutes.DELETE("/:id/documents/:documentId", middleware.Protect(middleware.PermMustahikManage, handler.DeleteMustahikDocument))
+	}
+
+	// Sesi pengumpulan offline (mis. zakat fitrah di masjid): input donasi tunai/barang secara batch
+	collectionRoutes := api.Group("/collection-sessions")
+	{
+		collectionRoutes.GET("", middleware.Protect(middleware.PermDonationOffline, handler.GetCollectionSessions))
+		collectionRoutes.POST("", middleware.Protect(middleware.PermDonationOffline, handler.CreateCollectionSession))
+		collectionRoutes.GET("/:id", middleware.Protect(middleware.PermDonationOffline, handler.GetCollectionSession))
+		collectionRoutes.POST("/:id/donations", middleware.Protect(middleware.PermDonationOffline, handler.AddCollectionSessionDonations))
+	}
+
+	// Donasi rutin milik donatur; /admin/all untuk pengelola
+	planRoutes := api.Group("/donation-plans")
+	{
+		planRoutes.POST("", middleware.Protect(middleware.PermDonationCreate, handler.CreateDonationPlan))
+		planRoutes.GET("", middleware.Auth(handler.GetMyDonationPlans))
+		planRoutes.GET("/admin/all", middleware.Protect(middleware.PermDonationReadAll, handler.GetAllDonationPlans))
+		planRoutes.GET("/:id", middleware.Auth(handler.GetDonationPlan))
+		planRoutes.PATCH("/:id", middleware.Auth(handler.UpdateDonationPlan))
+		planRoutes.POST("/:id/pause", middleware.Auth(handler.PauseDonationPlan))
+		planRoutes.POST("/:id/resume", middleware.Auth(handler.ResumeDonationPlan))
+		planRoutes.POST("/:id/cancel", middleware.Auth(handler.CancelDonationPlan))
 	}
 
 	// Donation routes
 	donationRoutes := api.Group("/donations")
 	{
-		donationRoutes.POST("", handler.CreateDonation)
-		donationRoutes.GET("", handler.GetAllDonations)
-		donationRoutes.GET("/admin/all", middleware.Auth(handler.GetAllDonationsAdmin))
+		donationRoutes.POST("", middleware.Protect(middleware.PermDonationCreate, handler.CreateDonation))
+		// Checkout tamu tanpa akun; donatur anonim disamarkan di listing publik (OptionalAuth)
+		donationRoutes.POST("/guest", handler.CreateGuestDonation)
+		donationRoutes.POST("/link-guest", middleware.Auth(handler.LinkGuestDonations))
+		donationRoutes.GET("", middleware.OptionalAuth(handler.GetAllDonations))
+		donationRoutes.GET("/admin/all", middleware.Protect(middleware.PermDonationReadAll, handler.GetAllDonationsAdmin))
+		// Transfer manual: donatur unggah bukti, amil verifikasi lewat antrian
+		donationRoutes.GET("/transfers", middleware.Protect(middleware.PermDonationVerify, handler.GetTransferProofs))
+		donationRoutes.POST("/transfers/:id/approve", middleware.Protect(middleware.PermDonationVerify, handler.ApproveTransferProof))
+		donationRoutes.POST("/transfers/:id/reject", middleware.Protect(middleware.PermDonationVerify, handler.RejectTransferProof))
+		donationRoutes.POST("/offline", middleware.Protect(middleware.PermDonationOffline, handler.CreateOfflineDonation))
+		donationRoutes.GET("/refunds", middleware.Protect(middleware.PermDonationRefund, handler.GetRefunds))
+		donationRoutes.POST("/:id/refunds", middleware.Protect(middleware.PermDonationRefund, handler.CreateRefund))
+		donationRoutes.GET("/:id/refunds", middleware.Auth(handler.GetDonationRefunds))
+		donationRoutes.GET("/:id/receipt", middleware.Auth(handler.GetDonationReceipt))
+		donationRoutes.GET("/:id/allocations", middleware.Auth(handler.GetDonationAllocations))
+		donationRoutes.POST("/:id/transfer-proof", middleware.Auth(middleware.UploadFile("proof")(handler.UploadTransferProof)))
+		// GET /by-user/:userId: pemilik data, atau user dengan donations:read_all (dicek di handler)
 		donationRoutes.GET("/by-user/:userId", middleware.Auth(handler.GetDonationsByUser))
-		donationRoutes.GET("/:id", handler.GetDonationByID)
-		donationRoutes.PUT("/:id", handler.UpdateDonation)
-		donationRoutes.DELETE("/:id", handler.DeleteDonation)
-		donationRoutes.GET("/by-campaign/:id", handler.GetByCampaign)
+		donationRoutes.GET("/:id", middleware.OptionalAuth(handler.GetDonationByID))
+		donationRoutes.PUT("/:id", middleware.Protect(middleware.PermDonationManage, handler.UpdateDonation))
+		donationRoutes.DELETE("/:id", middleware.Protect(middleware.PermDonationManage, handler.DeleteDonation))
+		donationRoutes.GET("/by-campaign/:id", middleware.OptionalAuth(handler.GetByCampaign))
 		donationRoutes.POST("/notifications", handler.HandlePaymentNotification)
+		donationRoutes.POST("/notifications/:provider", handler.HandlePaymentNotification)
 		donationRoutes.GET("/summary", handler.GetDonationSummary)
 	}
 }
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"log"
+	"math"
+	"os"
+	"strings"
+	"time"
+	"zakat/models"
+)
+
+// AllocationConfig pembagian setiap penerimaan per jenis dana
+type AllocationConfig struct {
+	AmilShare         map[string]float64 // bagian amil per jenis dana; dibatasi FundRule.AmilShareMax
+	GatewayFeePercent float64            // biaya gateway proporsional, mis. 0.007 untuk 0,7%
+	GatewayFeeFlat    float64            // biaya gateway tetap per transaksi
+	FeeFromAmil       bool               // biaya gateway diambil dari bagian amil dulu, sisanya dari program
+}
+
+// AllocationConfigFromEnv membaca AMIL_SHARE_<JENIS> (mis. AMIL_SHARE_ZAKAT=0.125; default batas
+// maksimal jenis dana), GATEWAY_FEE_PERCENT, GATEWAY_FEE_FLAT dan ALLOCATION_FEE_FROM_AMIL (default true)
+func AllocationConfigFromEnv() AllocationConfig {
+	config := AllocationConfig{
+		AmilShare:         make(map[string]float64, len(models.FundTypes)),
+		GatewayFeePercent: floatEnv("GATEWAY_FEE_PERCENT"),
+		GatewayFeeFlat:    floatEnv("GATEWAY_FEE_FLAT"),
+		FeeFromAmil:       os.Getenv("ALLOCATION_FEE_FROM_AMIL") != "false",
+	}
+	for _, fundType := range models.FundTypes {
+		rule, _ := models.FundRuleFor(fundType)
+		config.AmilShare[fundType] = rule.AmilShareMax
+		key := "AMIL_SHARE_" + strings.ToUpper(fundType)
+		if os.Getenv(key) != "" {
+			config.AmilShare[fundType] = floatEnv(key)
+		}
+	}
+	return config
+}
+
+// Allocator membagi penerimaan menjadi porsi program, amil dan biaya gateway
+type Allocator struct {
+	config AllocationConfig
+}
+
+func NewAllocator(config AllocationConfig) *Allocator {
+	shares := make(map[string]float64, len(config.AmilShare))
+	for fundType, share := range config.AmilShare {
+		rule, ok := models.FundRuleFor(fundType)
+		if !ok {
+			continue
+		}
+		if share > rule.AmilShareMax {
+			log.Printf("[Allocation] Amil share %.4f for %s exceeds the maximum %.4f, capping", share, fundType, rule.AmilShareMax)
+			share = rule.AmilShareMax
+		}
+		shares[fundType] = share
+	}
+	config.AmilShare = shares
+	return &Allocator{config: config}
+}
+
+// AmilShare bagian amil yang berlaku untuk jenis dana tersebut
+func (a *Allocator) AmilShare(fundType string) float64 {
+	return a.config.AmilShare[fundType]
+}
+
+// Split baris kredit untuk donasi yang baru sukses. Biaya gateway hanya untuk channel online;
+// porsi program adalah sisanya sehingga jumlah baris selalu sama persis dengan amount.
+func (a *Allocator) Split(donation *models.Donation, amount float64, now time.Time) []models.DonationAllocation {
+	share := a.config.AmilShare[donation.FundType]
+	amil := roundCents(amount * share)
+
+	fee := 0.0
+	if donation.Channel == models.DonationChannelOnline {
+		fee = math.Min(roundCents(amount*a.config.GatewayFeePercent+a.config.GatewayFeeFlat), amount)
+	}
+
+	if a.config.FeeFromAmil {
+		amil = math.Max(amil-fee, 0)
+	}
+	amil = math.Min(amil, amount-fee)
+
+	lines := []models.DonationAllocation{{Portion: models.AllocationProgram, Amount: roundCents(amount - amil - fee)}}
+	if amil > 0 {
+		lines = append(lines, models.DonationAllocation{Portion: models.AllocationAmil, Rate: share, Amount: amil})
+	}
+	if fee > 0 {
+		lines = append(lines, models.DonationAllocation{Portion: models.AllocationGatewayFee, Rate: a.config.GatewayFeePercent, Amount: fee})
+	}
+	return allocationLines(donation, models.AllocationEntryCredit, lines, now)
+}
+
+// Reverse baris pembalik untuk refund sebesar amount, proporsional terhadap saldo setiap porsi
+// (baris yang sudah ada, termasuk pembalik sebelumnya). Refund yang menghabiskan saldo
+// membalik seluruh saldo persis.
+func (a *Allocator) Reverse(donation *models.Donation, existing []models.DonationAllocation, amount float64, now time.Time) []models.DonationAllocation {
+	balances := make(map[string]float64)
+	total := 0.0
+	for _, line := range existing {
+		balances[line.Portion] += line.Amount
+		total += line.Amount
+	}
+	if total <= 0 || amount <= 0 {
+		return nil
+	}
+	full := amount >= total
+
+	var lines []models.DonationAllocation
+	reversed := 0.0
+	for _, portion := range []string{models.AllocationAmil, models.AllocationGatewayFee} {
+		balance := balances[portion]
+		if balance == 0 {
+			continue
+		}
+		amt := -balance
+		if !full {
+			amt = -roundCents(balance * amount / total)
+		}
+		reversed += amt
+		lines = append(lines, models.DonationAllocation{Portion: portion, Amount: amt})
+	}
+
+	program := -roundCents(math.Min(amount, total) + reversed)
+	if full {
+		program = -roundCents(balances[models.AllocationProgram])
+	}
+	lines = append([]models.DonationAllocation{{Portion: models.AllocationProgram, Amount: program}}, lines...)
+	return allocationLines(donation, models.AllocationEntryRefund, lines, now)
+}
+
+// ProgramAmount porsi program dari baris alokasi (menambah atau mengurangi net campaign)
+func ProgramAmount(lines []models.DonationAllocation) float64 {
+	total := 0.0
+	for _, line := range lines {
+		if line.Portion == models.AllocationProgram {
+			total += line.Amount
+		}
+	}
+	return total
+}
+
+func allocationLines(donation *models.Donation, entry string, lines []models.DonationAllocation, now time.Time) []models.DonationAllocation {
+	for i := range lines {
+		lines[i].DonationID = donation.ID
+		lines[i].CampaignID = donation.CampaignID
+		lines[i].FundType = donation.FundType
+		lines[i].Entry = entry
+		lines[i].CreatedAt = now
+	}
+	return lines
+}
+
+func roundCents(value float64) float64 {
+	return math.Round(value*100) / 100
+}
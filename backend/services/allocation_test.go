@@ -0,0 +1,112 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+	"zakat/models"
+)
+
+// cents menjumlahkan baris dalam sen agar perbandingan tidak terganggu galat floating point
+func cents(lines []models.DonationAllocation) map[string]int64 {
+	sums := map[string]int64{}
+	for _, line := range lines {
+		sums[line.Portion] += int64(math.Round(line.Amount * 100))
+		sums[""] += int64(math.Round(line.Amount * 100))
+	}
+	return sums
+}
+
+func TestSplitSumsToAmount(t *testing.T) {
+	cases := []struct {
+		name    string
+		config  AllocationConfig
+		fund    string
+		channel string
+		amount  float64
+		program float64
+		amil    float64
+		fee     float64
+	}{
+		{"zakat biaya gateway lebih besar dari bagian amil",
+			AllocationConfig{AmilShare: map[string]float64{models.FundZakat: 0.125}, GatewayFeePercent: 0.1, GatewayFeeFlat: 5000, FeeFromAmil: true},
+			models.FundZakat, models.DonationChannelOnline, 100000, 85000, 0, 15000},
+		{"zakat biaya gateway tidak diambil dari amil",
+			AllocationConfig{AmilShare: map[string]float64{models.FundZakat: 0.125}, GatewayFeePercent: 0.1, GatewayFeeFlat: 5000},
+			models.FundZakat, models.DonationChannelOnline, 100000, 72500, 12500, 15000},
+		{"zakat nominal ganjil dibulatkan per sen",
+			AllocationConfig{AmilShare: map[string]float64{models.FundZakat: 0.125}, GatewayFeePercent: 0.007, FeeFromAmil: true},
+			models.FundZakat, models.DonationChannelOnline, 100001, 87500.87, 11800.12, 700.01},
+		{"biaya gateway melebihi nominal",
+			AllocationConfig{AmilShare: map[string]float64{models.FundZakat: 0.125}, GatewayFeeFlat: 5000, FeeFromAmil: true},
+			models.FundZakat, models.DonationChannelOnline, 4000, 0, 0, 4000},
+		{"wakaf tanpa bagian nazhir",
+			AllocationConfig{AmilShare: map[string]float64{models.FundWakaf: 0.1}, GatewayFeePercent: 0.007, FeeFromAmil: true},
+			models.FundWakaf, models.DonationChannelOnline, 100000, 99300, 0, 700},
+		{"wakaf offline tanpa biaya gateway",
+			AllocationConfig{AmilShare: map[string]float64{models.FundWakaf: 0.1}, GatewayFeePercent: 0.007, FeeFromAmil: true},
+			models.FundWakaf, models.DonationChannelOffline, 100000, 100000, 0, 0},
+	}
+
+	for _, tc := range cases {
+		allocator := NewAllocator(tc.config)
+		donation := &models.Donation{ID: 1, CampaignID: 2, FundType: tc.fund, Channel: tc.channel}
+		lines := allocator.Split(donation, tc.amount, time.Now())
+
+		sums := cents(lines)
+		if sums[""] != int64(math.Round(tc.amount*100)) {
+			t.Errorf("%s: lines sum to %d cents, want %.2f", tc.name, sums[""], tc.amount)
+		}
+		want := map[string]float64{models.AllocationProgram: tc.program, models.AllocationAmil: tc.amil, models.AllocationGatewayFee: tc.fee}
+		for portion, amount := range want {
+			if sums[portion] != int64(math.Round(amount*100)) {
+				t.Errorf("%s: %s %d cents, want %.2f", tc.name, portion, sums[portion], amount)
+			}
+		}
+		for _, line := range lines {
+			if line.Amount <= 0 && line.Portion != models.AllocationProgram {
+				t.Errorf("%s: non-positive %s line %.2f", tc.name, line.Portion, line.Amount)
+			}
+			if line.DonationID != 1 || line.CampaignID != 2 || line.FundType != tc.fund || line.Entry != models.AllocationEntryCredit {
+				t.Errorf("%s: line not stamped with donation: %+v", tc.name, line)
+			}
+		}
+	}
+}
+
+func TestReversePartialThenFull(t *testing.T) {
+	allocator := NewAllocator(AllocationConfig{
+		AmilShare:         map[string]float64{models.FundZakat: 0.125},
+		GatewayFeePercent: 0.007,
+		FeeFromAmil:       true,
+	})
+	donation := &models.Donation{ID: 1, CampaignID: 2, FundType: models.FundZakat, Channel: models.DonationChannelOnline}
+	now := time.Now()
+
+	ledger := allocator.Split(donation, 100000, now)
+
+	// Refund parsial dengan nominal yang tidak habis dibagi
+	partial := allocator.Reverse(donation, ledger, 33333.33, now)
+	if got := cents(partial)[""]; got != -3333333 {
+		t.Errorf("partial refund lines sum to %d cents, want -3333333", got)
+	}
+	for _, line := range partial {
+		if line.Amount > 0 || line.Entry != models.AllocationEntryRefund {
+			t.Errorf("partial refund line %+v, want a non-positive refund entry", line)
+		}
+	}
+	ledger = append(ledger, partial...)
+
+	// Refund sisanya mengembalikan setiap porsi tepat ke nol
+	full := allocator.Reverse(donation, ledger, 66666.67, now)
+	ledger = append(ledger, full...)
+	for portion, balance := range cents(ledger) {
+		if balance != 0 {
+			t.Errorf("balance %q after full refund = %d cents, want 0", portion, balance)
+		}
+	}
+
+	if lines := allocator.Reverse(donation, ledger, 1000, now); lines != nil {
+		t.Errorf("refund on an empty balance returned %+v, want nil", lines)
+	}
+}
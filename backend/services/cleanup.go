@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+	"zakat/repositories"
+)
+
+// CleanupConfig pengaturan pembersihan data autentikasi yang sudah kedaluwarsa
+type CleanupConfig struct {
+	Interval time.Duration // jarak antar pembersihan; 0 menonaktifkan jadwal
+	// ThrottleRetention record throttle yang window-nya dimulai sebelum selama ini dan tidak
+	// sedang dikunci dihapus; sebaiknya sama dengan window terpanjang
+	ThrottleRetention time.Duration
+}
+
+// CleanupConfigFromEnv membaca CLEANUP_INTERVAL (default 1h); throttleRetention window throttle terpanjang
+func CleanupConfigFromEnv(throttleRetention time.Duration) CleanupConfig {
+	return CleanupConfig{
+		Interval:          durationEnv("CLEANUP_INTERVAL", time.Hour),
+		ThrottleRetention: throttleRetention,
+	}
+}
+
+// Cleaner menghapus sesi, OTP, token verifikasi email dan reset password yang sudah kedaluwarsa
+// atau terpakai, serta record throttle lama, agar tabelnya tidak tumbuh tanpa batas
+type Cleaner struct {
+	config             CleanupConfig
+	sessions           repositories.SessionRepository
+	otps               repositories.PhoneOTPRepository
+	emailVerifications repositories.EmailVerificationRepository
+	passwordResets     repositories.PasswordResetRepository
+	throttle           repositories.ThrottleRepository
+}
+
+// NewCleaner throttle boleh nil jika throttling memakai penyimpanan memori
+func NewCleaner(config CleanupConfig, sessions repositories.SessionRepository, otps repositories.PhoneOTPRepository,
+	emailVerifications repositories.EmailVerificationRepository, passwordResets repositories.PasswordResetRepository,
+	throttle repositories.ThrottleRepository) *Cleaner {
+	return &Cleaner{
+		config:             config,
+		sessions:           sessions,
+		otps:               otps,
+		emailVerifications: emailVerifications,
+		passwordResets:     passwordResets,
+		throttle:           throttle,
+	}
+}
+
+// Start menjalankan pembersihan terjadwal di background sampai ctx dibatalkan
+func (c *Cleaner) Start(ctx context.Context) {
+	if c.config.Interval <= 0 {
+		log.Println("[Cleaner] Scheduled cleanup disabled")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(c.config.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.RunOnce()
+			}
+		}
+	}()
+}
+
+type cleanupTask struct {
+	name string
+	run  func() error
+}
+
+// RunOnce menjalankan semua pembersihan; kegagalan satu tabel tidak menghentikan yang lain
+func (c *Cleaner) RunOnce() {
+	tasks := []cleanupTask{
+		{"sessions", c.sessions.DeleteExpired},
+		{"phone OTPs", c.otps.DeleteExpired},
+		{"email verifications", c.emailVerifications.DeleteExpired},
+		{"password resets", c.passwordResets.DeleteExpired},
+	}
+	if c.throttle != nil {
+		tasks = append(tasks, cleanupTask{"throttle records", func() error {
+			return c.throttle.DeleteExpired(time.Now().Add(-c.config.ThrottleRetention))
+		}})
+	}
+
+	for _, task := range tasks {
+		if err := task.run(); err != nil {
+			log.Printf("[Cleaner] Failed to delete expired %s: %v", task.name, err)
+		}
+	}
+}
@@ -0,0 +1,560 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"zakat/models"
+	"zakat/pkg/payment"
+	"zakat/repositories"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidStatusTransition dikembalikan jika perpindahan status donasi tidak diizinkan
+var ErrInvalidStatusTransition = errors.New("invalid donation status transition")
+
+// ErrDuplicateReceipt nomor kwitansi donasi offline sudah dipakai (juga di dalam satu batch)
+var ErrDuplicateReceipt = repositories.ErrDuplicateReceipt
+
+var (
+	// ErrRefundNotAllowed hanya donasi sukses yang bisa di-refund
+	ErrRefundNotAllowed = errors.New("donation cannot be refunded")
+	// ErrRefundExceedsAmount nominal refund melebihi sisa yang belum di-refund/sedang diproses
+	ErrRefundExceedsAmount = errors.New("refund amount exceeds refundable amount")
+	// ErrRefundGateway gateway menolak refund secara pasti; refund dicatat failed
+	ErrRefundGateway = errors.New("payment gateway refund failed")
+)
+
+// ErrManualCreditNotAllowed status success manual hanya untuk donasi offline dan transfer manual;
+// donasi payment gateway dikredit dari notifikasi/status gateway
+var ErrManualCreditNotAllowed = errors.New("donation can only be marked successful by its payment gateway")
+
+// errDuplicateNotification membatalkan transaksi jika notifikasi yang sama sudah pernah diproses
+var errDuplicateNotification = errors.New("payment notification already processed")
+
+// DonationService mengubah status donasi sesuai state machine dan mengkredit total campaign
+// dalam satu transaksi, sehingga setiap donasi sukses dihitung tepat sekali
+type DonationService interface {
+	// ApplyPaymentNotification mengembalikan duplicate=true jika notifikasi sudah pernah diproses
+	ApplyPaymentNotification(ctx context.Context, notification *models.PaymentNotification, paymentMethod string) (duplicate bool, err error)
+	TransitionStatus(ctx context.Context, id uint, status string) (*models.Donation, error)
+	// UpdateDonation menyimpan perubahan field dari admin dan, jika status diisi, memindahkan statusnya
+	// dalam satu transaksi: transisi yang ditolak ikut membatalkan perubahan field
+	UpdateDonation(ctx context.Context, donation *models.Donation, status string) error
+	// ReviewTransferProof menyetujui/menolak bukti transfer manual; persetujuan mengkredit donasi
+	// lewat jalur yang sama dengan settlement payment gateway
+	ReviewTransferProof(ctx context.Context, proofID uint, reviewerID int, approve bool, reason string) error
+	// RecordOfflineDonations mencatat donasi tunai/barang (Offline wajib terisi) langsung sebagai sukses.
+	// session opsional; jika ID-nya 0 sesi dibuat dulu. Semua entri disimpan atau tidak sama sekali.
+	RecordOfflineDonations(ctx context.Context, session *models.CollectionSession, donations []models.Donation) error
+	// RequestRefund mencadangkan nominal refund (status pending) sebelum gateway dipanggil agar
+	// permintaan bersamaan tidak melebihi nominal donasi. amount 0 berarti seluruh sisa.
+	RequestRefund(ctx context.Context, donationID uint, amount float64, reason string, requestedByID int, provider string) (*models.Refund, error)
+	// CompleteRefund menyimpan hasil dari gateway; refund yang berhasil mengurangi total campaign tepat sekali.
+	// changed=false jika refund sudah final sebelumnya atau masih pending di gateway.
+	CompleteRefund(ctx context.Context, refundID uint, result payment.Refund, failureReason string) (refund *models.Refund, changed bool, err error)
+	// SyncRefunds mencocokkan refund yang dilaporkan gateway dengan catatan lokal; refund yang belum dikenal
+	// (mis. dari dashboard provider) dicatat dengan source gateway. Mengembalikan refund yang baru selesai.
+	SyncRefunds(ctx context.Context, orderID, provider string, refunds []payment.Refund) ([]models.Refund, error)
+	// LinkGuestDonations menautkan donasi tamu dengan email tersebut ke akun user; hanya dipanggil
+	// setelah email terverifikasi. Jumlah donatur campaign disesuaikan jika user sudah tercatat di sana.
+	LinkGuestDonations(ctx context.Context, userID int, email string) (linked int, err error)
+	// ReconcileTotals menghitung ulang total campaign dan mengembalikan yang berbeda; fix=true menimpanya
+	ReconcileTotals(ctx context.Context, fix bool) ([]repositories.CampaignTotals, error)
+}
+
+type donationService struct {
+	uow       repositories.UnitOfWork
+	allocator *Allocator
+}
+
+// NewDonationService allocator membagi setiap kredit menjadi porsi program, amil dan biaya gateway
+func NewDonationService(uow repositories.UnitOfWork, allocator *Allocator) DonationService {
+	return &donationService{uow: uow, allocator: allocator}
+}
+
+func (s *donationService) ApplyPaymentNotification(ctx context.Context, notification *models.PaymentNotification, paymentMethod string) (bool, error) {
+	err := s.uow.Do(ctx, func(repos repositories.Repositories) error {
+		donation, err := repos.Donations.GetByOrderIDForUpdate(notification.OrderID)
+		if err != nil {
+			return err
+		}
+
+		changed, err := s.transition(repos, donation, notification.DonationStatus, paymentMethod)
+		switch {
+		case errors.Is(err, ErrInvalidStatusTransition):
+			// Mis. "pending" terlambat setelah "success": dicatat tapi tidak menurunkan status
+			notification.Result = models.NotificationResultIgnored
+		case err != nil:
+			return err
+		case changed:
+			notification.Result = models.NotificationResultApplied
+		default:
+			notification.Result = models.NotificationResultUnchanged
+		}
+
+		recorded, err := repos.Donations.RecordNotification(notification)
+		if err != nil {
+			return err
+		}
+		if !recorded {
+			return errDuplicateNotification
+		}
+		return nil
+	})
+	if errors.Is(err, errDuplicateNotification) {
+		return true, nil
+	}
+	return false, err
+}
+
+func (s *donationService) TransitionStatus(ctx context.Context, id uint, status string) (*models.Donation, error) {
+	var donation *models.Donation
+	err := s.uow.Do(ctx, func(repos repositories.Repositories) error {
+		var err error
+		donation, err = repos.Donations.GetForUpdate(id)
+		if err != nil {
+			return err
+		}
+		_, err = s.transition(repos, donation, status, "")
+		return err
+	})
+	return donation, err
+}
+
+func (s *donationService) UpdateDonation(ctx context.Context, donation *models.Donation, status string) error {
+	return s.uow.Do(ctx, func(repos repositories.Repositories) error {
+		if err := repos.Donations.Update(donation); err != nil {
+			return err
+		}
+		if status == "" || status == donation.Status {
+			return nil
+		}
+
+		locked, err := repos.Donations.GetForUpdate(uint(donation.ID))
+		if err != nil {
+			return err
+		}
+		if status == models.DonationStatusSuccess &&
+			locked.Channel != models.DonationChannelOffline && locked.PaymentProvider != models.PaymentProviderManual {
+			return ErrManualCreditNotAllowed
+		}
+		if _, err := s.transition(repos, locked, status, ""); err != nil {
+			return err
+		}
+		*donation = *locked
+		return nil
+	})
+}
+
+// transition memindahkan status donasi yang sudah dikunci. Nominal ditambahkan ke total campaign
+// tepat sekali (ditandai CreditedAt) dan dikurangi lagi jika donasi di-refund.
+// Status yang sama tidak dianggap error, hanya changed=false.
+func (s *donationService) transition(repos repositories.Repositories, donation *models.Donation, status, paymentMethod string) (changed bool, err error) {
+	if donation.Status == status {
+		return false, nil
+	}
+	if !models.CanTransitionDonation(donation.Status, status) {
+		return false, ErrInvalidStatusTransition
+	}
+
+	now := time.Now()
+	wasCredited := donation.Status == models.DonationStatusSuccess
+	donation.Status = status
+	donation.UpdatedAt = now
+	if paymentMethod != "" {
+		donation.PaymentMethod = paymentMethod
+	}
+
+	var sign, delta float64
+	var lines []models.DonationAllocation
+	switch {
+	case status == models.DonationStatusSuccess && donation.CreditedAt == nil:
+		donation.CreditedAt = &now
+		sign, delta = 1, donation.Amount
+		lines = s.allocator.Split(donation, donation.Amount, now)
+	case status == models.DonationStatusRefunded && wasCredited && donation.CreditedAt != nil:
+		// Refund parsial sebelumnya sudah dikurangi; tinggal sisanya
+		sign, delta = -1, -(donation.Amount - donation.RefundedAmount)
+		lines, err = s.reverseAllocation(repos, donation, -delta, now)
+		if err != nil {
+			return false, err
+		}
+		donation.RefundedAmount = donation.Amount
+	}
+
+	if sign != 0 {
+		// Kunci campaign dulu agar hitungan donatur unik tidak balapan dengan donasi lain
+		if _, err := repos.Campaigns.GetForUpdate(uint(donation.CampaignID)); err != nil {
+			return false, err
+		}
+		repeat, err := repos.Donations.HasOtherSuccessful(donation.CampaignID, donation.UserID, donation.ID)
+		if err != nil {
+			return false, err
+		}
+		donors := 0
+		if !repeat {
+			donors = int(sign)
+		}
+		if err := repos.Campaigns.AdjustTotals(uint(donation.CampaignID), delta, ProgramAmount(lines), donors); err != nil {
+			return false, err
+		}
+		if err := repos.Allocations.Create(lines); err != nil {
+			return false, err
+		}
+	}
+
+	if err := repos.Donations.UpdateStatus(donation); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *donationService) LinkGuestDonations(ctx context.Context, userID int, email string) (int, error) {
+	linked := 0
+	err := s.uow.Do(ctx, func(repos repositories.Repositories) error {
+		donations, err := repos.Donations.GuestByEmailForUpdate(email)
+		if err != nil {
+			return err
+		}
+		for i := range donations {
+			donation := &donations[i]
+			if donation.Status == models.DonationStatusSuccess {
+				// Donasi tamu dihitung sebagai donatur tersendiri; setelah ditautkan
+				// donatur unik berkurang jika user sudah punya donasi sukses di campaign ini
+				if _, err := repos.Campaigns.GetForUpdate(uint(donation.CampaignID)); err != nil {
+					return err
+				}
+				repeat, err := repos.Donations.HasOtherSuccessful(donation.CampaignID, &userID, donation.ID)
+				if err != nil {
+					return err
+				}
+				if repeat {
+					if err := repos.Campaigns.AdjustTotals(uint(donation.CampaignID), 0, 0, -1); err != nil {
+						return err
+					}
+				}
+			}
+			if err := repos.Donations.LinkUser(donation, userID); err != nil {
+				return err
+			}
+			linked++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return linked, nil
+}
+
+func (s *donationService) ReconcileTotals(ctx context.Context, fix bool) ([]repositories.CampaignTotals, error) {
+	var drifted []repositories.CampaignTotals
+	err := s.uow.Do(ctx, func(repos repositories.Repositories) error {
+		totals, err := repos.Campaigns.ComputeTotals()
+		if err != nil {
+			return err
+		}
+		for _, t := range totals {
+			if !t.Drift() {
+				continue
+			}
+			drifted = append(drifted, t)
+			if fix {
+				if err := repos.Campaigns.SetTotals(uint(t.CampaignID), t.ExpectedTotal, t.ExpectedNet, t.ExpectedDonors); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	return drifted, err
+}
+
+func (s *donationService) ReviewTransferProof(ctx context.Context, proofID uint, reviewerID int, approve bool, reason string) error {
+	return s.uow.Do(ctx, func(repos repositories.Repositories) error {
+		status := models.TransferProofRejected
+		if approve {
+			status = models.TransferProofApproved
+		}
+		if err := repos.TransferProofs.Review(proofID, status, reviewerID, reason); err != nil {
+			return err
+		}
+		if !approve {
+			return nil
+		}
+
+		proof, err := repos.TransferProofs.GetByID(proofID)
+		if err != nil {
+			return err
+		}
+		donation, err := repos.Donations.GetForUpdate(uint(proof.DonationID))
+		if err != nil {
+			return err
+		}
+		if donation.PaymentProvider != models.PaymentProviderManual {
+			return ErrInvalidStatusTransition
+		}
+		changed, err := s.transition(repos, donation, models.DonationStatusSuccess, models.PaymentMethodBankTransfer)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			// Donasi sudah sukses lewat bukti lain; jangan setujui dua kali
+			return ErrInvalidStatusTransition
+		}
+		return nil
+	})
+}
+
+func (s *donationService) RecordOfflineDonations(ctx context.Context, session *models.CollectionSession, donations []models.Donation) error {
+	return s.uow.Do(ctx, func(repos repositories.Repositories) error {
+		if session != nil && session.ID == 0 {
+			if err := repos.Offline.CreateSession(session); err != nil {
+				return err
+			}
+		}
+
+		receipts := make(map[string]bool, len(donations))
+		for i := range donations {
+			donation := &donations[i]
+			detail := donation.Offline
+
+			if receipts[detail.ReceiptNumber] {
+				return ErrDuplicateReceipt
+			}
+			receipts[detail.ReceiptNumber] = true
+			exists, err := repos.Offline.ReceiptExists(detail.ReceiptNumber)
+			if err != nil {
+				return err
+			}
+			if exists {
+				return ErrDuplicateReceipt
+			}
+
+			if session != nil {
+				detail.CollectionSessionID = &session.ID
+			}
+			donation.Channel = models.DonationChannelOffline
+			donation.Status = models.DonationStatusPending
+			donation.OrderID = "OFFLINE-" + detail.ReceiptNumber
+
+			// Offline ikut tersimpan sebagai asosiasi has-one
+			if err := repos.Donations.Create(donation); err != nil {
+				return err
+			}
+			if _, err := s.transition(repos, donation, models.DonationStatusSuccess, models.PaymentMethodOffline); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *donationService) RequestRefund(ctx context.Context, donationID uint, amount float64, reason string, requestedByID int, provider string) (*models.Refund, error) {
+	var refund *models.Refund
+	err := s.uow.Do(ctx, func(repos repositories.Repositories) error {
+		donation, err := repos.Donations.GetForUpdate(donationID)
+		if err != nil {
+			return err
+		}
+		if donation.Status != models.DonationStatusSuccess || donation.CreditedAt == nil {
+			return ErrRefundNotAllowed
+		}
+
+		pending, err := repos.Refunds.PendingAmount(donation.ID)
+		if err != nil {
+			return err
+		}
+		refundable := donation.Amount - donation.RefundedAmount - pending
+		if amount == 0 {
+			amount = refundable
+		}
+		if refundable <= 0 || (amount > refundable && !payment.AmountMatches(amount, refundable)) {
+			return ErrRefundExceedsAmount
+		}
+
+		key, err := newRefundKey(donation.ID)
+		if err != nil {
+			return err
+		}
+		refund = &models.Refund{
+			DonationID:    donation.ID,
+			Amount:        amount,
+			Reason:        reason,
+			Status:        models.RefundStatusPending,
+			Source:        models.RefundSourceAdmin,
+			Provider:      provider,
+			RefundKey:     key,
+			RequestedByID: &requestedByID,
+		}
+		return repos.Refunds.Create(refund)
+	})
+	return refund, err
+}
+
+func (s *donationService) CompleteRefund(ctx context.Context, refundID uint, result payment.Refund, failureReason string) (*models.Refund, bool, error) {
+	var refund *models.Refund
+	var changed bool
+	err := s.uow.Do(ctx, func(repos repositories.Repositories) error {
+		existing, err := repos.Refunds.GetByID(refundID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return fmt.Errorf("refund %d: %w", refundID, gorm.ErrRecordNotFound)
+		}
+
+		// Urutan kunci sama dengan SyncRefunds (donasi dulu, lalu refund) agar tidak deadlock
+		donation, err := repos.Donations.GetForUpdate(uint(existing.DonationID))
+		if err != nil {
+			return err
+		}
+		refund, err = repos.Refunds.GetForUpdate(refundID)
+		if err != nil {
+			return err
+		}
+
+		changed, err = s.settleRefund(repos, donation, refund, result, failureReason)
+		return err
+	})
+	return refund, changed, err
+}
+
+func (s *donationService) SyncRefunds(ctx context.Context, orderID, provider string, refunds []payment.Refund) ([]models.Refund, error) {
+	var settled []models.Refund
+	err := s.uow.Do(ctx, func(repos repositories.Repositories) error {
+		settled = nil
+		donation, err := repos.Donations.GetByOrderIDForUpdate(orderID)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range refunds {
+			refund, err := repos.Refunds.FindForDonation(donation.ID, r.RefundKey, r.RefundID)
+			if err != nil {
+				return err
+			}
+			if refund == nil {
+				// Refund yang tidak diajukan lewat aplikasi hanya dicatat setelah berhasil
+				if r.Status != payment.RefundStatusSucceeded || (r.RefundKey == "" && r.RefundID == "") {
+					continue
+				}
+				key := r.RefundKey
+				if key == "" {
+					key = "GW-" + r.RefundID
+				}
+				refund = &models.Refund{
+					DonationID:       donation.ID,
+					Amount:           r.Amount,
+					Reason:           "Refund dari " + provider,
+					Status:           models.RefundStatusPending,
+					Source:           models.RefundSourceGateway,
+					Provider:         provider,
+					RefundKey:        key,
+					ProviderRefundID: r.RefundID,
+				}
+				if err := repos.Refunds.Create(refund); err != nil {
+					return err
+				}
+			}
+
+			changed, err := s.settleRefund(repos, donation, refund, r, "")
+			if err != nil {
+				return err
+			}
+			if changed {
+				settled = append(settled, *refund)
+			}
+		}
+		return nil
+	})
+	return settled, err
+}
+
+// settleRefund menerapkan hasil gateway ke refund yang masih pending (keduanya sudah dikunci)
+func (s *donationService) settleRefund(repos repositories.Repositories, donation *models.Donation, refund *models.Refund, result payment.Refund, failureReason string) (bool, error) {
+	if refund.Status != models.RefundStatusPending {
+		return false, nil
+	}
+	if result.RefundID != "" {
+		refund.ProviderRefundID = result.RefundID
+	}
+
+	now := time.Now()
+	switch result.Status {
+	case payment.RefundStatusSucceeded:
+		if err := s.applyRefund(repos, donation, refund.Amount); err != nil {
+			return false, err
+		}
+		refund.Status = models.RefundStatusSucceeded
+		refund.ProcessedAt = &now
+	case payment.RefundStatusFailed:
+		refund.Status = models.RefundStatusFailed
+		refund.FailureReason = failureReason
+		refund.ProcessedAt = &now
+	default:
+		// Masih diproses gateway; simpan ID refund-nya saja
+		return false, repos.Refunds.Update(refund)
+	}
+	return true, repos.Refunds.Update(refund)
+}
+
+// applyRefund mengurangi total campaign sebesar nominal refund. Refund yang menghabiskan sisa
+// nominal memindahkan donasi ke refunded (donor_count ikut berkurang lewat transition).
+func (s *donationService) applyRefund(repos repositories.Repositories, donation *models.Donation, amount float64) error {
+	if donation.Status != models.DonationStatusSuccess || donation.CreditedAt == nil {
+		// Sudah refunded (mis. lewat status gateway) atau belum pernah dikredit: tidak ada yang dikurangi
+		return nil
+	}
+
+	credited := donation.Amount - donation.RefundedAmount
+	if amount >= credited || payment.AmountMatches(amount, credited) {
+		if amount > credited && !payment.AmountMatches(amount, credited) {
+			log.Printf("[Refund] Refund %.2f exceeds credited %.2f for donation %d, capping", amount, credited, donation.ID)
+		}
+		_, err := s.transition(repos, donation, models.DonationStatusRefunded, "")
+		return err
+	}
+
+	now := time.Now()
+	lines, err := s.reverseAllocation(repos, donation, amount, now)
+	if err != nil {
+		return err
+	}
+	if _, err := repos.Campaigns.GetForUpdate(uint(donation.CampaignID)); err != nil {
+		return err
+	}
+	if err := repos.Campaigns.AdjustTotals(uint(donation.CampaignID), -amount, ProgramAmount(lines), 0); err != nil {
+		return err
+	}
+	if err := repos.Allocations.Create(lines); err != nil {
+		return err
+	}
+	donation.RefundedAmount += amount
+	donation.UpdatedAt = now
+	return repos.Donations.UpdateStatus(donation)
+}
+
+// reverseAllocation baris pembalik untuk refund sebesar amount dari alokasi donasi yang sudah ada
+func (s *donationService) reverseAllocation(repos repositories.Repositories, donation *models.Donation, amount float64, now time.Time) ([]models.DonationAllocation, error) {
+	existing, err := repos.Allocations.ListByDonation(donation.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.allocator.Reverse(donation, existing, amount, now), nil
+}
+
+// newRefundKey idempotency key refund, unik per permintaan
+func newRefundKey(donationID int) (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("RF-%d-%s", donationID, hex.EncodeToString(b)), nil
+}
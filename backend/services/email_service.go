@@ -42,8 +42,97 @@ func (es *EmailService) SendResetEmail(to, token string) error {
 		</html>
 	`, resetLink, resetLink)
 
+	return es.send(to, subject, body)
+}
+
+func (es *EmailService) SendVerificationEmail(to, name, token string) error {
+	verifyLink := fmt.Sprintf("%s/verify-email?token=%s", es.BaseURL, token)
+
+	subject := "Verifikasi Email AmalSAS"
+	body := fmt.Sprintf(`
+		<html>
+		<body>
+			<h2>Assalamu'alaikum %s,</h2>
+			<p>Terima kasih telah mendaftar di AmalSAS. Klik link berikut untuk memverifikasi email Anda:</p>
+			<p><a href="%s">%s</a></p>
+			<p>Link ini berlaku selama 24 jam.</p>
+			<p>Jika Anda tidak merasa mendaftar, abaikan email ini.</p>
+		</body>
+		</html>
+	`, name, verifyLink, verifyLink)
+
+	return es.send(to, subject, body)
+}
+
+func (es *EmailService) SendAdminInviteEmail(to, role, token string, ttlHours int) error {
+	inviteLink := fmt.Sprintf("%s/accept-invite?token=%s", es.BaseURL, token)
+
+	subject := "Undangan Pengelola AmalSAS"
+	body := fmt.Sprintf(`
+		<html>
+		<body>
+			<h2>Assalamu'alaikum,</h2>
+			<p>Anda diundang menjadi pengelola AmalSAS dengan peran <b>%s</b>.</p>
+			<p>Masuk atau daftar dengan email ini, lalu buka link berikut untuk menerima undangan:</p>
+			<p><a href="%s">%s</a></p>
+			<p>Link ini berlaku selama %d jam dan hanya bisa dipakai sekali.</p>
+			<p>Jika Anda tidak mengenal pengirim undangan ini, abaikan email ini.</p>
+		</body>
+		</html>
+	`, role, inviteLink, inviteLink, ttlHours)
+
+	return es.send(to, subject, body)
+}
+
+// send mengirim email HTML melalui SMTP
+// SendPlanChargeEmail mengirim link pembayaran donasi rutin periode ini; reminder=true untuk pengingat
+func (es *EmailService) SendPlanChargeEmail(to, name, campaignTitle string, amount float64, paymentURL string, reminder bool) error {
+	subject := "Donasi Rutin AmalSAS"
+	intro := "Sudah waktunya donasi rutin Anda"
+	if reminder {
+		subject = "Pengingat Donasi Rutin AmalSAS"
+		intro = "Donasi rutin Anda periode ini belum dibayar"
+	}
+
+	body := fmt.Sprintf(`
+		<html>
+		<body>
+			<h2>Assalamu'alaikum %s,</h2>
+			<p>%s untuk <b>%s</b> sebesar <b>Rp %.0f</b>.</p>
+			<p>Silakan selesaikan pembayaran melalui link berikut:</p>
+			<p><a href="%s">%s</a></p>
+			<p>Anda dapat menjeda atau menghentikan donasi rutin kapan saja dari halaman akun Anda.</p>
+		</body>
+		</html>
+	`, name, intro, campaignTitle, amount, paymentURL, paymentURL)
+
+	return es.send(to, subject, body)
+}
+
+// SendRefundEmail memberi tahu donatur bahwa sebagian/seluruh donasinya sudah dikembalikan
+func (es *EmailService) SendRefundEmail(to, name, campaignTitle string, amount float64, full bool) error {
+	scope := "sebagian"
+	if full {
+		scope = "seluruh"
+	}
+
+	subject := "Pengembalian Dana Donasi AmalSAS"
+	body := fmt.Sprintf(`
+		<html>
+		<body>
+			<h2>Assalamu'alaikum %s,</h2>
+			<p>Kami telah mengembalikan %s donasi Anda untuk campaign <b>%s</b> sebesar <b>Rp %.0f</b>.</p>
+			<p>Dana akan diterima sesuai metode pembayaran yang Anda gunakan; waktu pencairan mengikuti kebijakan bank atau penyedia pembayaran.</p>
+			<p>Jika ada pertanyaan, silakan hubungi pengelola AmalSAS.</p>
+		</body>
+		</html>
+	`, name, scope, campaignTitle, amount)
+
+	return es.send(to, subject, body)
+}
+
+func (es *EmailService) send(to, subject, body string) error {
 	auth := smtp.PlainAuth("", es.Username, es.Password, es.SMTPHost)
-	toList := []string{to}
 
 	msg := []byte(fmt.Sprintf(
 		"To: %s\r\n"+
@@ -57,7 +146,7 @@ func (es *EmailService) SendResetEmail(to, token string) error {
 		fmt.Sprintf("%s:%s", es.SMTPHost, es.SMTPPort),
 		auth,
 		es.FromEmail,
-		toList,
+		[]string{to},
 		msg,
 	)
 }
@@ -1,50 +1,64 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"zakat/models"
-	zakatMidtrans "zakat/pkg/midtrans"
-
-	midtransSdk "github.com/midtrans/midtrans-go"
-	"github.com/midtrans/midtrans-go/coreapi"
-	"github.com/midtrans/midtrans-go/snap"
+	"zakat/pkg/payment"
 )
 
+// PaymentService menjembatani donasi dengan payment provider (pkg/payment) tanpa
+// bergantung pada tipe milik provider tertentu
 type PaymentService interface {
-	CreateTransaction(donation models.Donation) (*snap.Response, error)
-	VerifyPayment(orderID string) (bool, error)
-	GetTransactionStatus(orderID string) (*coreapi.TransactionStatusResponse, error)
+	// DefaultProvider provider yang dipakai untuk donasi baru
+	DefaultProvider() string
+	CreateTransaction(ctx context.Context, donation models.Donation) (*payment.Charge, error)
+	GetTransactionStatus(ctx context.Context, donation models.Donation) (*payment.Transaction, error)
+	Refund(ctx context.Context, donation models.Donation, req payment.RefundRequest) (*payment.Refund, error)
+	ParseNotification(provider string, body []byte, header http.Header) (*payment.Transaction, error)
 }
 
-type paymentService struct{}
+type paymentService struct {
+	providers *payment.Registry
+}
 
-func NewPaymentService() PaymentService {
-	return &paymentService{}
+func NewPaymentService(providers *payment.Registry) PaymentService {
+	return &paymentService{providers: providers}
 }
 
-func (s *paymentService) CreateTransaction(donation models.Donation) (*snap.Response, error) {
-	// Generate unique order ID dengan timestamp
-	// orderID := fmt.Sprintf("DONATION-%d-%d", donation.ID, time.Now().Unix())
-
-	// fallback nama user
-	fname := donation.User.FirstName + " " + donation.User.LastName
-	if fname == "" || fname == " " {
-		if donation.User.Username != "" {
-			fname = donation.User.Username
-		} else {
-			fname = "Anonim"
-		}
+func (s *paymentService) DefaultProvider() string {
+	p, err := s.providers.Default()
+	if err != nil {
+		return ""
+	}
+	return p.Name()
+}
+
+// provider memilih adapter sesuai provider donasi; donasi lama tanpa provider memakai Midtrans
+func (s *paymentService) provider(donation models.Donation) (payment.Provider, error) {
+	name := donation.PaymentProvider
+	if name == "" {
+		name = models.PaymentProviderMidtrans
+	}
+	return s.providers.Get(name)
+}
+
+func (s *paymentService) CreateTransaction(ctx context.Context, donation models.Donation) (*payment.Charge, error) {
+	provider, err := s.provider(donation)
+	if err != nil {
+		return nil, err
 	}
 
-	// fallback email
-	email := donation.User.Email
+	// Donatur tamu tidak punya akun; kontak diambil dari data yang diisi saat checkout
+	fname, email, phone := donation.DonorContact()
+	if fname == "" {
+		fname = "Anonim"
+	}
 	if email == "" {
 		email = "no-reply@amalsas.id"
 	}
-
-	// fallback phone
-	phone := donation.User.Phone
 	if phone == "" {
 		phone = "0000000000"
 	}
@@ -55,68 +69,72 @@ func (s *paymentService) CreateTransaction(donation models.Donation) (*snap.Resp
 		title = "Donasi AmalSAS"
 	}
 
-	// Buat request Midtrans
-	req := &snap.Request{
-		TransactionDetails: midtransSdk.TransactionDetails{
-			OrderID:  donation.OrderID,
-			GrossAmt: int64(donation.Amount),
-		},
-		CustomerDetail: &midtransSdk.CustomerDetails{
-			FName: fname,
-			Email: email,
-			Phone: phone,
-		},
-		Items: &[]midtransSdk.ItemDetails{
-			{
-				ID:    fmt.Sprintf("ITEM-%d", donation.CampaignID),
-				Price: int64(donation.Amount),
-				Qty:   1,
-				Name:  fmt.Sprintf("Donasi untuk %s", title),
-			},
-		},
-	}
-
-	log.Printf("[Midtrans] Creating transaction: %+v\n", donation)
+	log.Printf("[Payment] Creating %s transaction: order_id=%s amount=%.2f", provider.Name(), donation.OrderID, donation.Amount)
+
+	return provider.CreateCharge(ctx, payment.ChargeRequest{
+		OrderID:       donation.OrderID,
+		Amount:        donation.Amount,
+		CustomerName:  fname,
+		CustomerEmail: email,
+		CustomerPhone: phone,
+		ItemID:        fmt.Sprintf("ITEM-%d", donation.CampaignID),
+		ItemName:      fmt.Sprintf("Donasi untuk %s", title),
+	})
+}
 
-	// Panggil Midtrans Snap
-	snapResp, err := zakatMidtrans.SnapClient.CreateTransaction(req)
+func (s *paymentService) GetTransactionStatus(ctx context.Context, donation models.Donation) (*payment.Transaction, error) {
+	provider, err := s.provider(donation)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create snap transaction: %v", err)
+		return nil, err
 	}
 
-	return snapResp, nil
+	status, err := provider.GetStatus(ctx, donation.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[Payment] %s status order_id=%s -> %s", provider.Name(), donation.OrderID, status.RawStatus)
+	return status, nil
 }
 
-func (s *paymentService) VerifyPayment(orderID string) (bool, error) {
-	status, err := s.GetTransactionStatus(orderID)
+func (s *paymentService) Refund(ctx context.Context, donation models.Donation, req payment.RefundRequest) (*payment.Refund, error) {
+	provider, err := s.provider(donation)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-
-	log.Printf("[Midtrans] VerifyPayment OrderID=%s Status=%s Fraud=%s\n",
-		orderID, status.TransactionStatus, status.FraudStatus)
-
-	switch status.TransactionStatus {
-	case "capture":
-		if status.FraudStatus == "accept" {
-			return true, nil
+	req.OrderID = donation.OrderID
+	if req.TransactionID == "" {
+		// Beberapa provider (Xendit) me-refund berdasarkan ID transaksi, yang tidak disimpan di donasi
+		status, err := provider.GetStatus(ctx, donation.OrderID)
+		if err != nil {
+			return nil, err
 		}
-		return false, nil
-	case "settlement":
-		return true, nil
-	case "authorize":
-		return true, nil
-	default:
-		return false, nil
+		req.TransactionID = status.TransactionID
 	}
+	return provider.Refund(ctx, req)
 }
 
-func (s *paymentService) GetTransactionStatus(orderID string) (*coreapi.TransactionStatusResponse, error) {
-	status, err := zakatMidtrans.CoreClient.CheckTransaction(orderID)
+func (s *paymentService) ParseNotification(providerName string, body []byte, header http.Header) (*payment.Transaction, error) {
+	provider, err := s.providers.Get(providerName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check transaction status: %v", err)
+		return nil, err
 	}
+	return provider.ParseWebhook(body, header)
+}
 
-	log.Printf("[Midtrans] GetTransactionStatus OrderID=%s -> %s", orderID, status.TransactionStatus)
-	return status, nil
+// DonationStatusFromPayment memetakan status payment ke status donasi; kosong jika tidak ditangani
+func DonationStatusFromPayment(status string) string {
+	switch status {
+	case payment.StatusPending:
+		return models.DonationStatusPending
+	case payment.StatusPaid, payment.StatusPartiallyRefunded:
+		// Refund parsial tidak mengubah status; nominalnya dicatat lewat DonationService.SyncRefunds
+		return models.DonationStatusSuccess
+	case payment.StatusFailed:
+		return models.DonationStatusFailed
+	case payment.StatusExpired:
+		return models.DonationStatusExpired
+	case payment.StatusRefunded:
+		return models.DonationStatusRefunded
+	}
+	return ""
 }
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+	"zakat/models"
+	"zakat/pkg/audit"
+	"zakat/repositories"
+)
+
+// ErrPlanSchedulerRunning dikembalikan jika scheduler donasi rutin masih berjalan di instance ini
+var ErrPlanSchedulerRunning = errors.New("donation plan scheduler is already running")
+
+// PlanSchedulerConfig pengaturan penagihan donasi rutin
+type PlanSchedulerConfig struct {
+	Interval      time.Duration // jarak antar run; 0 menonaktifkan scheduler
+	ReminderAfter time.Duration // pengingat dikirim jika link belum dibayar setelah selama ini
+	BatchSize     int
+	// GeneralFundCampaignID campaign penampung dana umum untuk rencana tanpa campaign
+	GeneralFundCampaignID int
+}
+
+// PlanSchedulerConfigFromEnv membaca PLAN_SCHEDULER_INTERVAL (default 1h), PLAN_REMINDER_AFTER (12h),
+// PLAN_BATCH_SIZE (100) dan GENERAL_FUND_CAMPAIGN_ID
+func PlanSchedulerConfigFromEnv() PlanSchedulerConfig {
+	config := PlanSchedulerConfig{
+		Interval:      durationEnv("PLAN_SCHEDULER_INTERVAL", time.Hour),
+		ReminderAfter: durationEnv("PLAN_REMINDER_AFTER", 12*time.Hour),
+		BatchSize:     100,
+	}
+	if n, err := strconv.Atoi(os.Getenv("PLAN_BATCH_SIZE")); err == nil && n > 0 {
+		config.BatchSize = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("GENERAL_FUND_CAMPAIGN_ID")); err == nil && n > 0 {
+		config.GeneralFundCampaignID = n
+	}
+	return config
+}
+
+// PlanScheduler membuat donasi pending untuk setiap rencana rutin yang jatuh tempo, mengirim
+// link pembayarannya ke donatur, dan mengirim pengingat jika link belum dibayar
+type PlanScheduler struct {
+	config    PlanSchedulerConfig
+	plans     repositories.DonationPlanRepository
+	donations repositories.DonationRepository
+	campaigns repositories.CampaignRepository
+	users     repositories.UserRepository
+	payments  PaymentService
+	email     *EmailService
+	running   sync.Mutex
+}
+
+func NewPlanScheduler(config PlanSchedulerConfig, plans repositories.DonationPlanRepository, donations repositories.DonationRepository,
+	campaigns repositories.CampaignRepository, users repositories.UserRepository, payments PaymentService, email *EmailService) *PlanScheduler {
+	return &PlanScheduler{
+		config:    config,
+		plans:     plans,
+		donations: donations,
+		campaigns: campaigns,
+		users:     users,
+		payments:  payments,
+		email:     email,
+	}
+}
+
+// GeneralFundCampaignID campaign dana umum; 0 jika belum dikonfigurasi
+func (s *PlanScheduler) GeneralFundCampaignID() int {
+	return s.config.GeneralFundCampaignID
+}
+
+// Start menjalankan penagihan terjadwal di background sampai ctx dibatalkan
+func (s *PlanScheduler) Start(ctx context.Context) {
+	if s.config.Interval <= 0 {
+		log.Println("[PlanScheduler] Recurring donation scheduler disabled")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(s.config.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				charged, reminded, err := s.RunOnce(ctx)
+				if err != nil && !errors.Is(err, ErrPlanSchedulerRunning) {
+					log.Printf("[PlanScheduler] Run failed: %v", err)
+				} else if charged > 0 || reminded > 0 {
+					log.Printf("[PlanScheduler] Charged %d plan(s), sent %d reminder(s)", charged, reminded)
+				}
+			}
+		}
+	}()
+}
+
+// RunOnce menagih rencana yang jatuh tempo lalu mengirim pengingat link yang belum dibayar
+func (s *PlanScheduler) RunOnce(ctx context.Context) (charged, reminded int, err error) {
+	if !s.running.TryLock() {
+		return 0, 0, ErrPlanSchedulerRunning
+	}
+	defer s.running.Unlock()
+
+	ctx = audit.WithSystemActor(ctx, "plan-scheduler")
+
+	plans, err := s.plans.Due(time.Now(), s.config.BatchSize)
+	if err != nil {
+		return 0, 0, fmt.Errorf("load due plans: %w", err)
+	}
+	for _, plan := range plans {
+		ok, err := s.charge(ctx, plan)
+		if err != nil {
+			log.Printf("[PlanScheduler] Failed to charge plan %d: %v", plan.ID, err)
+			continue
+		}
+		if ok {
+			charged++
+		}
+	}
+
+	if s.config.ReminderAfter > 0 {
+		charges, err := s.plans.PendingCharges(time.Now().Add(-s.config.ReminderAfter), s.config.BatchSize)
+		if err != nil {
+			return charged, 0, fmt.Errorf("load pending charges: %w", err)
+		}
+		for _, charge := range charges {
+			if charge.Donation == nil {
+				continue
+			}
+			if err := s.sendLink(ctx, charge.Donation, true); err != nil {
+				log.Printf("[PlanScheduler] Failed to remind charge %d: %v", charge.ID, err)
+				continue
+			}
+			if err := s.plans.MarkReminded(charge.ID); err != nil {
+				log.Printf("[PlanScheduler] Failed to mark charge %d as reminded: %v", charge.ID, err)
+				continue
+			}
+			reminded++
+		}
+	}
+	return charged, reminded, nil
+}
+
+// charge membuat donasi untuk periode plan.NextChargeAt; false jika sudah diproses di tempat lain
+func (s *PlanScheduler) charge(ctx context.Context, plan models.DonationPlan) (bool, error) {
+	campaignID := s.config.GeneralFundCampaignID
+	if plan.CampaignID != nil {
+		campaignID = *plan.CampaignID
+	}
+	if campaignID == 0 {
+		return false, errors.New("plan has no campaign and GENERAL_FUND_CAMPAIGN_ID is not set")
+	}
+
+	now := time.Now()
+	donation := &models.Donation{
+		Amount:          plan.Amount,
+		Date:            now,
+		Status:          models.DonationStatusPending,
+		UserID:          &plan.UserID,
+		CampaignID:      campaignID,
+		OrderID:         fmt.Sprintf("PLAN-%d-%d", plan.ID, plan.NextChargeAt.Unix()),
+		Channel:         models.DonationChannelOnline,
+		PaymentProvider: s.payments.DefaultProvider(),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	created, err := s.plans.WithContext(ctx).CreateCharge(plan.ID, plan.NextChargeAt, donation)
+	if err != nil || !created {
+		return false, err
+	}
+
+	// Kegagalan membuat link tidak membatalkan tagihan; pengingat akan mencoba lagi
+	if err := s.sendLink(ctx, donation, false); err != nil {
+		log.Printf("[PlanScheduler] Failed to send payment link for plan %d: %v", plan.ID, err)
+	}
+	return true, nil
+}
+
+// sendLink membuat link pembayaran jika belum ada lalu mengirimkannya ke donatur
+func (s *PlanScheduler) sendLink(ctx context.Context, donation *models.Donation, reminder bool) error {
+	user := donation.User
+	if user == nil && donation.UserID != nil {
+		var err error
+		if user, err = s.users.GetByID(uint(*donation.UserID)); err != nil {
+			return err
+		}
+	}
+	if user == nil {
+		return errors.New("donor not found")
+	}
+	campaign, err := s.campaigns.GetByID(uint(donation.CampaignID))
+	if err != nil {
+		return err
+	}
+	if campaign == nil {
+		return errors.New("campaign not found")
+	}
+
+	if donation.PaymentURL == "" {
+		charge := *donation
+		charge.User, charge.Campaign = user, *campaign
+		paymentResp, err := s.payments.CreateTransaction(ctx, charge)
+		if err != nil {
+			return err
+		}
+		donation.PaymentURL = paymentResp.RedirectURL
+		donation.UpdatedAt = time.Now()
+
+		// Simpan tanpa relasi agar user/campaign tidak ikut ditulis ulang
+		update := *donation
+		update.User, update.Campaign, update.Offline = nil, models.Campaign{}, nil
+		if err := s.donations.WithContext(ctx).Update(&update); err != nil {
+			return err
+		}
+	}
+
+	if user.Email == "" || s.email == nil {
+		return nil
+	}
+	name := user.FirstName
+	if name == "" {
+		name = user.Username
+	}
+	return s.email.SendPlanChargeEmail(user.Email, name, campaign.Title, donation.Amount, donation.PaymentURL, reminder)
+}
@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+	"zakat/models"
+	"zakat/pkg/audit"
+	"zakat/pkg/payment"
+	"zakat/repositories"
+)
+
+// ErrReconcileRunning dikembalikan jika rekonsiliasi lain masih berjalan di instance ini
+var ErrReconcileRunning = errors.New("reconciliation is already running")
+
+// ReconcilerConfig pengaturan rekonsiliasi donasi pending dengan Midtrans
+type ReconcilerConfig struct {
+	Interval    time.Duration // jarak antar run terjadwal; 0 menonaktifkan jadwal
+	MinAge      time.Duration // donasi lebih muda dari ini dibiarkan menunggu webhook
+	ExpireAfter time.Duration // donasi yang belum dibayar di gateway setelah selama ini ditandai expired
+	// transfer manual tanpa bukti transfer yang menunggu verifikasi ditandai expired setelah selama ini,
+	// agar nominal + kode uniknya bisa dipakai lagi
+	ManualExpireAfter time.Duration
+	BatchSize         int
+}
+
+// ReconcilerConfigFromEnv membaca RECONCILE_INTERVAL (default 15m), RECONCILE_MIN_AGE (30m),
+// RECONCILE_EXPIRE_AFTER (24h), RECONCILE_MANUAL_EXPIRE_AFTER (72h) dan RECONCILE_BATCH_SIZE (200)
+func ReconcilerConfigFromEnv() ReconcilerConfig {
+	config := ReconcilerConfig{
+		Interval:          durationEnv("RECONCILE_INTERVAL", 15*time.Minute),
+		MinAge:            durationEnv("RECONCILE_MIN_AGE", 30*time.Minute),
+		ExpireAfter:       durationEnv("RECONCILE_EXPIRE_AFTER", 24*time.Hour),
+		ManualExpireAfter: durationEnv("RECONCILE_MANUAL_EXPIRE_AFTER", 72*time.Hour),
+		BatchSize:         200,
+	}
+	if n, err := strconv.Atoi(os.Getenv("RECONCILE_BATCH_SIZE")); err == nil && n > 0 {
+		config.BatchSize = n
+	}
+	return config
+}
+
+func durationEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return d
+	}
+	return fallback
+}
+
+// Reconciler mencocokkan donasi yang masih pending dengan status di Midtrans, untuk
+// menangani notifikasi yang hilang. Perubahan status memakai logika yang sama dengan webhook.
+type Reconciler struct {
+	config    ReconcilerConfig
+	repo      repositories.ReconciliationRepository
+	payments  PaymentService
+	donations DonationService
+	refunds   RefundService
+	running   sync.Mutex
+}
+
+// NewReconciler refunds dipakai untuk menyelesaikan refund yang masih pending karena hasil gateway belum pasti
+func NewReconciler(config ReconcilerConfig, repo repositories.ReconciliationRepository, payments PaymentService, donations DonationService, refunds RefundService) *Reconciler {
+	return &Reconciler{config: config, repo: repo, payments: payments, donations: donations, refunds: refunds}
+}
+
+// Start menjalankan rekonsiliasi terjadwal di background sampai ctx dibatalkan
+func (r *Reconciler) Start(ctx context.Context) {
+	if r.config.Interval <= 0 {
+		log.Println("[Reconciler] Scheduled reconciliation disabled")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(r.config.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run, err := r.Trigger(ctx, models.ReconciliationTriggerSchedule)
+				if err != nil && !errors.Is(err, ErrReconcileRunning) {
+					log.Printf("[Reconciler] Failed to start run: %v", err)
+				} else if run != nil {
+					log.Printf("[Reconciler] Started run %d", run.ID)
+				}
+			}
+		}
+	}()
+}
+
+// Trigger membuat run baru dan memprosesnya di background. Actor pada ctx (admin yang memicu)
+// ikut tercatat di audit log.
+func (r *Reconciler) Trigger(ctx context.Context, trigger string) (*models.ReconciliationRun, error) {
+	if !r.running.TryLock() {
+		return nil, ErrReconcileRunning
+	}
+
+	run := &models.ReconciliationRun{
+		Trigger:     trigger,
+		TriggeredBy: audit.ActorFrom(ctx).UserID,
+		StartedAt:   time.Now(),
+	}
+	if err := r.repo.CreateRun(run); err != nil {
+		r.running.Unlock()
+		return nil, err
+	}
+
+	// Run tetap berjalan walaupun request yang memicunya sudah selesai
+	ctx = audit.WithSystemActor(context.WithoutCancel(ctx), "reconciler")
+	go func() {
+		defer r.running.Unlock()
+		r.process(ctx, run)
+	}()
+	return run, nil
+}
+
+func (r *Reconciler) process(ctx context.Context, run *models.ReconciliationRun) {
+	donations, err := r.repo.PendingDonations(time.Now().Add(-r.config.MinAge), r.config.BatchSize)
+	if err != nil {
+		log.Printf("[Reconciler] Failed to load pending donations: %v", err)
+		run.Errors++
+	}
+
+	for _, donation := range donations {
+		item := r.check(ctx, donation)
+		item.RunID = run.ID
+		if err := r.repo.MarkReconciled(donation.ID, time.Now()); err != nil {
+			log.Printf("[Reconciler] Failed to mark order_id=%s reconciled: %v", donation.OrderID, err)
+		}
+		if err := r.repo.AddItem(&item); err != nil {
+			log.Printf("[Reconciler] Failed to save item for order_id=%s: %v", donation.OrderID, err)
+		}
+
+		run.Checked++
+		switch item.Result {
+		case models.ReconciliationResultUpdated:
+			run.Updated++
+		case models.ReconciliationResultExpired:
+			run.Expired++
+		case models.ReconciliationResultMismatch:
+			run.Mismatched++
+		case models.ReconciliationResultError:
+			run.Errors++
+		default:
+			run.Unchanged++
+		}
+	}
+
+	r.expireManualTransfers(ctx, run)
+
+	resolved, err := r.refunds.ResolvePending(ctx, time.Now().Add(-r.config.MinAge), r.config.BatchSize)
+	if err != nil {
+		log.Printf("[Reconciler] Failed to resolve pending refunds: %v", err)
+		run.Errors++
+	} else if resolved > 0 {
+		log.Printf("[Reconciler] Resolved %d pending refund(s)", resolved)
+	}
+
+	now := time.Now()
+	run.FinishedAt = &now
+	if err := r.repo.FinishRun(run); err != nil {
+		log.Printf("[Reconciler] Failed to finish run %d: %v", run.ID, err)
+	}
+	log.Printf("[Reconciler] Run %d finished: checked=%d updated=%d expired=%d mismatched=%d errors=%d",
+		run.ID, run.Checked, run.Updated, run.Expired, run.Mismatched, run.Errors)
+}
+
+// expireManualTransfers menandai transfer manual yang tidak pernah dibayar sebagai expired.
+// Transfer manual tidak ada di gateway, jadi tidak ikut dicek lewat check.
+func (r *Reconciler) expireManualTransfers(ctx context.Context, run *models.ReconciliationRun) {
+	if r.config.ManualExpireAfter <= 0 {
+		return
+	}
+	donations, err := r.repo.StaleManualTransfers(time.Now().Add(-r.config.ManualExpireAfter), r.config.BatchSize)
+	if err != nil {
+		log.Printf("[Reconciler] Failed to load stale manual transfers: %v", err)
+		run.Errors++
+		return
+	}
+
+	for _, donation := range donations {
+		item := models.ReconciliationItem{
+			RunID:          run.ID,
+			DonationID:     donation.ID,
+			OrderID:        donation.OrderID,
+			Amount:         donation.Amount,
+			PreviousStatus: donation.Status,
+			NewStatus:      models.DonationStatusExpired,
+			GatewayStatus:  "manual_unpaid",
+			Result:         models.ReconciliationResultExpired,
+		}
+		if _, err := r.donations.TransitionStatus(ctx, uint(donation.ID), models.DonationStatusExpired); err != nil {
+			item.NewStatus = donation.Status
+			item.Result = models.ReconciliationResultError
+			item.Error = err.Error()
+			run.Errors++
+		} else {
+			run.Expired++
+		}
+		run.Checked++
+		if err := r.repo.AddItem(&item); err != nil {
+			log.Printf("[Reconciler] Failed to save item for order_id=%s: %v", donation.OrderID, err)
+		}
+	}
+}
+
+// check mengambil status donasi dari Midtrans dan menerapkannya lewat DonationService
+func (r *Reconciler) check(ctx context.Context, donation models.Donation) models.ReconciliationItem {
+	item := models.ReconciliationItem{
+		DonationID:     donation.ID,
+		OrderID:        donation.OrderID,
+		Amount:         donation.Amount,
+		PreviousStatus: donation.Status,
+		NewStatus:      donation.Status,
+		Result:         models.ReconciliationResultUnchanged,
+	}
+	fail := func(err error) models.ReconciliationItem {
+		item.Result = models.ReconciliationResultError
+		item.Error = err.Error()
+		return item
+	}
+	// Donasi yang setelah ExpireAfter masih belum dibayar (tidak dikenal gateway, masih pending di
+	// gateway, atau datanya tidak cocok) ditandai expired. Pembayaran yang masuk sesudahnya tetap
+	// dikredit lewat webhook karena expired masih boleh berpindah ke success.
+	expireIfStale := func(note string) models.ReconciliationItem {
+		if time.Since(donation.CreatedAt) < r.config.ExpireAfter {
+			return item
+		}
+		if _, err := r.donations.TransitionStatus(ctx, uint(donation.ID), models.DonationStatusExpired); err != nil {
+			return fail(err)
+		}
+		item.NewStatus = models.DonationStatusExpired
+		item.Result = models.ReconciliationResultExpired
+		item.Error = note
+		return item
+	}
+
+	status, err := r.payments.GetTransactionStatus(ctx, donation)
+	if errors.Is(err, payment.ErrTransactionNotFound) {
+		item.GatewayStatus = "not_found"
+		return expireIfStale("")
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	item.GatewayStatus = status.RawStatus
+	if status.OrderID != donation.OrderID || !payment.AmountMatches(status.GrossAmount, donation.Amount) {
+		log.Printf("[Reconciler] Gateway data mismatch for order_id=%s: gateway_order_id=%s gross_amount=%.2f amount=%.2f",
+			donation.OrderID, status.OrderID, status.GrossAmount, donation.Amount)
+		item.Result = models.ReconciliationResultMismatch
+		return expireIfStale(fmt.Sprintf("gateway data mismatch: order_id=%s gross_amount=%.2f", status.OrderID, status.GrossAmount))
+	}
+
+	donationStatus := DonationStatusFromPayment(status.Status)
+	if donationStatus == "" || donationStatus == models.DonationStatusPending {
+		return expireIfStale("")
+	}
+
+	notification := &models.PaymentNotification{
+		OrderID:           donation.OrderID,
+		TransactionID:     status.TransactionID,
+		TransactionStatus: status.RawStatus,
+		FraudStatus:       status.FraudStatus,
+		GrossAmount:       strconv.FormatFloat(status.GrossAmount, 'f', 2, 64),
+		DonationStatus:    donationStatus,
+		Source:            models.NotificationSourceReconcile,
+	}
+	duplicate, err := r.donations.ApplyPaymentNotification(ctx, notification, status.PaymentType)
+	if err != nil {
+		return fail(err)
+	}
+	if !duplicate && notification.Result == models.NotificationResultApplied {
+		item.NewStatus = donationStatus
+		item.Result = models.ReconciliationResultUpdated
+	}
+	return item
+}
@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"zakat/models"
+	"zakat/pkg/zakat"
+	"zakat/repositories"
+)
+
+// ErrRateImportRunning dikembalikan jika impor harga referensi masih berjalan di instance ini
+var ErrRateImportRunning = errors.New("reference rate import is already running")
+
+// ErrUnsupportedFeed format feed bukan CSV atau JSON
+var ErrUnsupportedFeed = errors.New("reference rate feed must be CSV or JSON")
+
+// ReferenceRates sumber harga untuk kalkulator zakat dari tabel riwayat harga; jenis yang belum
+// punya riwayat memakai fallback (harga dari env)
+type ReferenceRates struct {
+	repo           repositories.ReferenceRateRepository
+	fallback       StaticZakatRates
+	regionalMaxAge time.Duration
+}
+
+// NewReferenceRates regionalMaxAge batas umur harga wilayah sebelum harga nasional yang lebih baru dipakai
+func NewReferenceRates(repo repositories.ReferenceRateRepository, fallback StaticZakatRates, regionalMaxAge time.Duration) *ReferenceRates {
+	return &ReferenceRates{repo: repo, fallback: fallback, regionalMaxAge: regionalMaxAge}
+}
+
+// RegionalRateMaxAgeFromEnv membaca REFERENCE_RATE_REGIONAL_MAX_AGE (default 8760h, satu tahun)
+func RegionalRateMaxAgeFromEnv() time.Duration {
+	return durationEnv("REFERENCE_RATE_REGIONAL_MAX_AGE", 365*24*time.Hour)
+}
+
+func (s *ReferenceRates) Rates(ctx context.Context, region string, on time.Time) (zakat.Rates, error) {
+	rates := zakat.Rates(s.fallback)
+	region = NormalizeRegion(region)
+	repo := s.repo.WithContext(ctx)
+	for kind, price := range map[string]*float64{
+		models.RateKindGold:   &rates.GoldPerGram,
+		models.RateKindSilver: &rates.SilverPerGram,
+		models.RateKindRice:   &rates.RicePerKg,
+		models.RateKindFitrah: &rates.FitrahPerPerson,
+	} {
+		rate, err := repo.Effective(kind, region, on, s.regionalMaxAge)
+		if err != nil {
+			return zakat.Rates{}, err
+		}
+		if rate != nil {
+			*price = rate.Price
+		}
+	}
+	return rates, nil
+}
+
+// Effective harga per jenis yang berlaku pada tanggal on; jenis tanpa riwayat tidak disertakan
+func (s *ReferenceRates) Effective(ctx context.Context, region string, on time.Time) (map[string]*models.ReferenceRate, error) {
+	region = NormalizeRegion(region)
+	repo := s.repo.WithContext(ctx)
+	result := make(map[string]*models.ReferenceRate)
+	for _, kind := range []string{models.RateKindGold, models.RateKindSilver, models.RateKindRice, models.RateKindFitrah} {
+		rate, err := repo.Effective(kind, region, on, s.regionalMaxAge)
+		if err != nil {
+			return nil, err
+		}
+		if rate != nil {
+			result[kind] = rate
+		}
+	}
+	return result, nil
+}
+
+// RateImportConfig pengaturan impor feed harga referensi
+type RateImportConfig struct {
+	FeedPath  string        // file CSV/JSON lokal; kosong menonaktifkan impor terjadwal
+	Interval  time.Duration // jarak antar impor; 0 menonaktifkan impor terjadwal
+	Authority string        // dicatat di setiap baris hasil impor
+}
+
+// RateImportConfigFromEnv membaca REFERENCE_RATE_FEED, REFERENCE_RATE_IMPORT_INTERVAL (default 24h)
+// dan REFERENCE_RATE_AUTHORITY
+func RateImportConfigFromEnv() RateImportConfig {
+	return RateImportConfig{
+		FeedPath:  os.Getenv("REFERENCE_RATE_FEED"),
+		Interval:  durationEnv("REFERENCE_RATE_IMPORT_INTERVAL", 24*time.Hour),
+		Authority: os.Getenv("REFERENCE_RATE_AUTHORITY"),
+	}
+}
+
+// RateImportResult ringkasan satu impor; baris yang tidak valid dilewati dan dilaporkan
+type RateImportResult struct {
+	Imported int      `json:"imported"`
+	Skipped  int      `json:"skipped"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// feedRow satu baris feed, sama untuk CSV (berdasarkan header) dan JSON
+type feedRow struct {
+	Kind          string  `json:"kind"`
+	Region        string  `json:"region"`
+	Price         float64 `json:"price"`
+	EffectiveFrom string  `json:"effective_from"` // YYYY-MM-DD
+	Authority     string  `json:"authority"`
+	Note          string  `json:"note"`
+}
+
+// RateImporter membaca feed harga referensi (CSV/JSON) dan menyimpannya sebagai riwayat
+type RateImporter struct {
+	config  RateImportConfig
+	repo    repositories.ReferenceRateRepository
+	running sync.Mutex
+}
+
+func NewRateImporter(config RateImportConfig, repo repositories.ReferenceRateRepository) *RateImporter {
+	return &RateImporter{config: config, repo: repo}
+}
+
+// FeedPath feed yang dikonfigurasi; kosong jika belum ada
+func (i *RateImporter) FeedPath() string {
+	return i.config.FeedPath
+}
+
+// Start mengimpor feed terjadwal di background sampai ctx dibatalkan
+func (i *RateImporter) Start(ctx context.Context) {
+	if i.config.FeedPath == "" || i.config.Interval <= 0 {
+		log.Println("[RateImporter] Scheduled reference rate import disabled")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(i.config.Interval)
+		defer ticker.Stop()
+		for {
+			result, err := i.ImportFile(ctx, i.config.FeedPath)
+			if err != nil && !errors.Is(err, ErrRateImportRunning) {
+				log.Printf("[RateImporter] Import failed: %v", err)
+			} else if result != nil {
+				log.Printf("[RateImporter] Imported %d rate(s), skipped %d", result.Imported, result.Skipped)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// ImportFile mengimpor feed lokal; format ditentukan dari ekstensi (.csv atau .json)
+func (i *RateImporter) ImportFile(ctx context.Context, path string) (*RateImportResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return i.Import(ctx, file, filepath.Ext(path))
+}
+
+// Import membaca feed dengan format "csv" atau "json" (boleh diawali titik)
+func (i *RateImporter) Import(ctx context.Context, feed io.Reader, format string) (*RateImportResult, error) {
+	if !i.running.TryLock() {
+		return nil, ErrRateImportRunning
+	}
+	defer i.running.Unlock()
+
+	var rows []feedRow
+	var err error
+	switch strings.ToLower(strings.TrimPrefix(format, ".")) {
+	case "csv":
+		rows, err = readCSVFeed(feed)
+	case "json":
+		err = json.NewDecoder(feed).Decode(&rows)
+	default:
+		return nil, ErrUnsupportedFeed
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read feed: %w", err)
+	}
+
+	result := &RateImportResult{}
+	rates := make([]models.ReferenceRate, 0, len(rows))
+	seen := make(map[string]int)
+	now := time.Now()
+	for n, row := range rows {
+		rate, err := i.feedRate(row, now)
+		if err != nil {
+			result.Skipped++
+			result.Errors = append(result.Errors, fmt.Sprintf("row %d: %v", n+1, err))
+			continue
+		}
+		// Kunci yang sama dalam satu feed: baris terakhir yang dipakai (upsert tidak boleh
+		// menyentuh baris yang sama dua kali)
+		key := rate.Kind + "|" + rate.Region + "|" + rate.EffectiveFrom.Format("2006-01-02")
+		if n, ok := seen[key]; ok {
+			rates[n] = rate
+			continue
+		}
+		seen[key] = len(rates)
+		rates = append(rates, rate)
+	}
+
+	if err := i.repo.WithContext(ctx).Import(rates); err != nil {
+		return nil, err
+	}
+	result.Imported = len(rates)
+	return result, nil
+}
+
+func (i *RateImporter) feedRate(row feedRow, now time.Time) (models.ReferenceRate, error) {
+	kind := strings.ToLower(strings.TrimSpace(row.Kind))
+	if !models.IsValidRateKind(kind) {
+		return models.ReferenceRate{}, fmt.Errorf("unknown kind %q", row.Kind)
+	}
+	if row.Price <= 0 {
+		return models.ReferenceRate{}, errors.New("price must be greater than zero")
+	}
+	effective, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(row.EffectiveFrom), time.Local)
+	if err != nil {
+		return models.ReferenceRate{}, fmt.Errorf("invalid effective_from %q", row.EffectiveFrom)
+	}
+	authority := strings.TrimSpace(row.Authority)
+	if authority == "" {
+		authority = i.config.Authority
+	}
+	return models.ReferenceRate{
+		Kind:          kind,
+		Region:        NormalizeRegion(row.Region),
+		EffectiveFrom: effective,
+		Source:        models.RateSourceImport,
+		Price:         row.Price,
+		Authority:     authority,
+		Note:          strings.TrimSpace(row.Note),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}, nil
+}
+
+// readCSVFeed membaca CSV dengan baris header; kolom wajib kind, price dan effective_from
+func readCSVFeed(feed io.Reader) ([]feedRow, error) {
+	reader := csv.NewReader(feed)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := make(map[string]int)
+	for n, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = n
+	}
+	for _, required := range []string{"kind", "price", "effective_from"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing column %q", required)
+		}
+	}
+	field := func(record []string, name string) string {
+		if n, ok := columns[name]; ok && n < len(record) {
+			return strings.TrimSpace(record[n])
+		}
+		return ""
+	}
+
+	rows := make([]feedRow, 0, len(records)-1)
+	for _, record := range records[1:] {
+		// Harga tidak valid dibiarkan 0 agar dilaporkan per baris, bukan menggagalkan seluruh feed
+		price, _ := strconv.ParseFloat(field(record, "price"), 64)
+		rows = append(rows, feedRow{
+			Kind:          field(record, "kind"),
+			Region:        field(record, "region"),
+			Price:         price,
+			EffectiveFrom: field(record, "effective_from"),
+			Authority:     field(record, "authority"),
+			Note:          field(record, "note"),
+		})
+	}
+	return rows, nil
+}
+
+// NormalizeRegion menyeragamkan penulisan wilayah (mis. "Kota Bandung" -> "kota bandung");
+// kosong berarti nasional
+func NormalizeRegion(region string) string {
+	return strings.ToLower(strings.Join(strings.Fields(region), " "))
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"zakat/models"
+	"zakat/pkg/payment"
+	"zakat/repositories"
+)
+
+// RefundService mengembalikan dana donasi: mencadangkan nominal, memanggil refund API gateway
+// di luar transaksi database, menyimpan hasilnya, lalu memberi tahu donatur
+type RefundService interface {
+	// Refund amount 0 berarti seluruh sisa donasi. Donasi transfer manual/offline tidak lewat gateway;
+	// refund dicatat langsung berhasil karena dana dikembalikan sendiri oleh amil.
+	Refund(ctx context.Context, donationID uint, amount float64, reason string, requestedByID int) (*models.Refund, error)
+	// SyncFromGateway mencatat refund yang dilaporkan gateway (webhook/status API)
+	SyncFromGateway(ctx context.Context, orderID, provider string, refunds []payment.Refund) error
+	// ResolvePending mengirim ulang refund yang masih pending sejak sebelum cutoff (mis. timeout ke gateway)
+	// dengan RefundKey yang sama, lalu menyimpan hasilnya. Mengembalikan jumlah refund yang selesai.
+	ResolvePending(ctx context.Context, before time.Time, limit int) (int, error)
+}
+
+type refundService struct {
+	donations    DonationService
+	payments     PaymentService
+	donationRepo repositories.DonationRepository
+	refundRepo   repositories.RefundRepository
+	email        *EmailService
+}
+
+func NewRefundService(donations DonationService, payments PaymentService, donationRepo repositories.DonationRepository, refundRepo repositories.RefundRepository, email *EmailService) RefundService {
+	return &refundService{donations: donations, payments: payments, donationRepo: donationRepo, refundRepo: refundRepo, email: email}
+}
+
+func (s *refundService) Refund(ctx context.Context, donationID uint, amount float64, reason string, requestedByID int) (*models.Refund, error) {
+	donation, err := s.donationRepo.GetByID(donationID)
+	if err != nil {
+		return nil, err
+	}
+	if donation == nil {
+		return nil, ErrRefundNotAllowed
+	}
+
+	provider := ""
+	if donation.Channel == "" || donation.Channel == models.DonationChannelOnline {
+		provider = donation.PaymentProvider
+		if provider == "" {
+			provider = models.PaymentProviderMidtrans
+		}
+	}
+
+	refund, err := s.donations.RequestRefund(ctx, donationID, amount, reason, requestedByID, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	if provider != "" {
+		return s.submit(ctx, donation, refund)
+	}
+
+	refund, changed, err := s.donations.CompleteRefund(ctx, refund.ID, payment.Refund{Status: payment.RefundStatusSucceeded}, "")
+	if err != nil {
+		return nil, err
+	}
+	if changed && refund.Status == models.RefundStatusSucceeded {
+		s.notify(refund)
+	}
+	return refund, nil
+}
+
+// submit mengirim refund ke gateway lalu menyimpan hasilnya. Hanya penolakan yang pasti
+// (payment.ErrRefundRejected) yang mencatat refund failed; timeout atau 5xx membiarkannya pending
+// karena gateway bisa saja sudah memprosesnya. Hasilnya menyusul lewat webhook atau ResolvePending.
+func (s *refundService) submit(ctx context.Context, donation *models.Donation, refund *models.Refund) (*models.Refund, error) {
+	result, gatewayErr := s.payments.Refund(ctx, *donation, payment.RefundRequest{
+		RefundKey: refund.RefundKey,
+		Amount:    refund.Amount,
+		Reason:    refund.Reason,
+	})
+	failure := ""
+	switch {
+	case errors.Is(gatewayErr, payment.ErrRefundRejected), errors.Is(gatewayErr, payment.ErrUnknownProvider):
+		result, failure = &payment.Refund{Status: payment.RefundStatusFailed}, gatewayErr.Error()
+	case gatewayErr != nil:
+		log.Printf("[Refund] Gateway result for refund %d is unknown, keeping it pending: %v", refund.ID, gatewayErr)
+		return refund, nil
+	}
+
+	refund, changed, err := s.donations.CompleteRefund(ctx, refund.ID, *result, failure)
+	if err != nil {
+		return nil, err
+	}
+	if failure != "" {
+		return refund, fmt.Errorf("%w: %s", ErrRefundGateway, failure)
+	}
+	if changed && refund.Status == models.RefundStatusSucceeded {
+		s.notify(refund)
+	}
+	return refund, nil
+}
+
+func (s *refundService) ResolvePending(ctx context.Context, before time.Time, limit int) (int, error) {
+	refunds, err := s.refundRepo.PendingBefore(before, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	resolved := 0
+	for i := range refunds {
+		donation, err := s.donationRepo.GetByID(uint(refunds[i].DonationID))
+		if err != nil {
+			return resolved, err
+		}
+		if donation == nil {
+			continue
+		}
+		// RefundKey yang sama menjadi idempotency key; gateway tidak akan mengembalikan dana dua kali
+		refund, err := s.submit(ctx, donation, &refunds[i])
+		if err != nil && !errors.Is(err, ErrRefundGateway) {
+			log.Printf("[Refund] Failed to resolve refund %d: %v", refunds[i].ID, err)
+			continue
+		}
+		if refund.Status != models.RefundStatusPending {
+			resolved++
+		}
+	}
+	return resolved, nil
+}
+
+func (s *refundService) SyncFromGateway(ctx context.Context, orderID, provider string, refunds []payment.Refund) error {
+	settled, err := s.donations.SyncRefunds(ctx, orderID, provider, refunds)
+	if err != nil {
+		return err
+	}
+	for i := range settled {
+		if settled[i].Status == models.RefundStatusSucceeded {
+			s.notify(&settled[i])
+		}
+	}
+	return nil
+}
+
+// notify kegagalan kirim email hanya dicatat; refund tetap berhasil
+func (s *refundService) notify(refund *models.Refund) {
+	donation, err := s.donationRepo.GetByID(uint(refund.DonationID))
+	if err != nil || donation == nil || s.email == nil {
+		return
+	}
+
+	name, email, _ := donation.DonorContact()
+	if email == "" {
+		return
+	}
+	full := donation.Status == models.DonationStatusRefunded
+	if err := s.email.SendRefundEmail(email, name, donation.Campaign.Title, refund.Amount, full); err != nil {
+		log.Printf("[Refund] Failed to notify donor for refund %d: %v", refund.ID, err)
+	}
+}
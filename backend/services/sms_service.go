@@ -0,0 +1,33 @@
+package services
+
+import (
+	"fmt"
+	"os"
+)
+
+type SMSService struct {
+	APIKey     string
+	SenderID   string
+	GatewayURL string
+}
+
+func NewSMSService() *SMSService {
+	return &SMSService{
+		APIKey:     os.Getenv("SMS_API_KEY"),
+		SenderID:   os.Getenv("SMS_SENDER_ID"),
+		GatewayURL: os.Getenv("SMS_GATEWAY_URL"),
+	}
+}
+
+func (ss *SMSService) SendOTPMessage(to, code string, ttlMinutes int) error {
+	message := fmt.Sprintf("AmalSAS: kode OTP Anda %s (berlaku %d menit). Jangan berikan kode ini kepada siapa pun.", code, ttlMinutes)
+
+	// Simulasi kirim SMS
+	fmt.Printf("=== SMS OTP ===\n")
+	fmt.Printf("Kepada: %s\n", to)
+	fmt.Printf("Dari: %s\n", ss.SenderID)
+	fmt.Printf("Pesan: %s\n", message)
+	fmt.Printf("===============\n")
+
+	return nil
+}
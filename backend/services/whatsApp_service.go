@@ -37,3 +37,36 @@ func (ws *WhatsAppService) SendResetMessage(to, token string) error {
 
 	return nil
 }
+
+func (ws *WhatsAppService) SendOTPMessage(to, code string, ttlMinutes int) error {
+	message := fmt.Sprintf(
+		"Kode OTP AmalSAS Anda: %s\n\nBerlaku %d menit. Jangan berikan kode ini kepada siapa pun, termasuk pihak yang mengaku dari AmalSAS.",
+		code, ttlMinutes,
+	)
+
+	// Simulasi kirim WA
+	fmt.Printf("=== WHATSAPP OTP ===\n")
+	fmt.Printf("Kepada: %s\n", to)
+	fmt.Printf("Dari: %s\n", ws.FromNumber)
+	fmt.Printf("Pesan: %s\n", message)
+	fmt.Printf("====================\n")
+
+	return nil
+}
+
+func (ws *WhatsAppService) SendAdminInviteMessage(to, role, token string, ttlHours int) error {
+	inviteLink := fmt.Sprintf("%s/accept-invite?token=%s", ws.BaseURL, token)
+	message := fmt.Sprintf(
+		"Assalamu'alaikum! Anda diundang menjadi pengelola AmalSAS dengan peran %s. Masuk ke akun Anda lalu buka link berikut: %s\n\nLink berlaku %d jam dan hanya bisa dipakai sekali.",
+		role, inviteLink, ttlHours,
+	)
+
+	// Simulasi kirim WA
+	fmt.Printf("=== WHATSAPP INVITE ===\n")
+	fmt.Printf("Kepada: %s\n", to)
+	fmt.Printf("Dari: %s\n", ws.FromNumber)
+	fmt.Printf("Pesan: %s\n", message)
+	fmt.Printf("=======================\n")
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+	"zakat/pkg/zakat"
+)
+
+// ZakatRateSource menyediakan harga referensi emas, perak dan beras yang berlaku
+// di suatu wilayah pada tanggal tertentu
+type ZakatRateSource interface {
+	Rates(ctx context.Context, region string, on time.Time) (zakat.Rates, error)
+}
+
+// StaticZakatRates harga referensi tetap untuk semua wilayah dan tanggal
+type StaticZakatRates zakat.Rates
+
+func (r StaticZakatRates) Rates(ctx context.Context, region string, on time.Time) (zakat.Rates, error) {
+	return zakat.Rates(r), nil
+}
+
+// ZakatConfig pengaturan kalkulator zakat
+type ZakatConfig struct {
+	Calculator zakat.Config
+	// CampaignID campaign penampung zakat yang disarankan ke klien; 0 jika belum ada
+	CampaignID int
+}
+
+// ZakatConfigFromEnv membaca ZAKAT_NISAB_GOLD_GRAMS (default 85), ZAKAT_NISAB_SILVER_GRAMS (595),
+// ZAKAT_FITRAH_RICE_KG (2.5), ZAKAT_DEFAULT_MADHHAB (standard) dan ZAKAT_CAMPAIGN_ID
+func ZakatConfigFromEnv() ZakatConfig {
+	config := ZakatConfig{
+		Calculator: zakat.Config{
+			NisabGoldGrams:   floatEnv("ZAKAT_NISAB_GOLD_GRAMS"),
+			NisabSilverGrams: floatEnv("ZAKAT_NISAB_SILVER_GRAMS"),
+			FitrahRiceKg:     floatEnv("ZAKAT_FITRAH_RICE_KG"),
+		},
+	}
+	if madhhab := os.Getenv("ZAKAT_DEFAULT_MADHHAB"); zakat.IsValidMadhhab(madhhab) {
+		config.Calculator.DefaultMadhhab = madhhab
+	}
+	if n, err := strconv.Atoi(os.Getenv("ZAKAT_CAMPAIGN_ID")); err == nil && n > 0 {
+		config.CampaignID = n
+	}
+	return config
+}
+
+// ZakatRatesFromEnv harga referensi dari ZAKAT_GOLD_PRICE_PER_GRAM, ZAKAT_SILVER_PRICE_PER_GRAM,
+// ZAKAT_RICE_PRICE_PER_KG dan ZAKAT_FITRAH_PER_PERSON
+func ZakatRatesFromEnv() StaticZakatRates {
+	return StaticZakatRates{
+		GoldPerGram:     floatEnv("ZAKAT_GOLD_PRICE_PER_GRAM"),
+		SilverPerGram:   floatEnv("ZAKAT_SILVER_PRICE_PER_GRAM"),
+		RicePerKg:       floatEnv("ZAKAT_RICE_PRICE_PER_KG"),
+		FitrahPerPerson: floatEnv("ZAKAT_FITRAH_PER_PERSON"),
+	}
+}
+
+func floatEnv(key string) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil || value < 0 {
+		return 0
+	}
+	return value
+}
+
+// NisabInfo ambang yang berlaku di satu wilayah; nilai 0 jika harga referensinya belum ada
+type NisabInfo struct {
+	Region          string      `json:"region,omitempty"`
+	On              time.Time   `json:"on"`
+	GoldGrams       float64     `json:"gold_grams"`
+	SilverGrams     float64     `json:"silver_grams"`
+	GoldNisab       float64     `json:"gold_nisab"`
+	SilverNisab     float64     `json:"silver_nisab"`
+	FitrahRiceKg    float64     `json:"fitrah_rice_kg"`
+	FitrahPerPerson float64     `json:"fitrah_per_person"`
+	Rates           zakat.Rates `json:"rates"`
+}
+
+// ZakatService menghitung zakat dengan harga referensi yang berlaku pada tanggal perhitungan
+type ZakatService struct {
+	calculator *zakat.Calculator
+	rates      ZakatRateSource
+	campaignID int
+}
+
+func NewZakatService(config ZakatConfig, rates ZakatRateSource) *ZakatService {
+	return &ZakatService{
+		calculator: zakat.NewCalculator(config.Calculator),
+		rates:      rates,
+		campaignID: config.CampaignID,
+	}
+}
+
+// CampaignID campaign zakat yang disarankan untuk membayar hasil perhitungan
+func (s *ZakatService) CampaignID() int {
+	return s.campaignID
+}
+
+func (s *ZakatService) Calculate(ctx context.Context, region string, req zakat.Request) (*zakat.Result, error) {
+	if req.On.IsZero() {
+		req.On = time.Now()
+	}
+	rates, err := s.rates.Rates(ctx, region, req.On)
+	if err != nil {
+		return nil, err
+	}
+	return s.calculator.Calculate(req, rates)
+}
+
+func (s *ZakatService) Nisab(ctx context.Context, region string, on time.Time) (*NisabInfo, error) {
+	rates, err := s.rates.Rates(ctx, region, on)
+	if err != nil {
+		return nil, err
+	}
+	config := s.calculator.Config()
+	info := &NisabInfo{
+		Region:       region,
+		On:           on,
+		GoldGrams:    config.NisabGoldGrams,
+		SilverGrams:  config.NisabSilverGrams,
+		FitrahRiceKg: config.FitrahRiceKg,
+		Rates:        rates,
+	}
+	// Harga yang belum tersedia dibiarkan 0 agar klien tetap mendapat data yang ada
+	info.GoldNisab, _ = s.calculator.Nisab(zakat.NisabBasisGold, rates)
+	info.SilverNisab, _ = s.calculator.Nisab(zakat.NisabBasisSilver, rates)
+	info.FitrahPerPerson, _ = s.calculator.FitrahPerPerson(rates)
+	return info, nil
+}
@@ -14,6 +14,7 @@ const CampaignForm = ({ initialData = {}, isEdit = false, onSuccess }) => {
     status: 'active',
     target_total: '',
     category: '',
+    fund_type: '',
     isCustomCategory: false,
     location: '',
     photo: null,
@@ -88,6 +89,7 @@ const CampaignForm = ({ initialData = {}, isEdit = false, onSuccess }) => {
         end: new Date(formData.end).toISOString(),
         target_total: parseFloat(formData.target_total),
         category: formData.category,
+        fund_type: formData.fund_type,
         location: formData.location,
         status: formData.status,
         cpocket: formData.cpocket,
@@ -115,6 +117,7 @@ const CampaignForm = ({ initialData = {}, isEdit = false, onSuccess }) => {
       formDataToSend.append('end', new Date(formData.end).toISOString().split('T')[0]); // Format YYYY-MM-DD
       formDataToSend.append('target_total', formData.target_total);
       formDataToSend.append('category', formData.category);
+      formDataToSend.append('fund_type', formData.fund_type);
       formDataToSend.append('location', formData.location);
       formDataToSend.append('status', formData.status);
       formDataToSend.append('cpocket', formData.cpocket);
@@ -251,6 +254,35 @@ const CampaignForm = ({ initialData = {}, isEdit = false, onSuccess }) => {
           />
         </div>
 
+        <div>
+          <label style={{ display: 'block', marginBottom: '8px', fontWeight: 'bold', color: '#555' }}>
+            Jenis Dana:
+          </label>
+          <select
+            name="fund_type"
+            value={formData.fund_type}
+            onChange={handleChange}
+            required
+            style={{ 
+              width: '100%', 
+              padding: '12px', 
+              borderRadius: '6px', 
+              border: '1px solid #ddd',
+              fontSize: '16px',
+              boxSizing: 'border-box',
+              backgroundColor: 'white'
+            }}
+          >
+            <option value="">Pilih Jenis Dana</option>
+            <option value="zakat">Zakat</option>
+            <option value="infaq">Infaq</option>
+            <option value="sedekah">Sedekah</option>
+            <option value="wakaf">Wakaf</option>
+            <option value="qurban">Qurban</option>
+            <option value="fidyah">Fidyah</option>
+          </select>
+        </div>
+
         <div>
           <label style={{ display: 'block', marginBottom: '8px', fontWeight: 'bold', color: '#555' }}>
             Kategori:
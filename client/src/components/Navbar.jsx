@@ -243,9 +243,15 @@ export default function Navbar() {
     setIsMenuOpen(false);
   };
 
-  const handleLogout = () => {
+  const handleLogout = async () => {
+    try {
+      await API.post("/logout");
+    } catch (error) {
+      console.error("Logout error:", error);
+    }
     dispatch({ type: "LOGOUT" });
     localStorage.removeItem("token");
+    localStorage.removeItem("refresh_token");
     setAuthToken();
   };
 
@@ -26,6 +26,7 @@ export default function SignInModal({ show, onHide, openSignUp }) {
     },
     onSuccess: (data) => {
       localStorage.setItem("token", data.data.token); 
+      localStorage.setItem("refresh_token", data.data.refresh_token);
       onHide();
       window.location.reload();
     },
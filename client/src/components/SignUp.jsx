@@ -1,16 +1,13 @@
 import React, { useState, useRef, useEffect } from "react";
 import { Modal, Form, Button, Alert, Spinner } from "react-bootstrap";
-import { useMutation, useQuery } from '@tanstack/react-query';
+import { useMutation } from '@tanstack/react-query';
 import { API } from "../config/api";
-import { FaUser, FaEnvelope, FaPhone, FaLock, FaHome, FaCrown } from "react-icons/fa";
+import { FaUser, FaEnvelope, FaPhone, FaLock, FaHome } from "react-icons/fa";
 import './SignUp.css';
 
 export default function SignUpModal({ show, onHide, openSignIn }) {
   const [message, setMessage] = useState(null);
   const [validated, setValidated] = useState(false);
-  const [userType, setUserType] = useState('user');
-  const [adminCount, setAdminCount] = useState(0);
-  const [canCreateAdmin, setCanCreateAdmin] = useState(true);
   
   const [form, setForm] = useState({
     first_name: "",
@@ -20,34 +17,11 @@ export default function SignUpModal({ show, onHide, openSignIn }) {
     address: "",
     email: "",
     password: "",
-    is_admin: true
   });
 
   const formRef = useRef(null);
 
   
-  const { data: adminData, isLoading: isLoadingAdminCount } = useQuery({
-    queryKey: ['adminCount'],
-    queryFn: async () => {
-      try {
-        const response = await API.get("/admin-count");
-        return response.data;
-      } catch (error) {
-        console.error("Error fetching admin count:", error);
-        return { admin_count: 0, can_create_admin: true };
-      }
-    },
-    enabled: show,
-    onSuccess: (data) => {
-      setAdminCount(data.admin_count || 0);
-      setCanCreateAdmin(data.can_create_admin !== false);
-      
-      if (!data.can_create_admin && userType === 'admin') {
-        setUserType('user');
-      }
-    }
-  });
-
   const handleChange = (e) => {
     const { name, value } = e.target;
     setForm(prev => ({
@@ -56,11 +30,6 @@ export default function SignUpModal({ show, onHide, openSignIn }) {
     }));
   };
 
-  const handleUserTypeChange = (type) => {
-    if (type === 'admin' && !canCreateAdmin) return;
-    setUserType(type);
-  };
-
   const { mutate, isLoading } = useMutation({
     mutationFn: async (payload) => {
       console.log('Sending payload:', payload);
@@ -70,9 +39,7 @@ export default function SignUpModal({ show, onHide, openSignIn }) {
     onSuccess: (data) => {
       setMessage({
         type: 'success',
-        text: userType === 'admin' 
-          ? 'Registrasi admin berhasil! Mengarahkan ke halaman login...' 
-          : 'Registrasi berhasil! Mengarahkan ke halaman login...'
+        text: 'Registrasi berhasil! Mengarahkan ke halaman login...'
       });
       setTimeout(() => {
         onHide();
@@ -85,19 +52,10 @@ export default function SignUpModal({ show, onHide, openSignIn }) {
       
       const errorMessage = error.response?.data?.message || "Registrasi gagal. Silakan coba lagi.";
       
-      if (error.response?.status === 403 && errorMessage.includes("admin limit")) {
-        setMessage({
-          type: 'error',
-          text: 'Batas maksimal admin (3) telah tercapai. Silakan daftar sebagai user biasa.'
-        });
-        setUserType('user');
-        setCanCreateAdmin(false);
-      } else {
-        setMessage({
-          type: 'error',
-          text: errorMessage
-        });
-      }
+      setMessage({
+        type: 'error',
+        text: errorMessage
+      });
     }
   });
 
@@ -118,14 +76,6 @@ export default function SignUpModal({ show, onHide, openSignIn }) {
       return;
     }
 
-    if (userType === 'admin' && !canCreateAdmin) {
-      setMessage({
-        type: 'error',
-        text: 'Tidak dapat membuat admin baru. Batas maksimal telah tercapai.'
-      });
-      return;
-    }
-
     setValidated(true);
 
     let formattedPhone = form.phone;
@@ -142,8 +92,7 @@ export default function SignUpModal({ show, onHide, openSignIn }) {
       phone: formattedPhone,
       address: form.address,
       email: form.email,
-      password: form.password,
-      is_admin: userType === 'admin'
+      password: form.password
     };
 
     console.log('Calling mutate with payload:', payload);
@@ -154,7 +103,6 @@ export default function SignUpModal({ show, onHide, openSignIn }) {
     if (show) {
       setMessage(null);
       setValidated(false);
-      setUserType('user');
       setForm({
         first_name: "",
         last_name: "",
@@ -184,13 +132,6 @@ export default function SignUpModal({ show, onHide, openSignIn }) {
       </Modal.Header>
       
       <Modal.Body className="modal-body">
-        {isLoadingAdminCount && (
-          <div className="text-center mb-3">
-            <Spinner animation="border" size="sm" />
-            <span className="ms-2">Memeriksa ketersediaan admin...</span>
-          </div>
-        )}
-
         {message && (
           <Alert 
             variant={message.type === 'success' ? 'success' : 'danger'}
@@ -207,39 +148,6 @@ export default function SignUpModal({ show, onHide, openSignIn }) {
           ref={formRef}
           className="signup-form"
         >
-          {/* Role Selection */}
-          <Form.Group className="form-group">
-            <Form.Label>
-              <FaCrown className="icon" />
-              Peran (Role)
-            </Form.Label>
-            {canCreateAdmin ? (
-              <>
-                <Form.Select
-                  required
-                  name="role"
-                  value={userType}
-                  onChange={(e) => handleUserTypeChange(e.target.value)}
-                >
-                  <option value="user">Donatur</option>
-                  <option value="admin">Admin</option>
-                </Form.Select>
-                {userType === 'admin' && (
-                  <Form.Text className="text-muted">
-                    Status admin: {adminCount}/3 terisi
-                  </Form.Text>
-                )}
-              </>
-            ) : (
-              <div className="alert alert-warning py-2 mb-0">
-                <small>
-                  <FaCrown className="me-1" />
-                  Batas maksimal admin (3) telah tercapai. Otomatis terdaftar sebagai Donatur.
-                </small>
-              </div>
-            )}
-          </Form.Group>
-
           <div className="name-fields">
             <Form.Group className="form-group">
               <Form.Label>
@@ -391,9 +299,9 @@ export default function SignUpModal({ show, onHide, openSignIn }) {
 
           <Button 
             type="submit"
-            variant={userType === 'admin' ? 'warning' : 'primary'}
+            variant="primary"
             className="submit-button"
-            disabled={isLoading || (userType === 'admin' && !canCreateAdmin)}
+            disabled={isLoading}
             onClick={(e) => {
               console.log('Button onClick triggered');
             }}
@@ -401,12 +309,7 @@ export default function SignUpModal({ show, onHide, openSignIn }) {
             {isLoading && (
               <Spinner animation="border" size="sm" className="me-2" />
             )}
-            {isLoading 
-              ? "Memproses..." 
-              : userType === 'admin' 
-                ? "Daftar sebagai Admin" 
-                : "Daftar sebagai Donatur"
-            }
+            {isLoading ? "Memproses..." : "Daftar sebagai Donatur"}
           </Button>
         </Form>
 
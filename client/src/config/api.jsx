@@ -16,6 +16,47 @@ export const setAuthToken = (token) => {
   }
 };
 
+// Access token berumur pendek: saat 401, tukar refresh token lalu ulangi request
+let refreshRequest = null;
+
+API.interceptors.response.use(
+  (response) => response,
+  async (error) => {
+    const original = error.config;
+    const refreshToken = localStorage.getItem("refresh_token");
+
+    if (
+      error.response?.status !== 401 ||
+      !refreshToken ||
+      original._retry ||
+      original.url === "/refresh"
+    ) {
+      return Promise.reject(error);
+    }
+
+    original._retry = true;
+    try {
+      if (!refreshRequest) {
+        refreshRequest = API.post("/refresh", { refresh_token: refreshToken }).finally(() => {
+          refreshRequest = null;
+        });
+      }
+      const res = await refreshRequest;
+      const { token, refresh_token } = res.data.data;
+      localStorage.setItem("token", token);
+      localStorage.setItem("refresh_token", refresh_token);
+      setAuthToken(token);
+      original.headers["Authorization"] = `Bearer ${token}`;
+      return API(original);
+    } catch (refreshError) {
+      localStorage.removeItem("token");
+      localStorage.removeItem("refresh_token");
+      setAuthToken();
+      return Promise.reject(error);
+    }
+  }
+);
+
 export const getImageUrl = (photo) => {
   if (!photo) return "https://via.placeholder.com/600x400?text=No+Image";
   if (photo.startsWith("http")) return photo;